@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/job"
+)
+
+// App 服务运行需要的全部组件
+type App struct {
+	server    *gin.Engine
+	scheduler *job.Scheduler
+}
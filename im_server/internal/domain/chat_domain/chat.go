@@ -0,0 +1,52 @@
+package chat_domain
+
+import "time"
+
+// Chat 私聊消息领域对象
+type Chat struct {
+	ID          int64     `json:"id"`
+	CreateTime  time.Time `json:"createTime"`
+	UpdateTime  time.Time `json:"updateTime"`
+	MsgType     int8      `json:"msgType"`
+	MsgPreview  string    `json:"msgPreview"`
+	Msg         Msg       `json:"msg"`
+	SendUserID  int64     `json:"sendUserID"`
+	RevUserID   int64     `json:"revUserID"`
+	Edited      bool      `json:"edited"`
+	TempGroupID int64     `json:"tempGroupID"` // 临时会话来源群ID，好友之间的消息为 0
+
+	IsRead    bool       `json:"isRead"`    // 对方是否已读，只对自己发送的消息有意义
+	Reactions []Reaction `json:"reactions"` // 表情回应汇总
+}
+
+// GroupMsg 群消息领域对象
+type GroupMsg struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+	MsgType    int8      `json:"msgType"`
+	MsgPreview string    `json:"msgPreview"`
+	Msg        Msg       `json:"msg"`
+	GroupID    int64     `json:"groupID"`
+	SendUserID int64     `json:"sendUserID"`
+	Edited     bool      `json:"edited"`
+
+	SendNickname string     `json:"sendNickname"` // 发送者在群里展示的名字，群昵称优先
+	ReadCount    int        `json:"readCount"`    // 已读人数，只对自己发送的消息有意义
+	MemberCount  int        `json:"memberCount"`  // 除发送者以外的群成员数，即 "N/M 已读" 中的 M
+	Reactions    []Reaction `json:"reactions"`    // 表情回应汇总
+}
+
+// Conversation 用户的会话状态
+type Conversation struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+	UserID     int64     `json:"userID"`
+	ConvType   int8      `json:"convType"`
+	ConvID     int64     `json:"convID"`     // 私聊为对方用户ID，群聊为群ID
+	ReadMsgID  int64     `json:"readMsgID"`  // 已读游标，小于等于该ID的消息都视为已读
+	ClearMsgID int64     `json:"clearMsgID"` // 清空游标，群聊中小于等于该ID的消息不再可见
+	IsPinned   bool      `json:"isPinned"`   // 是否置顶
+	PinTime    time.Time `json:"pinTime"`    // 置顶时间，未置顶为零值
+}
@@ -0,0 +1,44 @@
+package chat_domain
+
+import "errors"
+
+// maxDeleteMsgs 一次最多删除的消息数
+const maxDeleteMsgs = 100
+
+var (
+	ErrDeleteEmpty   = errors.New("未选择要删除的消息")
+	ErrDeleteTooMany = errors.New("一次最多删除100条消息")
+)
+
+// DeleteRequest 删除消息请求体，只对自己生效
+type DeleteRequest struct {
+	UserID   int64   `json:"-"`
+	ConvType int8    `json:"convType"`
+	ConvID   int64   `json:"convID"` // 私聊为对方用户ID，群聊为群ID
+	MsgIDs   []int64 `json:"msgIDs"`
+}
+
+// Validate 校验要删除的消息数量
+func (req DeleteRequest) Validate() error {
+	if len(req.MsgIDs) == 0 {
+		return ErrDeleteEmpty
+	}
+	if len(req.MsgIDs) > maxDeleteMsgs {
+		return ErrDeleteTooMany
+	}
+	return nil
+}
+
+// ClearRequest 清空会话请求体，只对自己生效
+type ClearRequest struct {
+	UserID   int64 `json:"-"`
+	ConvType int8  `json:"convType"`
+	ConvID   int64 `json:"convID"`
+}
+
+// DeleteEvent 删除消息或清空会话后推送给自己的其他端，MsgIDs 为空表示清空整个会话
+type DeleteEvent struct {
+	ConvType int8    `json:"convType"`
+	ConvID   int64   `json:"convID"`
+	MsgIDs   []int64 `json:"msgIDs,omitempty"`
+}
@@ -0,0 +1,22 @@
+package chat_domain
+
+import "time"
+
+// EditRequest 编辑消息请求体，目前只支持编辑文本消息
+type EditRequest struct {
+	UserID   int64  `json:"-"`
+	ConvType int8   `json:"convType"`
+	MsgID    int64  `json:"msgID"`
+	Content  string `json:"content"`
+}
+
+// MsgEdit 消息的一个历史版本
+type MsgEdit struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"` // 编辑时间
+	ConvType   int8      `json:"convType"`
+	MsgID      int64     `json:"msgID"`
+	EditUserID int64     `json:"editUserID"`
+	Msg        Msg       `json:"msg"`     // 编辑前的消息内容
+	MsgTime    time.Time `json:"msgTime"` // 这个版本产生的时间
+}
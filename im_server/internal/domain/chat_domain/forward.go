@@ -0,0 +1,107 @@
+package chat_domain
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	maxForwardMsgs    = 100 // 一次最多转发的消息数
+	maxForwardTargets = 9   // 一次最多转发到的会话数
+	mergeSummaryLen   = 4   // 合并转发卡片展示的摘要条数
+)
+
+var (
+	ErrForwardEmpty         = errors.New("未选择要转发的消息")
+	ErrForwardTooMany       = errors.New("一次最多转发100条消息")
+	ErrForwardTargetEmpty   = errors.New("未选择转发到的会话")
+	ErrForwardTargetTooMany = errors.New("一次最多转发到9个会话")
+	ErrForwardNotSupported  = errors.New("该类型的消息不能转发")
+)
+
+// ForwardTarget 转发到的会话
+type ForwardTarget struct {
+	ConvType int8  `json:"convType"`
+	ConvID   int64 `json:"convID"` // 私聊为对方用户ID，群聊为群ID
+}
+
+// ForwardRequest 转发消息请求体
+// 被转发的消息必须来自同一个会话，Merge 为 true 时合并为一条聊天记录，否则逐条转发
+type ForwardRequest struct {
+	UserID   int64           `json:"-"`
+	ConvType int8            `json:"convType"` // 被转发消息所在的会话类型
+	ConvID   int64           `json:"convID"`   // 被转发消息所在的会话ID
+	MsgIDs   []int64         `json:"msgIDs"`
+	Merge    bool            `json:"merge"`
+	Targets  []ForwardTarget `json:"targets"`
+}
+
+// Validate 校验转发的消息数量和会话数量
+func (req ForwardRequest) Validate() error {
+	if len(req.MsgIDs) == 0 {
+		return ErrForwardEmpty
+	}
+	if len(req.MsgIDs) > maxForwardMsgs {
+		return ErrForwardTooMany
+	}
+	if len(req.Targets) == 0 {
+		return ErrForwardTargetEmpty
+	}
+	if len(req.Targets) > maxForwardTargets {
+		return ErrForwardTargetTooMany
+	}
+	return nil
+}
+
+// ForwardedMsg 转发后在目标会话中生成的消息
+type ForwardedMsg struct {
+	ConvType int8      `json:"convType"`
+	ConvID   int64     `json:"convID"`
+	Chat     *Chat     `json:"chat,omitempty"`
+	GroupMsg *GroupMsg `json:"groupMsg,omitempty"`
+}
+
+// ForwardCopy 逐条转发时生成的新消息
+// 回复、引用、@ 的对象只在原会话中有意义，转为普通文本；通话和撤回消息不能转发
+func (m Msg) ForwardCopy() (Msg, error) {
+	var res Msg
+	switch m.Type {
+	case MsgTypeVoiceCall, MsgTypeVideoCall, MsgTypeWithdraw:
+		return Msg{}, ErrForwardNotSupported
+	case MsgTypeReply, MsgTypeQuote, MsgTypeAt:
+		content := m.SearchText()
+		res = Msg{Type: MsgTypeText, Content: &content}
+	default:
+		res = m
+	}
+	res.Forwarded = true
+	return res, nil
+}
+
+// Clone 深拷贝消息，同一条消息转发到多个会话时，每个会话中的新消息分别替换附件ID
+func (m Msg) Clone() (Msg, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return Msg{}, err
+	}
+	var res Msg
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+// NewMergeMsg 把 items 合并为一条聊天记录消息
+func NewMergeMsg(title string, items []MergeItem) Msg {
+	summary := make([]string, 0, mergeSummaryLen)
+	for i := 0; i < len(items) && i < mergeSummaryLen; i++ {
+		summary = append(summary, items[i].SendNickname+"："+items[i].Msg.Preview())
+	}
+	return Msg{
+		Type: MsgTypeMerge,
+		MergeMsg: &MergeMsg{
+			Title:   title,
+			Summary: summary,
+			Items:   items,
+		},
+		Forwarded: true,
+	}
+}
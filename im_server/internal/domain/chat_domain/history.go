@@ -0,0 +1,31 @@
+package chat_domain
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// HistoryRequest 历史消息请求体
+// 按消息ID倒序分页，LastID 为上一页最后一条消息ID，第一页传 0
+type HistoryRequest struct {
+	UserID int64 `form:"-"`
+	ConvID int64 `form:"convID"` // 私聊为对方用户ID，群聊为群ID
+	LastID int64 `form:"lastID"`
+	Limit  int   `form:"limit"`
+}
+
+// PageLimit 返回修正后的分页大小
+func (req HistoryRequest) PageLimit() int {
+	return pageLimit(req.Limit, defaultHistoryLimit, maxHistoryLimit)
+}
+
+// pageLimit 没传分页大小时使用默认值，超过上限时使用上限
+func pageLimit(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
@@ -0,0 +1,38 @@
+package chat_domain
+
+import "time"
+
+// Mention 我被@的记录
+type Mention struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"`
+	UserID     int64     `json:"userID"`
+	GroupID    int64     `json:"groupID"`
+	MsgID      int64     `json:"msgID"`
+	SendUserID int64     `json:"sendUserID"`
+	IsAll      bool      `json:"isAll"`
+
+	IsRead   bool      `json:"isRead"`   // 群聊已读游标是否已经越过这条消息
+	GroupMsg *GroupMsg `json:"groupMsg"` // 被@的群消息，方便客户端直接跳转
+}
+
+// MentionRequest 我被@的列表请求体，按ID倒序分页
+type MentionRequest struct {
+	UserID  int64 `form:"-"`
+	GroupID int64 `form:"groupID"` // 只看某个群，0 表示全部群
+	LastID  int64 `form:"lastID"`
+	Limit   int   `form:"limit"`
+}
+
+// PageLimit 返回修正后的分页大小
+func (req MentionRequest) PageLimit() int {
+	return pageLimit(req.Limit, defaultHistoryLimit, maxHistoryLimit)
+}
+
+// AtEvent @提醒，推送给被@的用户
+type AtEvent struct {
+	GroupID    int64 `json:"groupID"`
+	MsgID      int64 `json:"msgID"`
+	SendUserID int64 `json:"sendUserID"`
+	IsAll      bool  `json:"isAll"`
+}
@@ -0,0 +1,556 @@
+package chat_domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型
+const (
+	MsgTypeText      int8 = 1  // 文本类型
+	MsgTypeImage     int8 = 2  // 图片消息
+	MsgTypeVideo     int8 = 3  // 视频消息
+	MsgTypeFile      int8 = 4  // 文件消息
+	MsgTypeVoice     int8 = 5  // 语音消息
+	MsgTypeVoiceCall int8 = 6  // 语言通话
+	MsgTypeVideoCall int8 = 7  // 视频通话
+	MsgTypeWithdraw  int8 = 8  // 撤回消息
+	MsgTypeReply     int8 = 9  // 回复消息
+	MsgTypeQuote     int8 = 10 // 引用消息
+	MsgTypeAt        int8 = 11 // @消息
+	MsgTypeMerge     int8 = 12 // 合并转发
+)
+
+// 会话类型
+const (
+	ConvTypeChat  int8 = 1 // 私聊
+	ConvTypeGroup int8 = 2 // 群聊
+)
+
+// maxAtUsers 一条消息最多@的用户数，更多请使用@所有人
+const maxAtUsers = 50
+
+// previewLen 消息预览最多保留的字符数，和表结构 MsgPreview size:64 对应
+const previewLen = 64
+
+var (
+	ErrMsgTypeNotSupported = errors.New("不支持的消息类型")
+	ErrMsgContentEmpty     = errors.New("消息内容不能为空")
+	ErrRefMsgEmpty         = errors.New("未指定被引用的消息")
+	ErrAtEmpty             = errors.New("未指定被@的用户")
+	ErrAtTooMany           = errors.New("一次最多@50个用户")
+	ErrFileMisplaced       = errors.New("附件只能放在和消息类型对应的内容中")
+	ErrMsgServerOnly       = errors.New("消息中包含只能由服务端生成的内容")
+)
+
+// Msg 消息内容
+type Msg struct {
+	Type         int8          `json:"type"`         // 消息类型
+	Content      *string       `json:"content"`      // 文本消息内容
+	ImageMsg     *ImageMsg     `json:"imageMsg"`     // 图片消息
+	VideoMsg     *VideoMsg     `json:"videoMsg"`     // 视频消息
+	FileMsg      *FileMsg      `json:"fileMsg"`      // 文件消息
+	VoiceMsg     *VoiceMsg     `json:"voiceMsg"`     // 语音消息
+	VoiceCallMsg *VoiceCallMsg `json:"voiceCallMsg"` // 语言通话
+	VideoCallMsg *VideoCallMsg `json:"videoCallMsg"` // 视频通话
+	WithdrawMsg  *WithdrawMsg  `json:"withdrawMsg"`  // 撤回消息
+	ReplyMsg     *ReplyMsg     `json:"replyMsg"`     // 回复消息
+	QuoteMsg     *QuoteMsg     `json:"quoteMsg"`     // 引用消息
+	AtMsg        *AtMsg        `json:"atMsg"`        // @消息
+	MergeMsg     *MergeMsg     `json:"mergeMsg"`     // 合并转发
+	Forwarded    bool          `json:"forwarded"`    // 是否为转发的消息
+}
+
+type ImageMsg struct {
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Title   string `json:"title"`
+	Src     string `json:"src"`
+	Thumb   string `json:"thumb"`   // 缩略图地址
+	Width   int    `json:"width"`   // 原图宽度（像素）
+	Height  int    `json:"height"`  // 原图高度（像素）
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
+}
+
+type VideoMsg struct {
+	FileID    int64  `json:"fileID"` // 上传附件返回的ID
+	Title     string `json:"title"`
+	Src       string `json:"src"`
+	Time      int    `json:"time"`      // 时长（秒）
+	Width     int    `json:"width"`     // 画面宽度（像素）
+	Height    int    `json:"height"`    // 画面高度（像素）
+	Poster    string `json:"poster"`    // 封面地址
+	HasPoster bool   `json:"hasPoster"` // 是否有封面
+	Blocked   bool   `json:"blocked"`   // 附件没有通过内容扫描，已被隔离
+}
+
+type FileMsg struct {
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Title   string `json:"title"`
+	Src     string `json:"src"`
+	Size    int64  `json:"size"`    // 文件大小
+	Type    string `json:"type"`    // 文件类型
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
+}
+
+type VoiceMsg struct {
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Src     string `json:"src"`
+	Time    int    `json:"time"`    // 时长（秒）
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
+}
+
+type VoiceCallMsg struct {
+	StartTime time.Time `json:"startTime"` // 开始时间
+	EndTime   time.Time `json:"endTime"`   // 结束时间
+	EndReason int8      `json:"endReason"` // 结束原因
+}
+
+type VideoCallMsg struct {
+	StartTime time.Time `json:"startTime"` // 开始时间
+	EndTime   time.Time `json:"endTime"`   // 结束时间
+	EndReason int8      `json:"endReason"` // 结束原因
+}
+
+type WithdrawMsg struct {
+	Content   string `json:"content"` // 撤回提示词
+	OriginMsg *Msg   `json:"originMsg"`
+}
+
+type ReplyMsg struct {
+	MsgID   int64  `json:"msgID"`   // 被回复消息ID
+	Content string `json:"content"` // 回复文本
+	Msg     *Msg   `json:"msg"`
+}
+
+type QuoteMsg struct {
+	MsgID   int64  `json:"msgID"`   // 引用消息ID
+	Content string `json:"content"` // 引用文本
+	Msg     *Msg   `json:"msg"`
+}
+
+type AtMsg struct {
+	UserID  int64   `json:"userID"`  // 被@的用户ID
+	UserIDs []int64 `json:"userIDs"` // 被@的多个用户ID
+	IsAll   bool    `json:"isAll"`   // 是否@所有人
+	Content string  `json:"content"` // @消息内容
+	Msg     *Msg    `json:"msg"`
+}
+
+type MergeMsg struct {
+	Title   string      `json:"title"`   // 标题，如 "xx和xx的聊天记录"
+	Summary []string    `json:"summary"` // 前几条消息的摘要，用于卡片展示
+	Items   []MergeItem `json:"items"`   // 全部被转发的消息，展开时使用
+}
+
+type MergeItem struct {
+	SendUserID   int64     `json:"sendUserID"`
+	SendNickname string    `json:"sendNickname"`
+	SendTime     time.Time `json:"sendTime"`
+	Msg          Msg       `json:"msg"`
+}
+
+// Targets 合并 UserID 和 UserIDs 并去重
+func (a AtMsg) Targets() []int64 {
+	res := make([]int64, 0, len(a.UserIDs)+1)
+	seen := make(map[int64]bool, len(a.UserIDs)+1)
+	for _, uid := range append([]int64{a.UserID}, a.UserIDs...) {
+		if uid > 0 && !seen[uid] {
+			seen[uid] = true
+			res = append(res, uid)
+		}
+	}
+	return res
+}
+
+// Validate 校验客户端发送的消息，只允许客户端直接发送的类型通过
+// 撤回、合并转发、转发标记和被引用的消息只能由服务端生成，校验通过后只保留消息类型对应的内容
+func (m *Msg) Validate() error {
+	if m.serverOnly() {
+		return ErrMsgServerOnly
+	}
+	if m.misplacedFile() {
+		return ErrFileMisplaced
+	}
+	m.keepTypePayload()
+	switch m.Type {
+	case MsgTypeText:
+		if m.Content == nil || *m.Content == "" {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeImage:
+		if m.ImageMsg == nil || m.ImageMsg.FileID <= 0 {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeVideo:
+		if m.VideoMsg == nil || m.VideoMsg.FileID <= 0 {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeFile:
+		if m.FileMsg == nil || m.FileMsg.FileID <= 0 {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeVoice:
+		if m.VoiceMsg == nil || m.VoiceMsg.FileID <= 0 {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeVoiceCall:
+		if m.VoiceCallMsg == nil {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeVideoCall:
+		if m.VideoCallMsg == nil {
+			return ErrMsgContentEmpty
+		}
+	case MsgTypeReply:
+		if m.ReplyMsg == nil || m.ReplyMsg.Content == "" {
+			return ErrMsgContentEmpty
+		}
+		if m.ReplyMsg.MsgID <= 0 {
+			return ErrRefMsgEmpty
+		}
+	case MsgTypeQuote:
+		if m.QuoteMsg == nil || m.QuoteMsg.Content == "" {
+			return ErrMsgContentEmpty
+		}
+		if m.QuoteMsg.MsgID <= 0 {
+			return ErrRefMsgEmpty
+		}
+	case MsgTypeAt:
+		if m.AtMsg == nil || m.AtMsg.Content == "" {
+			return ErrMsgContentEmpty
+		}
+		targets := m.AtMsg.Targets()
+		if !m.AtMsg.IsAll && len(targets) == 0 {
+			return ErrAtEmpty
+		}
+		if len(targets) > maxAtUsers {
+			return ErrAtTooMany
+		}
+	default:
+		return ErrMsgTypeNotSupported
+	}
+	return nil
+}
+
+// misplacedFile 消息类型对应的内容以外是否带有附件ID
+// 只有类型对应的附件会被占用和校验归属，其他内容中的附件ID不能保存到消息中
+func (m Msg) misplacedFile() bool {
+	return (m.Type != MsgTypeImage && m.ImageMsg != nil && m.ImageMsg.FileID != 0) ||
+		(m.Type != MsgTypeVideo && m.VideoMsg != nil && m.VideoMsg.FileID != 0) ||
+		(m.Type != MsgTypeFile && m.FileMsg != nil && m.FileMsg.FileID != 0) ||
+		(m.Type != MsgTypeVoice && m.VoiceMsg != nil && m.VoiceMsg.FileID != 0)
+}
+
+// serverOnly 消息是否带有只能由服务端生成的内容
+func (m Msg) serverOnly() bool {
+	return m.WithdrawMsg != nil || m.MergeMsg != nil || m.Forwarded ||
+		(m.ReplyMsg != nil && m.ReplyMsg.Msg != nil) ||
+		(m.QuoteMsg != nil && m.QuoteMsg.Msg != nil) ||
+		(m.AtMsg != nil && m.AtMsg.Msg != nil)
+}
+
+// keepTypePayload 只保留消息类型对应的内容，其他内容不保存到消息中
+func (m *Msg) keepTypePayload() {
+	res := Msg{Type: m.Type}
+	switch m.Type {
+	case MsgTypeText:
+		res.Content = m.Content
+	case MsgTypeImage:
+		res.ImageMsg = m.ImageMsg
+	case MsgTypeVideo:
+		res.VideoMsg = m.VideoMsg
+	case MsgTypeFile:
+		res.FileMsg = m.FileMsg
+	case MsgTypeVoice:
+		res.VoiceMsg = m.VoiceMsg
+	case MsgTypeVoiceCall:
+		res.VoiceCallMsg = m.VoiceCallMsg
+	case MsgTypeVideoCall:
+		res.VideoCallMsg = m.VideoCallMsg
+	case MsgTypeReply:
+		res.ReplyMsg = m.ReplyMsg
+	case MsgTypeQuote:
+		res.QuoteMsg = m.QuoteMsg
+	case MsgTypeAt:
+		res.AtMsg = m.AtMsg
+	}
+	*m = res
+}
+
+// RefMsgID 回复、引用消息所引用的消息ID，其他类型返回 0
+func (m Msg) RefMsgID() int64 {
+	switch {
+	case m.Type == MsgTypeReply && m.ReplyMsg != nil:
+		return m.ReplyMsg.MsgID
+	case m.Type == MsgTypeQuote && m.QuoteMsg != nil:
+		return m.QuoteMsg.MsgID
+	}
+	return 0
+}
+
+// SetRefMsg 设置被引用消息的快照
+func (m *Msg) SetRefMsg(ref *Msg) {
+	switch {
+	case m.Type == MsgTypeReply && m.ReplyMsg != nil:
+		m.ReplyMsg.Msg = ref
+	case m.Type == MsgTypeQuote && m.QuoteMsg != nil:
+		m.QuoteMsg.Msg = ref
+	}
+}
+
+// Snapshot 生成被引用时保存的快照，不再保留它自己引用的消息，避免快照层层嵌套
+func (m Msg) Snapshot() *Msg {
+	res := m
+	if res.ReplyMsg != nil {
+		reply := *res.ReplyMsg
+		reply.Msg = nil
+		res.ReplyMsg = &reply
+	}
+	if res.QuoteMsg != nil {
+		quote := *res.QuoteMsg
+		quote.Msg = nil
+		res.QuoteMsg = &quote
+	}
+	if res.AtMsg != nil {
+		at := *res.AtMsg
+		at.Msg = nil
+		res.AtMsg = &at
+	}
+	if res.MergeMsg != nil {
+		merge := *res.MergeMsg
+		merge.Items = nil
+		res.MergeMsg = &merge
+	}
+	return &res
+}
+
+// Withdrawn 撤回后的消息，OriginMsg 保留原消息，只返回给发送者本人
+func (m Msg) Withdrawn(content string) Msg {
+	origin := m
+	return Msg{
+		Type: MsgTypeWithdraw,
+		WithdrawMsg: &WithdrawMsg{
+			Content:   content,
+			OriginMsg: &origin,
+		},
+	}
+}
+
+// HideWithdrawOrigin 对发送者以外的人隐藏撤回前的原消息
+func (m *Msg) HideWithdrawOrigin() {
+	if m.WithdrawMsg != nil && m.WithdrawMsg.OriginMsg != nil {
+		w := *m.WithdrawMsg
+		w.OriginMsg = nil
+		m.WithdrawMsg = &w
+	}
+}
+
+// FileSigner 生成附件和附件预览图的签名下载地址
+type FileSigner interface {
+	Sign(fileID int64) string
+	SignThumb(fileID int64) string
+}
+
+// SignFiles 为消息中引用的附件生成下载地址，包括引用、@、撤回和合并转发中嵌套的消息
+// 附件地址是有有效期的签名地址，不保存在消息中，每次返回给客户端时重新生成
+// 只为 bound 中的附件生成地址，已被隔离的附件不再生成地址
+func (m *Msg) SignFiles(s FileSigner, bound map[int64]bool) {
+	switch {
+	case m.Type == MsgTypeImage && m.ImageMsg != nil && bound[m.ImageMsg.FileID] && !m.ImageMsg.Blocked:
+		m.ImageMsg.Src = s.Sign(m.ImageMsg.FileID)
+		m.ImageMsg.Thumb = s.SignThumb(m.ImageMsg.FileID)
+	case m.Type == MsgTypeVideo && m.VideoMsg != nil && bound[m.VideoMsg.FileID] && !m.VideoMsg.Blocked:
+		m.VideoMsg.Src = s.Sign(m.VideoMsg.FileID)
+		if m.VideoMsg.HasPoster {
+			m.VideoMsg.Poster = s.SignThumb(m.VideoMsg.FileID)
+		}
+	case m.Type == MsgTypeFile && m.FileMsg != nil && bound[m.FileMsg.FileID] && !m.FileMsg.Blocked:
+		m.FileMsg.Src = s.Sign(m.FileMsg.FileID)
+	case m.Type == MsgTypeVoice && m.VoiceMsg != nil && bound[m.VoiceMsg.FileID] && !m.VoiceMsg.Blocked:
+		m.VoiceMsg.Src = s.Sign(m.VoiceMsg.FileID)
+	}
+	for _, nested := range []*Msg{m.refMsg(), m.withdrawOrigin()} {
+		if nested != nil {
+			nested.SignFiles(s, bound)
+		}
+	}
+	if m.MergeMsg != nil {
+		for i := range m.MergeMsg.Items {
+			m.MergeMsg.Items[i].Msg.SignFiles(s, bound)
+		}
+	}
+}
+
+// BlockFile 标记消息引用的附件已被隔离，包括引用、@、撤回和合并转发中嵌套的消息，返回消息是否引用了该附件
+func (m *Msg) BlockFile(fileID int64) bool {
+	found := false
+	m.walkFiles(func(id *int64, blocked *bool) {
+		if *id == fileID {
+			*blocked = true
+			found = true
+		}
+	})
+	return found
+}
+
+// FileIDs 消息中引用的附件ID，包括引用、@、撤回和合并转发中嵌套的消息，已经去重
+func (m *Msg) FileIDs() []int64 {
+	var res []int64
+	seen := make(map[int64]bool)
+	m.walkFiles(func(id *int64, blocked *bool) {
+		if !seen[*id] {
+			seen[*id] = true
+			res = append(res, *id)
+		}
+	})
+	return res
+}
+
+// ReplaceFileIDs 按 ids 替换消息中引用的附件ID，不在 ids 中的保持不变
+func (m *Msg) ReplaceFileIDs(ids map[int64]int64) {
+	m.walkFiles(func(id *int64, blocked *bool) {
+		if newID, ok := ids[*id]; ok {
+			*id = newID
+		}
+	})
+}
+
+// walkFiles 依次访问消息和嵌套的消息中引用的附件，只访问消息类型对应的内容
+func (m *Msg) walkFiles(fn func(fileID *int64, blocked *bool)) {
+	switch {
+	case m.Type == MsgTypeImage && m.ImageMsg != nil && m.ImageMsg.FileID > 0:
+		fn(&m.ImageMsg.FileID, &m.ImageMsg.Blocked)
+	case m.Type == MsgTypeVideo && m.VideoMsg != nil && m.VideoMsg.FileID > 0:
+		fn(&m.VideoMsg.FileID, &m.VideoMsg.Blocked)
+	case m.Type == MsgTypeFile && m.FileMsg != nil && m.FileMsg.FileID > 0:
+		fn(&m.FileMsg.FileID, &m.FileMsg.Blocked)
+	case m.Type == MsgTypeVoice && m.VoiceMsg != nil && m.VoiceMsg.FileID > 0:
+		fn(&m.VoiceMsg.FileID, &m.VoiceMsg.Blocked)
+	}
+	for _, nested := range []*Msg{m.refMsg(), m.withdrawOrigin()} {
+		if nested != nil {
+			nested.walkFiles(fn)
+		}
+	}
+	if m.MergeMsg != nil {
+		for i := range m.MergeMsg.Items {
+			m.MergeMsg.Items[i].Msg.walkFiles(fn)
+		}
+	}
+}
+
+// refMsg 回复、引用、@消息中嵌套的消息
+func (m *Msg) refMsg() *Msg {
+	switch {
+	case m.ReplyMsg != nil:
+		return m.ReplyMsg.Msg
+	case m.QuoteMsg != nil:
+		return m.QuoteMsg.Msg
+	case m.AtMsg != nil:
+		return m.AtMsg.Msg
+	}
+	return nil
+}
+
+func (m *Msg) withdrawOrigin() *Msg {
+	if m.WithdrawMsg != nil {
+		return m.WithdrawMsg.OriginMsg
+	}
+	return nil
+}
+
+// Preview 生成消息预览，用于会话列表展示
+func (m Msg) Preview() string {
+	var preview string
+	switch m.Type {
+	case MsgTypeText:
+		if m.Content != nil {
+			preview = *m.Content
+		}
+	case MsgTypeImage:
+		preview = "[图片消息]"
+	case MsgTypeVideo:
+		preview = "[视频消息]"
+	case MsgTypeFile:
+		preview = "[文件消息]"
+		if m.FileMsg != nil {
+			preview += m.FileMsg.Title
+		}
+	case MsgTypeVoice:
+		preview = "[语音消息]"
+	case MsgTypeVoiceCall:
+		preview = "[语言通话]"
+	case MsgTypeVideoCall:
+		preview = "[视频通话]"
+	case MsgTypeWithdraw:
+		if m.WithdrawMsg != nil {
+			preview = m.WithdrawMsg.Content
+		}
+	case MsgTypeReply:
+		if m.ReplyMsg != nil {
+			preview = m.ReplyMsg.Content
+		}
+	case MsgTypeQuote:
+		if m.QuoteMsg != nil {
+			preview = m.QuoteMsg.Content
+		}
+	case MsgTypeAt:
+		if m.AtMsg != nil {
+			preview = m.AtMsg.Content
+		}
+	case MsgTypeMerge:
+		preview = "[聊天记录]"
+		if m.MergeMsg != nil {
+			preview += m.MergeMsg.Title
+		}
+	}
+	return truncate(preview, previewLen)
+}
+
+// SearchText 消息中可以被搜索的文本，包括文本内容和文件名等标题，没有可搜索的文本时返回空字符串
+func (m Msg) SearchText() string {
+	switch m.Type {
+	case MsgTypeText:
+		if m.Content != nil {
+			return *m.Content
+		}
+	case MsgTypeImage:
+		if m.ImageMsg != nil {
+			return m.ImageMsg.Title
+		}
+	case MsgTypeVideo:
+		if m.VideoMsg != nil {
+			return m.VideoMsg.Title
+		}
+	case MsgTypeFile:
+		if m.FileMsg != nil {
+			return m.FileMsg.Title
+		}
+	case MsgTypeReply:
+		if m.ReplyMsg != nil {
+			return m.ReplyMsg.Content
+		}
+	case MsgTypeQuote:
+		if m.QuoteMsg != nil {
+			return m.QuoteMsg.Content
+		}
+	case MsgTypeAt:
+		if m.AtMsg != nil {
+			return m.AtMsg.Content
+		}
+	case MsgTypeMerge:
+		if m.MergeMsg != nil {
+			return m.MergeMsg.Title
+		}
+	}
+	return ""
+}
+
+// truncate 按字符截断，避免把多字节的中文截成乱码
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
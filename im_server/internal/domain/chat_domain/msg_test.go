@@ -0,0 +1,119 @@
+package chat_domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMsgValidate(t *testing.T) {
+	text := "hello"
+	testCases := []struct {
+		name    string
+		msg     Msg
+		wantErr error
+	}{
+		{name: "文本", msg: Msg{Type: MsgTypeText, Content: &text}},
+		{name: "图片", msg: Msg{Type: MsgTypeImage, ImageMsg: &ImageMsg{FileID: 1}}},
+		{name: "图片没有附件", msg: Msg{Type: MsgTypeImage, ImageMsg: &ImageMsg{}}, wantErr: ErrMsgContentEmpty},
+		{name: "文本带图片附件", msg: Msg{Type: MsgTypeText, Content: &text, ImageMsg: &ImageMsg{FileID: 1}}, wantErr: ErrFileMisplaced},
+		{name: "图片带文件附件", msg: Msg{Type: MsgTypeImage, ImageMsg: &ImageMsg{FileID: 1}, FileMsg: &FileMsg{FileID: 2}}, wantErr: ErrFileMisplaced},
+		{name: "回复带语音附件", msg: Msg{Type: MsgTypeReply, ReplyMsg: &ReplyMsg{MsgID: 1, Content: text}, VoiceMsg: &VoiceMsg{FileID: 1}}, wantErr: ErrFileMisplaced},
+		{name: "其他内容没有附件", msg: Msg{Type: MsgTypeText, Content: &text, VideoMsg: &VideoMsg{}}},
+		{name: "不支持的类型", msg: Msg{Type: MsgTypeMerge}, wantErr: ErrMsgTypeNotSupported},
+		{name: "撤回消息", msg: Msg{Type: MsgTypeText, Content: &text}.Withdrawn("撤回"), wantErr: ErrMsgServerOnly},
+		{name: "带撤回内容", msg: Msg{Type: MsgTypeText, Content: &text, WithdrawMsg: &WithdrawMsg{}}, wantErr: ErrMsgServerOnly},
+		{name: "带合并转发内容", msg: Msg{Type: MsgTypeText, Content: &text, MergeMsg: &MergeMsg{}}, wantErr: ErrMsgServerOnly},
+		{name: "带转发标记", msg: Msg{Type: MsgTypeText, Content: &text, Forwarded: true}, wantErr: ErrMsgServerOnly},
+		{name: "回复带被引用的消息", msg: Msg{Type: MsgTypeReply, ReplyMsg: &ReplyMsg{MsgID: 1, Content: text, Msg: &Msg{}}}, wantErr: ErrMsgServerOnly},
+		{name: "@带嵌套的消息", msg: Msg{Type: MsgTypeAt, AtMsg: &AtMsg{UserID: 1, Content: text, Msg: &Msg{}}}, wantErr: ErrMsgServerOnly},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.msg.Validate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("err = %v, 期望 %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMsgValidateKeepTypePayload(t *testing.T) {
+	text := "hello"
+	msg := Msg{
+		Type:         MsgTypeText,
+		Content:      &text,
+		VideoMsg:     &VideoMsg{Title: "x"},
+		VoiceCallMsg: &VoiceCallMsg{},
+		QuoteMsg:     &QuoteMsg{MsgID: 1},
+	}
+	if err := msg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content == nil || *msg.Content != text {
+		t.Errorf("文本内容被清除")
+	}
+	if msg.VideoMsg != nil || msg.VoiceCallMsg != nil || msg.QuoteMsg != nil {
+		t.Errorf("消息类型以外的内容没有被清除: %+v", msg)
+	}
+}
+
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(fileID int64) string {
+	return "sign"
+}
+
+func (fakeSigner) SignThumb(fileID int64) string {
+	return "thumb"
+}
+
+func TestMsgSignFiles(t *testing.T) {
+	text := "hello"
+	bound := map[int64]bool{1: true, 3: true}
+	testCases := []struct {
+		name string
+		msg  Msg
+		src  func(m Msg) string
+		want string
+	}{
+		{
+			name: "绑定的附件",
+			msg:  Msg{Type: MsgTypeImage, ImageMsg: &ImageMsg{FileID: 1}},
+			src:  func(m Msg) string { return m.ImageMsg.Src },
+			want: "sign",
+		},
+		{
+			name: "没有绑定的附件",
+			msg:  Msg{Type: MsgTypeImage, ImageMsg: &ImageMsg{FileID: 2}},
+			src:  func(m Msg) string { return m.ImageMsg.Src },
+		},
+		{
+			name: "隔离的附件",
+			msg:  Msg{Type: MsgTypeFile, FileMsg: &FileMsg{FileID: 1, Blocked: true}},
+			src:  func(m Msg) string { return m.FileMsg.Src },
+		},
+		{
+			name: "消息类型以外的内容",
+			msg:  Msg{Type: MsgTypeText, Content: &text, ImageMsg: &ImageMsg{FileID: 1}},
+			src:  func(m Msg) string { return m.ImageMsg.Src },
+		},
+		{
+			name: "引用的消息",
+			msg:  Msg{Type: MsgTypeQuote, QuoteMsg: &QuoteMsg{MsgID: 9, Msg: &Msg{Type: MsgTypeVoice, VoiceMsg: &VoiceMsg{FileID: 3}}}},
+			src:  func(m Msg) string { return m.QuoteMsg.Msg.VoiceMsg.Src },
+			want: "sign",
+		},
+		{
+			name: "合并转发中没有绑定的附件",
+			msg:  NewMergeMsg("", []MergeItem{{Msg: Msg{Type: MsgTypeFile, FileMsg: &FileMsg{FileID: 4}}}}),
+			src:  func(m Msg) string { return m.MergeMsg.Items[0].Msg.FileMsg.Src },
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.msg.SignFiles(fakeSigner{}, bound)
+			if got := tc.src(tc.msg); got != tc.want {
+				t.Errorf("地址 = %q, 期望 %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package chat_domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MaxPinnedMsgs          = 10 // 每个会话最多置顶的消息数
+	MaxPinnedConversations = 20 // 每个用户最多置顶的会话数
+)
+
+var (
+	ErrPinnedMsgsTooMany = errors.New("每个会话最多置顶 10 条消息")
+	ErrPinnedConvTooMany = errors.New("最多置顶 20 个会话")
+)
+
+// PinRequest 置顶、取消置顶消息请求体
+type PinRequest struct {
+	UserID   int64 `json:"-"`
+	ConvType int8  `json:"convType"`
+	MsgID    int64 `json:"msgID"`
+}
+
+// PinnedListRequest 查询会话置顶消息请求参数
+type PinnedListRequest struct {
+	UserID   int64 `form:"-"`
+	ConvType int8  `form:"convType"`
+	ConvID   int64 `form:"convID"` // 私聊为对方用户ID，群聊为群ID
+}
+
+// PinRecord 一条置顶记录
+type PinRecord struct {
+	MsgID     int64
+	PinUserID int64
+	PinTime   time.Time
+}
+
+// PinnedMsg 会话中的一条置顶消息，Chat 和 GroupMsg 按会话类型二选一
+type PinnedMsg struct {
+	PinUserID int64     `json:"pinUserID"`
+	PinTime   time.Time `json:"pinTime"`
+	Chat      *Chat     `json:"chat,omitempty"`
+	GroupMsg  *GroupMsg `json:"groupMsg,omitempty"`
+}
+
+// PinEvent 置顶消息变化时推送给会话中的用户
+type PinEvent struct {
+	ConvType int8  `json:"convType"`
+	ConvID   int64 `json:"convID"` // 站在接收推送的用户角度：私聊为对方用户ID，群聊为群ID
+	MsgID    int64 `json:"msgID"`
+	UserID   int64 `json:"userID"` // 操作者
+	Unpinned bool  `json:"unpinned"`
+}
+
+// PinConversationRequest 置顶、取消置顶会话请求体
+type PinConversationRequest struct {
+	UserID   int64 `json:"-"`
+	ConvType int8  `json:"convType"`
+	ConvID   int64 `json:"convID"`
+	Pinned   bool  `json:"pinned"`
+}
+
+// ConversationItem 会话列表中的一项
+type ConversationItem struct {
+	ConvType    int8      `json:"convType"`
+	ConvID      int64     `json:"convID"` // 私聊为对方用户ID，群聊为群ID
+	Name        string    `json:"name"`   // 私聊为对方昵称，群聊为群名称
+	Avatar      string    `json:"avatar"`
+	LastMsgID   int64     `json:"lastMsgID"`
+	LastMsgType int8      `json:"lastMsgType"`
+	LastPreview string    `json:"lastPreview"`
+	LastSender  int64     `json:"lastSender"`
+	LastTime    time.Time `json:"lastTime"`
+	Unread      int       `json:"unread"`
+	IsPinned    bool      `json:"isPinned"`
+	PinTime     time.Time `json:"pinTime"`
+	TempGroupID int64     `json:"tempGroupID"` // 私聊为临时会话时的来源群ID，取自最新消息
+}
@@ -0,0 +1,82 @@
+package chat_domain
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxEmojiLen 表情最多的字符数，组合表情由多个码点组成
+const maxEmojiLen = 8
+
+var ErrEmojiInvalid = errors.New("不支持的表情")
+
+// ReactionRequest 添加、取消表情回应请求体
+type ReactionRequest struct {
+	UserID   int64  `json:"-"`
+	ConvType int8   `json:"convType"`
+	MsgID    int64  `json:"msgID"`
+	Emoji    string `json:"emoji"`
+}
+
+// Validate 只允许 emoji，不允许普通文字
+func (req ReactionRequest) Validate() error {
+	if req.Emoji == "" || utf8.RuneCountInString(req.Emoji) > maxEmojiLen {
+		return ErrEmojiInvalid
+	}
+	for _, r := range req.Emoji {
+		if r < utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsDigit(r) {
+			return ErrEmojiInvalid
+		}
+	}
+	return nil
+}
+
+// ReactionRecord 某个用户对某条消息的一个表情回应
+type ReactionRecord struct {
+	MsgID  int64
+	UserID int64
+	Emoji  string
+}
+
+// Reaction 一条消息上同一个表情的汇总
+type Reaction struct {
+	Emoji   string  `json:"emoji"`
+	Count   int     `json:"count"`
+	UserIDs []int64 `json:"userIDs"` // 回应过的用户，按回应时间正序
+	Reacted bool    `json:"reacted"` // 当前用户是否回应过
+}
+
+// AggregateReactions 按消息汇总表情回应，表情按第一次出现的顺序排列
+func AggregateReactions(records []ReactionRecord, viewer int64) map[int64][]Reaction {
+	res := make(map[int64][]Reaction)
+	index := make(map[int64]map[string]int)
+	for _, r := range records {
+		if index[r.MsgID] == nil {
+			index[r.MsgID] = make(map[string]int)
+		}
+		i, ok := index[r.MsgID][r.Emoji]
+		if !ok {
+			i = len(res[r.MsgID])
+			index[r.MsgID][r.Emoji] = i
+			res[r.MsgID] = append(res[r.MsgID], Reaction{Emoji: r.Emoji})
+		}
+		reaction := &res[r.MsgID][i]
+		reaction.Count++
+		reaction.UserIDs = append(reaction.UserIDs, r.UserID)
+		if r.UserID == viewer {
+			reaction.Reacted = true
+		}
+	}
+	return res
+}
+
+// ReactionEvent 表情回应变化时推送给会话中的用户
+type ReactionEvent struct {
+	ConvType int8   `json:"convType"`
+	ConvID   int64  `json:"convID"` // 站在接收推送的用户角度：私聊为对方用户ID，群聊为群ID
+	MsgID    int64  `json:"msgID"`
+	UserID   int64  `json:"userID"`
+	Emoji    string `json:"emoji"`
+	Removed  bool   `json:"removed"`
+}
@@ -0,0 +1,33 @@
+package chat_domain
+
+import "time"
+
+// ReadRequest 上报已读请求体，MsgID 为当前会话中读到的最后一条消息
+type ReadRequest struct {
+	UserID   int64 `json:"-"`
+	ConvType int8  `json:"convType"`
+	ConvID   int64 `json:"convID"`
+	MsgID    int64 `json:"msgID"`
+}
+
+// ReadEvent 已读事件，实时推送给消息发送者
+type ReadEvent struct {
+	ConvType  int8  `json:"convType"`
+	ConvID    int64 `json:"convID"` // 站在接收推送的用户角度：私聊为读者ID，群聊为群ID
+	UserID    int64 `json:"userID"` // 读者ID
+	ReadMsgID int64 `json:"readMsgID"`
+}
+
+// Reader 群消息的已读/未读成员
+type Reader struct {
+	UserID         int64     `json:"userID"`
+	MemberNickname string    `json:"memberNickname"`
+	ReadTime       time.Time `json:"readTime"` // 最近一次上报已读的时间，未读成员为零值
+}
+
+// GroupMsgReaders 群消息已读详情
+type GroupMsgReaders struct {
+	MsgID  int64    `json:"msgID"`
+	Read   []Reader `json:"read"`
+	Unread []Reader `json:"unread"`
+}
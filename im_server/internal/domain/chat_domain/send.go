@@ -0,0 +1,16 @@
+package chat_domain
+
+// SendChatRequest 发送私聊消息请求体
+type SendChatRequest struct {
+	SendUserID int64 `json:"-"`
+	RevUserID  int64 `json:"revUserID"`
+	GroupID    int64 `json:"groupID"` // 临时会话来源群ID，和对方不是好友时必填
+	Msg        Msg   `json:"msg"`
+}
+
+// SendGroupMsgRequest 发送群消息请求体
+type SendGroupMsgRequest struct {
+	SendUserID int64 `json:"-"`
+	GroupID    int64 `json:"groupID"`
+	Msg        Msg   `json:"msg"`
+}
@@ -0,0 +1,48 @@
+package chat_domain
+
+const (
+	defaultSyncLimit = 100
+	maxSyncLimit     = 500
+)
+
+// InboxMsg 用户收件箱中的一条消息，私聊和群聊共用同一个序号空间
+type InboxMsg struct {
+	Seq      int64     `json:"seq"`
+	ConvType int8      `json:"convType"`
+	MsgID    int64     `json:"msgID"`
+	Chat     *Chat     `json:"chat,omitempty"`
+	GroupMsg *GroupMsg `json:"groupMsg,omitempty"`
+}
+
+// UserSeq 用户的收件箱序号
+type UserSeq struct {
+	UserID int64 `json:"userID"`
+	MaxSeq int64 `json:"maxSeq"`
+	AckSeq int64 `json:"ackSeq"`
+}
+
+// SyncRequest 离线同步请求体，拉取 Seq 之后的消息
+type SyncRequest struct {
+	UserID int64 `form:"-"`
+	Seq    int64 `form:"seq"`
+	Limit  int   `form:"limit"`
+}
+
+// PageLimit 返回修正后的分页大小
+func (req SyncRequest) PageLimit() int {
+	return pageLimit(req.Limit, defaultSyncLimit, maxSyncLimit)
+}
+
+// SyncResult 离线同步结果
+type SyncResult struct {
+	Msgs    []InboxMsg `json:"msgs"`    // 按 Seq 升序
+	MaxSeq  int64      `json:"maxSeq"`  // 服务端当前最大序号
+	AckSeq  int64      `json:"ackSeq"`  // 已确认的序号，之前的消息已经从收件箱清理，只能通过历史消息接口获取
+	HasMore bool       `json:"hasMore"` // 是否还有更多消息，需要以最后一条的 Seq 继续拉取
+}
+
+// AckRequest 确认收到请求体，Seq 及之前的消息都已经收到
+type AckRequest struct {
+	UserID int64 `json:"-"`
+	Seq    int64 `json:"seq"`
+}
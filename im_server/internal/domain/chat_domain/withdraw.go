@@ -0,0 +1,18 @@
+package chat_domain
+
+// DefaultRecallMessage 用户没有设置撤回提示内容时使用
+const DefaultRecallMessage = "撤回了一条消息"
+
+// WithdrawRequest 撤回消息请求体
+type WithdrawRequest struct {
+	UserID   int64 `json:"-"`
+	ConvType int8  `json:"convType"`
+	MsgID    int64 `json:"msgID"`
+}
+
+// MsgEvent 已有消息发生变更（撤回等）时推送的事件
+type MsgEvent struct {
+	ConvType int8      `json:"convType"`
+	Chat     *Chat     `json:"chat,omitempty"`
+	GroupMsg *GroupMsg `json:"groupMsg,omitempty"`
+}
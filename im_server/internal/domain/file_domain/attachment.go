@@ -0,0 +1,59 @@
+package file_domain
+
+import (
+	"errors"
+	"time"
+)
+
+// 附件类型，和消息类型中的图片、视频、文件、语音对应
+const (
+	KindImage = "image"
+	KindVideo = "video"
+	KindFile  = "file"
+	KindVoice = "voice"
+)
+
+var ErrKindNotSupported = errors.New("不支持的附件类型")
+
+// UploadRequest 上传附件请求参数，文件本身通过 multipart 的 file 字段上传
+type UploadRequest struct {
+	UserID int64  `form:"-"`
+	Kind   string `form:"-"` // 附件类型，取自路径参数
+}
+
+func (req UploadRequest) Validate() error {
+	switch req.Kind {
+	case KindImage, KindVideo, KindFile, KindVoice:
+	default:
+		return ErrKindNotSupported
+	}
+	return nil
+}
+
+// Attachment 聊天附件，上传后归上传者所有，发送消息时绑定到消息上
+type Attachment struct {
+	ID         int64     `json:"fileID"`
+	CreateTime time.Time `json:"createTime"`
+	UserID     int64     `json:"userID"`   // 上传者
+	Kind       string    `json:"kind"`     // 附件类型
+	Title      string    `json:"title"`    // 原始文件名
+	Src        string    `json:"src"`      // 签名下载地址，不保存，每次返回时重新生成
+	Size       int64     `json:"size"`     // 文件大小（字节）
+	MimeType   string    `json:"mimeType"` // 根据文件内容识别的类型
+	Type       string    `json:"type"`     // 文件扩展名，不带点，对应 FileMsg.Type
+	Duration   int       `json:"duration"` // 音视频时长（秒），由服务端解析文件得到
+	Width      int       `json:"width"`    // 图片、视频宽度（像素）
+	Height     int       `json:"height"`   // 图片、视频高度（像素）
+	Thumb      string    `json:"thumb"`    // 图片缩略图或视频封面的签名下载地址，不保存
+	HasThumb   bool      `json:"-"`        // 是否有缩略图或视频封面
+	FileID     int64     `json:"-"`        // 文件记录ID
+	ConvType   int8      `json:"convType"` // 绑定的消息所在的会话类型，未绑定为 0
+	MsgID      int64     `json:"msgID"`    // 绑定的消息ID，未绑定为 0
+	BindTime   time.Time `json:"-"`        // 绑定到消息的时间，未绑定为零值
+	Blocked    bool      `json:"blocked"`  // 文件没有通过内容扫描，已被隔离，不能发送和下载
+}
+
+// Attached 是否已经绑定到消息上
+func (a Attachment) Attached() bool {
+	return a.ConvType != 0
+}
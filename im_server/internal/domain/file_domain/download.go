@@ -0,0 +1,21 @@
+package file_domain
+
+import (
+	"io"
+	"time"
+)
+
+// DownloadRequest 签名下载地址中的参数
+type DownloadRequest struct {
+	FileID  int64  `form:"id"`
+	Expires int64  `form:"expires"`
+	Sign    string `form:"sign"`
+	Thumb   bool   `form:"thumb"` // 下载预览图
+}
+
+// Download 校验通过后要返回给客户端的附件内容，Content 支持 Seek，用来响应 Range 请求
+type Download struct {
+	Attachment
+	ModTime time.Time
+	Content io.ReadSeekCloser
+}
@@ -0,0 +1,57 @@
+package file_domain
+
+import "time"
+
+// 头像，和聊天附件共用文件记录表
+const (
+	KindAvatar      = "avatar" // 用户头像
+	KindGroupAvatar = "group"  // 群头像
+)
+
+// Avatar 上传头像的结果，头像裁剪为正方形，Sizes 为各个边长的头像地址
+type Avatar struct {
+	URL   string         `json:"url"`   // 默认头像地址，保存在用户资料中
+	Sizes map[int]string `json:"sizes"` // 边长（像素）对应的头像地址
+}
+
+// 文件的内容扫描状态，没有配置扫描器时上传的文件都是 ScanClean
+const (
+	ScanClean   int8 = 0 // 通过或不需要扫描
+	ScanPending int8 = 1 // 等待后台任务扫描，可以正常下载
+	ScanBlocked int8 = 2 // 没有通过扫描，已被隔离，不能下载
+)
+
+// GroupAvatarRequest 上传群头像请求参数，图片通过 multipart 的 image 字段上传
+type GroupAvatarRequest struct {
+	UserID  int64 `form:"-"`
+	GroupID int64 `form:"groupID"`
+}
+
+// File 一次上传的文件记录
+// 文件内容按 SHA-256 存储在存储后端中服务端生成的路径下，内容相同的上传共用同一份文件，原始文件名只作为元数据保存
+type File struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"`
+	UserID     int64     `json:"userID"`   // 上传者
+	Kind       string    `json:"kind"`     // 文件用途 avatar group image video file voice
+	Name       string    `json:"name"`     // 原始文件名
+	Hash       string    `json:"hash"`     // 文件内容的 SHA-256，十六进制
+	Size       int64     `json:"size"`     // 文件大小（字节）
+	MimeType   string    `json:"mimeType"` // 根据文件内容识别的类型
+	Path       string    `json:"path"`     // 存储后端中的对象路径，由 Hash 生成
+	Width      int       `json:"width"`    // 图片、视频宽度（像素）
+	Height     int       `json:"height"`   // 图片、视频高度（像素）
+	Duration   int       `json:"duration"` // 音视频时长（秒）
+	Thumb      string    `json:"thumb"`    // 图片缩略图或视频封面的对象路径，没有时为空
+	Refs       int       `json:"refs"`     // 引用计数，头像被用户资料或群资料引用，附件被消息引用
+	GroupID    int64     `json:"groupID"`  // 群头像所属的群ID
+	ScanStatus int8      `json:"scanStatus"`
+	ScanReason string    `json:"scanReason"` // 隔离原因
+}
+
+// Usage 用户的存储空间使用情况
+type Usage struct {
+	Used  int64 `json:"used"`  // 已使用（字节）
+	Quota int64 `json:"quota"` // 配额（字节），为 0 表示不限制
+	Files int64 `json:"files"` // 文件数
+}
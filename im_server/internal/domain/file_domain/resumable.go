@@ -0,0 +1,68 @@
+package file_domain
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// maxNameLen 文件名最多的字符数，和文件记录表 Name size:128 对应
+const maxNameLen = 128
+
+var (
+	ErrUploadSize = errors.New("文件大小错误")
+	ErrUploadHash = errors.New("文件哈希格式错误，需要 64 位十六进制的 SHA-256")
+	ErrUploadName = errors.New("文件名不能为空且不能超过 128 个字符")
+)
+
+// UploadInitRequest 创建断点续传任务请求体
+type UploadInitRequest struct {
+	UserID int64  `json:"-"`
+	Kind   string `json:"kind"` // 附件类型
+	Name   string `json:"name"` // 原始文件名
+	Size   int64  `json:"size"` // 文件总大小（字节）
+	Hash   string `json:"hash"` // 整个文件的 SHA-256，完成时校验
+}
+
+func (req UploadInitRequest) Validate() error {
+	if err := (UploadRequest{Kind: req.Kind}).Validate(); err != nil {
+		return err
+	}
+	if req.Size <= 0 {
+		return ErrUploadSize
+	}
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxNameLen {
+		return ErrUploadName
+	}
+	if b, err := hex.DecodeString(req.Hash); err != nil || len(b) != 32 {
+		return ErrUploadHash
+	}
+	return nil
+}
+
+// UploadChunkRequest 上传一个分片，分片内容为请求体
+type UploadChunkRequest struct {
+	UserID   int64  `form:"-"`
+	UploadID string `form:"-"`
+	Offset   int64  `form:"offset"` // 分片在文件中的起始位置，必须等于已上传的大小
+}
+
+// UploadSession 断点续传任务，连接断开后通过查询 Offset 从断点继续上传
+type UploadSession struct {
+	UploadID   string    `json:"uploadID"`
+	UserID     int64     `json:"userID"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	Offset     int64     `json:"offset"`     // 已上传的大小
+	ChunkSize  int64     `json:"chunkSize"`  // 单个分片的大小上限
+	Completing bool      `json:"completing"` // 正在完成，不能再上传分片
+	ExpireTime time.Time `json:"expireTime"` // 过期时间，每上传一个分片顺延
+}
+
+// Done 是否已经上传完全部内容
+func (s UploadSession) Done() bool {
+	return s.Offset == s.Size
+}
@@ -0,0 +1,69 @@
+package group_domain
+
+import "time"
+
+// 成员角色
+const (
+	RoleOwner  = 1 // 群主
+	RoleAdmin  = 2 // 管理员
+	RoleMember = 3 // 普通成员
+)
+
+// Group 群领域对象
+type Group struct {
+	ID                   int64     `json:"id"`
+	CreateTime           time.Time `json:"createTime"`
+	UpdateTime           time.Time `json:"updateTime"`
+	Title                string    `json:"title"`
+	Abstract             string    `json:"abstract"`
+	Avatar               string    `json:"avatar"`
+	IsSearch             bool      `json:"isSearch"`
+	Verification         int8      `json:"verification"`
+	VerificationQuestion *string   `json:"verificationQuestion"`
+	IsInvite             bool      `json:"isInvite"`
+	IsTemporarySession   bool      `json:"isTemporarySession"`
+	IsProhibition        bool      `json:"isProhibition"`
+	Size                 int       `json:"size"`
+	Creator              int64     `json:"creator"`
+}
+
+// GroupMember 群成员领域对象
+type GroupMember struct {
+	ID              int64     `json:"id"`
+	CreateTime      time.Time `json:"createTime"`
+	UpdateTime      time.Time `json:"updateTime"`
+	MemberNickname  string    `json:"memberNickname"`
+	Role            int       `json:"role"`
+	ProhibitionTime int64     `json:"prohibitionTime"`
+	GroupID         int64     `json:"groupID"`
+	UserID          int64     `json:"userID"`
+}
+
+// IsAdmin 群主和管理员都算管理员
+func (m GroupMember) IsAdmin() bool {
+	return m.Role == RoleOwner || m.Role == RoleAdmin
+}
+
+// IsProhibited 是否处于禁言中，禁言时长从最后一次更新成员信息开始计算
+func (m GroupMember) IsProhibited() bool {
+	if m.ProhibitionTime <= 0 {
+		return false
+	}
+	return time.Now().Before(m.ProhibitionEnd())
+}
+
+// ProhibitionEnd 禁言结束时间，没有禁言时为零值
+func (m GroupMember) ProhibitionEnd() time.Time {
+	if m.ProhibitionTime <= 0 {
+		return time.Time{}
+	}
+	return m.UpdateTime.Add(time.Duration(m.ProhibitionTime) * time.Minute)
+}
+
+// DisplayName 在群里展示的名字，设置了群昵称时优先使用群昵称
+func (m GroupMember) DisplayName(nickname string) string {
+	if m.MemberNickname != "" {
+		return m.MemberNickname
+	}
+	return nickname
+}
@@ -0,0 +1,170 @@
+package group_domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// 群验证规则，和好友验证一致
+const (
+	VerificationForbid   = 0 // 不允许任何人加入
+	VerificationAllowAll = 1 // 允许任何人加入
+)
+
+// 验证状态
+const (
+	VerifyStatusPending = 0 // 未操作
+	VerifyStatusAgree   = 1 // 同意
+	VerifyStatusReject  = 2 // 拒绝
+)
+
+// 验证类型
+const (
+	VerifyTypeApply  = 1 // 主动申请
+	VerifyTypeInvite = 2 // 成员邀请
+	VerifyTypeLink   = 3 // 邀请链接
+)
+
+const (
+	maxInviteUsers        = 50
+	maxVerifyMessageLen   = 32
+	maxInviteLinkUses     = 1000
+	defaultInviteLinkTTL  = 24 * time.Hour
+	maxInviteLinkTTLHours = 7 * 24
+)
+
+var (
+	ErrInviteEmpty       = errors.New("请选择要邀请的好友")
+	ErrInviteTooMany     = errors.New("一次最多邀请 50 人")
+	ErrInviteLinkTTL     = errors.New("邀请链接有效期最长 7 天")
+	ErrInviteLinkMaxUses = errors.New("邀请链接最多使用 1000 次")
+	ErrVerifyMessageLen  = errors.New("附加消息不能超过 32 个字符")
+	ErrVerifyStatus      = errors.New("验证状态错误")
+)
+
+// InviteRequest 邀请好友入群请求体
+type InviteRequest struct {
+	UserID  int64   `json:"-"`
+	GroupID int64   `json:"groupID"`
+	UserIDs []int64 `json:"userIDs"`
+}
+
+func (req InviteRequest) Validate() error {
+	if len(req.UserIDs) == 0 {
+		return ErrInviteEmpty
+	}
+	if len(req.UserIDs) > maxInviteUsers {
+		return ErrInviteTooMany
+	}
+	return nil
+}
+
+// InviteResult 邀请结果，不需要审核的直接入群，需要审核的等待群主和管理员处理
+type InviteResult struct {
+	Joined  []int64 `json:"joined"`
+	Pending []int64 `json:"pending"`
+}
+
+// InviteLinkRequest 创建邀请链接请求体
+type InviteLinkRequest struct {
+	UserID      int64 `json:"-"`
+	GroupID     int64 `json:"groupID"`
+	ExpireHours int   `json:"expireHours"` // 有效期（小时），0 表示默认 24 小时
+	MaxUses     int   `json:"maxUses"`     // 最多使用次数，0 表示不限
+}
+
+func (req InviteLinkRequest) Validate() error {
+	if req.ExpireHours < 0 || req.ExpireHours > maxInviteLinkTTLHours {
+		return ErrInviteLinkTTL
+	}
+	if req.MaxUses < 0 || req.MaxUses > maxInviteLinkUses {
+		return ErrInviteLinkMaxUses
+	}
+	return nil
+}
+
+// TTL 邀请链接的有效期
+func (req InviteLinkRequest) TTL() time.Duration {
+	if req.ExpireHours == 0 {
+		return defaultInviteLinkTTL
+	}
+	return time.Duration(req.ExpireHours) * time.Hour
+}
+
+// InviteLink 群邀请链接领域对象
+type InviteLink struct {
+	ID         int64     `json:"id"`
+	CreateTime time.Time `json:"createTime"`
+	GroupID    int64     `json:"groupID"`
+	Token      string    `json:"token"`
+	CreatorID  int64     `json:"creatorID"`
+	ExpireTime time.Time `json:"expireTime"`
+	MaxUses    int       `json:"maxUses"`
+	Uses       int       `json:"uses"`
+	Revoked    bool      `json:"revoked"`
+}
+
+// Usable 链接是否还可以使用
+func (l InviteLink) Usable() bool {
+	return !l.Revoked && time.Now().Before(l.ExpireTime) && (l.MaxUses == 0 || l.Uses < l.MaxUses)
+}
+
+// InviteLinkIDRequest 撤销邀请链接请求体
+type InviteLinkIDRequest struct {
+	UserID int64 `json:"-"`
+	LinkID int64 `json:"linkID"`
+}
+
+// JoinByLinkRequest 通过邀请链接入群请求体
+type JoinByLinkRequest struct {
+	UserID  int64  `json:"-"`
+	Token   string `json:"token"`
+	Message string `json:"message"` // 需要审核时的附加消息
+}
+
+func (req JoinByLinkRequest) Validate() error {
+	if utf8.RuneCountInString(req.Message) > maxVerifyMessageLen {
+		return ErrVerifyMessageLen
+	}
+	return nil
+}
+
+// JoinResult 入群结果，Joined 为 false 表示已提交验证请求，等待审核
+type JoinResult struct {
+	GroupID int64 `json:"groupID"`
+	Joined  bool  `json:"joined"`
+}
+
+// Verify 入群验证请求领域对象
+type Verify struct {
+	ID                 int64     `json:"id"`
+	CreateTime         time.Time `json:"createTime"`
+	UpdateTime         time.Time `json:"updateTime"`
+	GroupID            int64     `json:"groupID"`
+	UserID             int64     `json:"userID"`
+	InviterID          int64     `json:"inviterID"`
+	InviteLinkID       int64     `json:"inviteLinkID"`
+	Type               int8      `json:"type"`
+	Status             int8      `json:"status"`
+	AdditionalMessages string    `json:"additionalMessages"`
+}
+
+// VerifyHandleRequest 处理入群验证请求体
+type VerifyHandleRequest struct {
+	UserID   int64 `json:"-"`
+	VerifyID int64 `json:"verifyID"`
+	Status   int8  `json:"status"` // 1 同意 2 拒绝
+}
+
+func (req VerifyHandleRequest) Validate() error {
+	if req.Status != VerifyStatusAgree && req.Status != VerifyStatusReject {
+		return ErrVerifyStatus
+	}
+	return nil
+}
+
+// VerifyEvent 有新的入群验证请求时推送给群主和管理员
+type VerifyEvent struct {
+	Verify Verify `json:"verify"`
+}
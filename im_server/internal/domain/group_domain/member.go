@@ -0,0 +1,94 @@
+package group_domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxMemberNicknameLen   = 32
+	defaultMemberPageLimit = 50
+	maxMemberPageLimit     = 200
+)
+
+var ErrMemberNicknameTooLong = errors.New("群昵称不能超过 32 个字符")
+
+// MemberListRequest 群成员列表请求参数
+type MemberListRequest struct {
+	UserID  int64  `form:"-"`
+	GroupID int64  `form:"groupID"`
+	Keyword string `form:"keyword"` // 按群昵称、用户昵称搜索，是数字时同时按用户ID匹配
+	Page    int    `form:"page"`    // 从 1 开始
+	Limit   int    `form:"limit"`
+}
+
+// PageLimit 返回修正后的分页大小
+func (req MemberListRequest) PageLimit() int {
+	if req.Limit <= 0 {
+		return defaultMemberPageLimit
+	}
+	if req.Limit > maxMemberPageLimit {
+		return maxMemberPageLimit
+	}
+	return req.Limit
+}
+
+// Offset 返回分页偏移量
+func (req MemberListRequest) Offset() int {
+	if req.Page <= 1 {
+		return 0
+	}
+	return (req.Page - 1) * req.PageLimit()
+}
+
+// MemberItem 群成员列表中的一项
+type MemberItem struct {
+	UserID         int64     `json:"userID"`
+	Nickname       string    `json:"nickname"`       // 用户昵称
+	MemberNickname string    `json:"memberNickname"` // 群昵称
+	DisplayName    string    `json:"displayName"`    // 群里展示的名字，群昵称优先
+	Avatar         string    `json:"avatar"`
+	Role           int       `json:"role"`
+	IsProhibited   bool      `json:"isProhibited"`
+	ProhibitionEnd time.Time `json:"prohibitionEnd"` // 禁言结束时间，没有禁言时为零值
+	JoinTime       time.Time `json:"joinTime"`
+}
+
+// MemberList 群成员列表
+type MemberList struct {
+	Total   int64        `json:"total"`
+	Members []MemberItem `json:"members"`
+}
+
+// MemberNicknameRequest 修改自己的群昵称请求体，为空时恢复使用用户昵称
+type MemberNicknameRequest struct {
+	UserID         int64  `json:"-"`
+	GroupID        int64  `json:"groupID"`
+	MemberNickname string `json:"memberNickname"`
+}
+
+func (req *MemberNicknameRequest) Validate() error {
+	req.MemberNickname = strings.TrimSpace(req.MemberNickname)
+	if utf8.RuneCountInString(req.MemberNickname) > maxMemberNicknameLen {
+		return ErrMemberNicknameTooLong
+	}
+	return nil
+}
+
+// MemberEvent 群成员变化时推送给群成员
+type MemberEvent struct {
+	GroupID   int64  `json:"groupID"`
+	UserID    int64  `json:"userID"`
+	InviterID int64  `json:"inviterID"`
+	Action    string `json:"action"` // join 入群 nickname 修改群昵称
+
+	MemberNickname string `json:"memberNickname,omitempty"`
+}
+
+// 群成员事件动作
+const (
+	MemberActionJoin     = "join"
+	MemberActionNickname = "nickname"
+)
@@ -0,0 +1,117 @@
+package group_domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxNoticeTitleLen   = 64
+	maxNoticeContentLen = 4096
+)
+
+var (
+	ErrNoticeTitleEmpty     = errors.New("公告标题不能为空")
+	ErrNoticeTitleTooLong   = errors.New("公告标题不能超过 64 个字符")
+	ErrNoticeContentEmpty   = errors.New("公告内容不能为空")
+	ErrNoticeContentTooLong = errors.New("公告内容不能超过 4096 个字符")
+)
+
+// Notice 群公告领域对象
+type Notice struct {
+	ID          int64     `json:"id"`
+	CreateTime  time.Time `json:"createTime"`
+	UpdateTime  time.Time `json:"updateTime"`
+	GroupID     int64     `json:"groupID"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	NeedConfirm bool      `json:"needConfirm"`
+	IsPinned    bool      `json:"isPinned"`
+	PinTime     time.Time `json:"pinTime"`
+	UserID      int64     `json:"userID"`     // 发布者
+	EditUserID  int64     `json:"editUserID"` // 最后编辑者，未编辑为 0
+
+	Confirmed bool `json:"confirmed"` // 当前用户是否已确认，只对需要确认的公告有意义
+}
+
+// NoticeRequest 发布、编辑群公告请求体，编辑时需要 ID
+type NoticeRequest struct {
+	UserID      int64  `json:"-"`
+	ID          int64  `json:"id"`
+	GroupID     int64  `json:"groupID"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	NeedConfirm bool   `json:"needConfirm"`
+	IsPinned    bool   `json:"isPinned"` // 只在发布时生效，之后通过置顶接口修改
+}
+
+// Validate 校验标题和内容，首尾空白不计入长度
+func (req *NoticeRequest) Validate() error {
+	req.Title = strings.TrimSpace(req.Title)
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Title == "" {
+		return ErrNoticeTitleEmpty
+	}
+	if utf8.RuneCountInString(req.Title) > maxNoticeTitleLen {
+		return ErrNoticeTitleTooLong
+	}
+	if req.Content == "" {
+		return ErrNoticeContentEmpty
+	}
+	if utf8.RuneCountInString(req.Content) > maxNoticeContentLen {
+		return ErrNoticeContentTooLong
+	}
+	return nil
+}
+
+// NoticeIDRequest 删除、确认群公告请求体
+type NoticeIDRequest struct {
+	UserID   int64 `json:"-"`
+	NoticeID int64 `json:"noticeID"`
+}
+
+// NoticePinRequest 置顶、取消置顶群公告请求体
+type NoticePinRequest struct {
+	UserID   int64 `json:"-"`
+	NoticeID int64 `json:"noticeID"`
+	Pinned   bool  `json:"pinned"`
+}
+
+// NoticeConfirmer 群公告的已确认/未确认成员
+type NoticeConfirmer struct {
+	UserID         int64     `json:"userID"`
+	MemberNickname string    `json:"memberNickname"`
+	ConfirmTime    time.Time `json:"confirmTime"` // 未确认成员为零值
+}
+
+// NoticeConfirms 群公告确认详情
+type NoticeConfirms struct {
+	NoticeID    int64             `json:"noticeID"`
+	Confirmed   []NoticeConfirmer `json:"confirmed"`
+	Unconfirmed []NoticeConfirmer `json:"unconfirmed"`
+}
+
+// NoticeConfirmRecord 一条确认记录
+type NoticeConfirmRecord struct {
+	UserID      int64
+	ConfirmTime time.Time
+}
+
+// NoticeEvent 群公告发布、编辑、删除时推送给群成员
+type NoticeEvent struct {
+	GroupID  int64   `json:"groupID"`
+	NoticeID int64   `json:"noticeID"`
+	Action   string  `json:"action"` // create 发布 update 编辑 delete 删除 pin 置顶 unpin 取消置顶
+	Notice   *Notice `json:"notice,omitempty"`
+}
+
+// 群公告事件动作
+const (
+	NoticeActionCreate = "create"
+	NoticeActionUpdate = "update"
+	NoticeActionDelete = "delete"
+	NoticeActionPin    = "pin"
+	NoticeActionUnpin  = "unpin"
+)
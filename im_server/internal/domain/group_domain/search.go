@@ -0,0 +1,71 @@
+package group_domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxGroupKeywordLen      = 32
+	defaultGroupSearchLimit = 20
+	maxGroupSearchLimit     = 50
+)
+
+var (
+	ErrGroupKeywordEmpty   = errors.New("请输入群号或群名称")
+	ErrGroupKeywordTooLong = errors.New("搜索关键词不能超过 32 个字符")
+)
+
+// GroupSearchRequest 搜索群请求参数，关键词是数字时同时按群号精确匹配
+type GroupSearchRequest struct {
+	UserID  int64  `form:"-"`
+	Keyword string `form:"keyword"`
+	LastID  int64  `form:"lastID"` // 上一页最后一个群ID，第一页为 0
+	Limit   int    `form:"limit"`
+}
+
+func (req *GroupSearchRequest) Validate() error {
+	req.Keyword = strings.TrimSpace(req.Keyword)
+	if req.Keyword == "" {
+		return ErrGroupKeywordEmpty
+	}
+	if utf8.RuneCountInString(req.Keyword) > maxGroupKeywordLen {
+		return ErrGroupKeywordTooLong
+	}
+	return nil
+}
+
+// GroupID 关键词是数字时作为群号
+func (req GroupSearchRequest) GroupID() int64 {
+	id, err := strconv.ParseInt(req.Keyword, 10, 64)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+// PageLimit 返回修正后的分页大小
+func (req GroupSearchRequest) PageLimit() int {
+	if req.Limit <= 0 {
+		return defaultGroupSearchLimit
+	}
+	if req.Limit > maxGroupSearchLimit {
+		return maxGroupSearchLimit
+	}
+	return req.Limit
+}
+
+// GroupSearchItem 一条群搜索结果
+type GroupSearchItem struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Abstract     string `json:"abstract"`
+	Avatar       string `json:"avatar"`
+	Verification int8   `json:"verification"`
+	Size         int    `json:"size"`
+	MemberCount  int64  `json:"memberCount"`
+	IsMember     bool   `json:"isMember"`  // 当前用户是否已经是群成员
+	IsPending    bool   `json:"isPending"` // 当前用户是否有未处理的入群验证请求
+}
@@ -0,0 +1,88 @@
+package search_domain
+
+import (
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxKeywordLen      = 64
+)
+
+var (
+	ErrKeywordEmpty   = errors.New("搜索关键词不能为空")
+	ErrKeywordTooLong = errors.New("搜索关键词最多64个字")
+	ErrConvTypeEmpty  = errors.New("指定会话时需要同时指定会话类型")
+)
+
+// MsgDoc 被索引的一条消息
+type MsgDoc struct {
+	ID         int64     `json:"id"`
+	ConvType   int8      `json:"convType"`
+	ConvID     int64     `json:"convID"`  // 私聊为对方用户ID，群聊为群ID
+	OwnerID    int64     `json:"ownerID"` // 私聊为索引所属用户，群聊为 0
+	MsgID      int64     `json:"msgID"`
+	SendUserID int64     `json:"sendUserID"`
+	MsgType    int8      `json:"msgType"`
+	MsgTime    time.Time `json:"msgTime"`
+	Text       string    `json:"text"`
+}
+
+// SearchRequest 消息搜索请求体，除关键词外的条件都是可选的
+// 按索引ID倒序分页，LastID 为上一页返回的 LastID，第一页传 0
+type SearchRequest struct {
+	UserID     int64  `form:"-"`
+	Keyword    string `form:"keyword"`
+	ConvType   int8   `form:"convType"`   // 0 表示私聊和群聊都搜索
+	ConvID     int64  `form:"convID"`     // 只搜索某个会话，需要同时指定 ConvType
+	SendUserID int64  `form:"sendUserID"` // 只搜索某个用户发送的消息
+	MsgType    int8   `form:"msgType"`
+	StartTime  int64  `form:"startTime"` // 毫秒时间戳，包含
+	EndTime    int64  `form:"endTime"`   // 毫秒时间戳，不包含
+	LastID     int64  `form:"lastID"`
+	Limit      int    `form:"limit"`
+}
+
+// Validate 校验搜索关键词，私聊的对方用户ID和群ID可能相同，指定会话时必须指定会话类型
+func (req SearchRequest) Validate() error {
+	if req.Keyword == "" {
+		return ErrKeywordEmpty
+	}
+	if utf8.RuneCountInString(req.Keyword) > maxKeywordLen {
+		return ErrKeywordTooLong
+	}
+	if req.ConvID > 0 && req.ConvType == 0 {
+		return ErrConvTypeEmpty
+	}
+	return nil
+}
+
+// PageLimit 返回修正后的分页大小
+func (req SearchRequest) PageLimit() int {
+	if req.Limit <= 0 {
+		return defaultSearchLimit
+	}
+	if req.Limit > maxSearchLimit {
+		return maxSearchLimit
+	}
+	return req.Limit
+}
+
+// SearchHit 一条搜索结果
+type SearchHit struct {
+	ConvType int8                  `json:"convType"`
+	ConvID   int64                 `json:"convID"`
+	Chat     *chat_domain.Chat     `json:"chat,omitempty"`
+	GroupMsg *chat_domain.GroupMsg `json:"groupMsg,omitempty"`
+}
+
+// SearchResult 搜索结果，自己已经删除、清空的群消息会被过滤，一页可能不足 Limit 条
+type SearchResult struct {
+	Hits    []SearchHit `json:"hits"`
+	LastID  int64       `json:"lastID"`
+	HasMore bool        `json:"hasMore"`
+}
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// CollectFilesJob 清理超过保留期限的消息附件、没有发送的附件和被替换的头像
+type CollectFilesJob struct {
+	svc file_service.FileService
+	l   logger.Logger
+}
+
+func NewCollectFilesJob(svc file_service.FileService, l logger.Logger) *CollectFilesJob {
+	return &CollectFilesJob{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (j *CollectFilesJob) Name() string {
+	return "collect_files"
+}
+
+func (j *CollectFilesJob) Run(ctx context.Context) error {
+	n, err := j.svc.CollectFiles(ctx)
+	if n > 0 {
+		j.l.Info("清理不再引用的文件", logger.Int64("count", int64(n)))
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package job
+
+import (
+	"context"
+	"github.com/ink-yht/im/pkg/logger"
+	"sync"
+	"time"
+)
+
+// Job 后台任务
+type Job interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// Scheduler 按固定间隔执行后台任务，同一个任务上一次没有执行完时不会重复执行
+type Scheduler struct {
+	l      logger.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(l logger.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		l:      l,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Every 每隔 interval 执行一次 j，每次执行的超时时间也是 interval
+func (s *Scheduler) Every(interval time.Duration, j Job) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.run(interval, j)
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) run(timeout time.Duration, j Job) {
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	if err := j.Run(ctx); err != nil {
+		s.l.Error("后台任务执行失败", logger.String("job", j.Name()), logger.Error("err", err))
+		return
+	}
+	s.l.Debug("后台任务执行完成", logger.String("job", j.Name()),
+		logger.Int64("ms", time.Since(start).Milliseconds()))
+}
+
+// Stop 停止调度并等待正在执行的任务退出
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
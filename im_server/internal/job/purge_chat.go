@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/service/chat_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// PurgeChatJob 物理删除收发双方都已经删除的私聊消息
+type PurgeChatJob struct {
+	svc chat_service.ChatService
+	l   logger.Logger
+}
+
+func NewPurgeChatJob(svc chat_service.ChatService, l logger.Logger) *PurgeChatJob {
+	return &PurgeChatJob{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (j *PurgeChatJob) Name() string {
+	return "purge_chat"
+}
+
+func (j *PurgeChatJob) Run(ctx context.Context) error {
+	n, err := j.svc.PurgeDeleted(ctx)
+	if n > 0 {
+		j.l.Info("清理已删除的私聊消息", logger.Int64("count", int64(n)))
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// PurgeUploadsJob 删除过期的断点续传任务和已上传的内容
+type PurgeUploadsJob struct {
+	svc file_service.FileService
+	l   logger.Logger
+}
+
+func NewPurgeUploadsJob(svc file_service.FileService, l logger.Logger) *PurgeUploadsJob {
+	return &PurgeUploadsJob{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (j *PurgeUploadsJob) Name() string {
+	return "purge_uploads"
+}
+
+func (j *PurgeUploadsJob) Run(ctx context.Context) error {
+	n, err := j.svc.PurgeExpiredUploads(ctx)
+	if n > 0 {
+		j.l.Info("清理过期的断点续传任务", logger.Int64("count", int64(n)))
+	}
+	return err
+}
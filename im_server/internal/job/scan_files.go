@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// ScanFilesJob 扫描上传后等待扫描的聊天附件，隔离没有通过扫描的文件
+type ScanFilesJob struct {
+	svc file_service.FileService
+	l   logger.Logger
+}
+
+func NewScanFilesJob(svc file_service.FileService, l logger.Logger) *ScanFilesJob {
+	return &ScanFilesJob{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (j *ScanFilesJob) Name() string {
+	return "scan_files"
+}
+
+func (j *ScanFilesJob) Run(ctx context.Context) error {
+	n, err := j.svc.ScanFiles(ctx)
+	if n > 0 {
+		j.l.Warn("隔离没有通过安全检查的文件", logger.Int64("count", int64(n)))
+	}
+	return err
+}
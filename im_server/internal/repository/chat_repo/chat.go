@@ -0,0 +1,564 @@
+package chat_repo
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/dao/chat_dao"
+	"time"
+)
+
+var (
+	ErrRecordNotFound = chat_dao.ErrRecordNotFound
+	ErrMsgChanged     = chat_dao.ErrMsgChanged
+	ErrPinLimit       = chat_dao.ErrPinLimit
+)
+
+type ChatRepository interface {
+	CreateChat(ctx context.Context, c chat_domain.Chat) (chat_domain.Chat, map[int64]int64, error)
+	CreateGroupMsg(ctx context.Context, m chat_domain.GroupMsg, uids []int64, mentioned []int64) (chat_domain.GroupMsg, map[int64]int64, error)
+	FindChatByID(ctx context.Context, id int64) (chat_domain.Chat, error)
+	FindGroupMsgByID(ctx context.Context, id int64) (chat_domain.GroupMsg, error)
+	FindChatsByIDs(ctx context.Context, ids []int64) ([]chat_domain.Chat, error)
+	FindGroupMsgsByIDs(ctx context.Context, ids []int64) ([]chat_domain.GroupMsg, error)
+	FindVisibleChats(ctx context.Context, uid int64, ids []int64) ([]chat_domain.Chat, error)
+	FindVisibleGroupMsgs(ctx context.Context, uid int64, ids []int64) ([]chat_domain.GroupMsg, error)
+	UpdateChatMsg(ctx context.Context, c chat_domain.Chat) error
+	UpdateGroupMsg(ctx context.Context, m chat_domain.GroupMsg) error
+	EditChat(ctx context.Context, old chat_domain.Chat, c chat_domain.Chat) error
+	EditGroupMsg(ctx context.Context, old chat_domain.GroupMsg, m chat_domain.GroupMsg) error
+	FindMsgEdits(ctx context.Context, convType int8, msgID int64) ([]chat_domain.MsgEdit, error)
+
+	AddReaction(ctx context.Context, convType int8, msgID, uid int64, emoji string) (bool, error)
+	RemoveReaction(ctx context.Context, convType int8, msgID, uid int64, emoji string) (bool, error)
+	FindReactions(ctx context.Context, convType int8, msgIDs []int64) ([]chat_domain.ReactionRecord, error)
+	CountTempChats(ctx context.Context, uid, peerID int64, since time.Time) (int64, error)
+	ChatHistory(ctx context.Context, uid, peerID, lastID int64, limit int) ([]chat_domain.Chat, error)
+	GroupHistory(ctx context.Context, uid, groupID, lastID int64, limit int) ([]chat_domain.GroupMsg, error)
+
+	DeleteChats(ctx context.Context, uid, peerID int64, ids []int64) error
+	DeleteGroupMsgs(ctx context.Context, uid, groupID int64, ids []int64) error
+	ClearChat(ctx context.Context, uid, peerID int64) error
+	ClearGroup(ctx context.Context, uid, groupID int64) error
+	PurgeChats(ctx context.Context, limit int) ([]int64, error)
+	FindHiddenMsgIDs(ctx context.Context, uid int64, msgIDs []int64) ([]int64, error)
+
+	FindConversation(ctx context.Context, uid int64, convType int8, convID int64) (chat_domain.Conversation, error)
+	FindConversations(ctx context.Context, convType int8, convID int64) ([]chat_domain.Conversation, error)
+	FindUserConversations(ctx context.Context, uid int64, convType int8, convIDs []int64) ([]chat_domain.Conversation, error)
+	UpdateReadMsgID(ctx context.Context, uid int64, convType int8, convID int64, msgID int64) (int64, error)
+	FindGroupSenders(ctx context.Context, groupID, fromID, toID, excludeUID int64) ([]int64, error)
+
+	FindUserSeq(ctx context.Context, uid int64) (chat_domain.UserSeq, error)
+	FindInbox(ctx context.Context, uid, seq int64, limit int) ([]chat_domain.InboxMsg, error)
+	Ack(ctx context.Context, uid, seq int64) error
+
+	FindMentions(ctx context.Context, uid, groupID, lastID int64, limit int) ([]chat_domain.Mention, error)
+
+	PinMsg(ctx context.Context, convType int8, convID, peerID, msgID, uid int64, limit int) error
+	UnpinMsg(ctx context.Context, convType int8, convID, peerID, msgID int64) (bool, error)
+	UnpinMsgByMsgID(ctx context.Context, convType int8, msgID int64) error
+	FindPinnedMsgs(ctx context.Context, convType int8, convID, peerID int64) ([]chat_domain.PinRecord, error)
+
+	PinConversation(ctx context.Context, uid int64, convType int8, convID int64, pinned bool, limit int) error
+	FindConversationsByUser(ctx context.Context, uid int64) ([]chat_domain.Conversation, error)
+	FindLastChats(ctx context.Context, uid int64) (map[int64]int64, error)
+	FindLastGroupMsgs(ctx context.Context, uid int64, groupIDs []int64) (map[int64]int64, error)
+	CountChatUnread(ctx context.Context, uid int64) (map[int64]int, error)
+	CountGroupUnread(ctx context.Context, uid int64, groupIDs []int64) (map[int64]int, error)
+}
+
+type ChatRepositoryImpl struct {
+	dao chat_dao.ChatDao
+}
+
+func NewChatRepository(dao chat_dao.ChatDao) ChatRepository {
+	return &ChatRepositoryImpl{
+		dao: dao,
+	}
+}
+
+func (repo *ChatRepositoryImpl) CreateChat(ctx context.Context, c chat_domain.Chat) (chat_domain.Chat, map[int64]int64, error) {
+	entity, seqs, err := repo.dao.InsertChat(ctx, repo.chatDomainToEntity(c))
+	if err != nil {
+		return chat_domain.Chat{}, nil, err
+	}
+	return repo.chatEntityToDomain(entity), seqs, nil
+}
+
+func (repo *ChatRepositoryImpl) CreateGroupMsg(ctx context.Context, m chat_domain.GroupMsg, uids []int64, mentioned []int64) (chat_domain.GroupMsg, map[int64]int64, error) {
+	entity, seqs, err := repo.dao.InsertGroupMsg(ctx, repo.groupMsgDomainToEntity(m), uids, mentioned)
+	if err != nil {
+		return chat_domain.GroupMsg{}, nil, err
+	}
+	return repo.groupMsgEntityToDomain(entity), seqs, nil
+}
+
+func (repo *ChatRepositoryImpl) FindChatByID(ctx context.Context, id int64) (chat_domain.Chat, error) {
+	c, err := repo.dao.FindChatByID(ctx, id)
+	if err != nil {
+		return chat_domain.Chat{}, err
+	}
+	return repo.chatEntityToDomain(c), nil
+}
+
+func (repo *ChatRepositoryImpl) FindGroupMsgByID(ctx context.Context, id int64) (chat_domain.GroupMsg, error) {
+	m, err := repo.dao.FindGroupMsgByID(ctx, id)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	return repo.groupMsgEntityToDomain(m), nil
+}
+
+func (repo *ChatRepositoryImpl) FindChatsByIDs(ctx context.Context, ids []int64) ([]chat_domain.Chat, error) {
+	chats, err := repo.dao.FindChatsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Chat, 0, len(chats))
+	for _, c := range chats {
+		res = append(res, repo.chatEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) FindGroupMsgsByIDs(ctx context.Context, ids []int64) ([]chat_domain.GroupMsg, error) {
+	msgs, err := repo.dao.FindGroupMsgsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.GroupMsg, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, repo.groupMsgEntityToDomain(m))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) FindVisibleChats(ctx context.Context, uid int64, ids []int64) ([]chat_domain.Chat, error) {
+	chats, err := repo.dao.FindVisibleChats(ctx, uid, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Chat, 0, len(chats))
+	for _, c := range chats {
+		res = append(res, repo.chatEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) FindVisibleGroupMsgs(ctx context.Context, uid int64, ids []int64) ([]chat_domain.GroupMsg, error) {
+	msgs, err := repo.dao.FindVisibleGroupMsgs(ctx, uid, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.GroupMsg, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, repo.groupMsgEntityToDomain(m))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) UpdateChatMsg(ctx context.Context, c chat_domain.Chat) error {
+	return repo.dao.UpdateChatMsg(ctx, repo.chatDomainToEntity(c))
+}
+
+func (repo *ChatRepositoryImpl) UpdateGroupMsg(ctx context.Context, m chat_domain.GroupMsg) error {
+	return repo.dao.UpdateGroupMsg(ctx, repo.groupMsgDomainToEntity(m))
+}
+
+func (repo *ChatRepositoryImpl) EditChat(ctx context.Context, old chat_domain.Chat, c chat_domain.Chat) error {
+	return repo.dao.EditChat(ctx, repo.chatDomainToEntity(old), repo.chatDomainToEntity(c))
+}
+
+func (repo *ChatRepositoryImpl) EditGroupMsg(ctx context.Context, old chat_domain.GroupMsg, m chat_domain.GroupMsg) error {
+	return repo.dao.EditGroupMsg(ctx, repo.groupMsgDomainToEntity(old), repo.groupMsgDomainToEntity(m))
+}
+
+func (repo *ChatRepositoryImpl) FindMsgEdits(ctx context.Context, convType int8, msgID int64) ([]chat_domain.MsgEdit, error) {
+	edits, err := repo.dao.FindMsgEdits(ctx, convType, msgID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.MsgEdit, 0, len(edits))
+	for _, e := range edits {
+		res = append(res, chat_domain.MsgEdit{
+			ID:         e.ID,
+			CreateTime: time.UnixMilli(e.CreateTime),
+			ConvType:   e.ConvType,
+			MsgID:      e.MsgID,
+			EditUserID: e.EditUserID,
+			Msg:        msgEntityToDomain(e.Msg),
+			MsgTime:    time.UnixMilli(e.MsgTime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) AddReaction(ctx context.Context, convType int8, msgID, uid int64, emoji string) (bool, error) {
+	return repo.dao.InsertReaction(ctx, chat_dao.Reaction{ConvType: convType, MsgID: msgID, UserID: uid, Emoji: emoji})
+}
+
+func (repo *ChatRepositoryImpl) RemoveReaction(ctx context.Context, convType int8, msgID, uid int64, emoji string) (bool, error) {
+	return repo.dao.DeleteReaction(ctx, chat_dao.Reaction{ConvType: convType, MsgID: msgID, UserID: uid, Emoji: emoji})
+}
+
+func (repo *ChatRepositoryImpl) FindReactions(ctx context.Context, convType int8, msgIDs []int64) ([]chat_domain.ReactionRecord, error) {
+	rs, err := repo.dao.FindReactions(ctx, convType, msgIDs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.ReactionRecord, 0, len(rs))
+	for _, r := range rs {
+		res = append(res, chat_domain.ReactionRecord{
+			MsgID:  r.MsgID,
+			UserID: r.UserID,
+			Emoji:  r.Emoji,
+		})
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) CountTempChats(ctx context.Context, uid, peerID int64, since time.Time) (int64, error) {
+	return repo.dao.CountTempChats(ctx, uid, peerID, since.UnixMilli())
+}
+
+func (repo *ChatRepositoryImpl) ChatHistory(ctx context.Context, uid, peerID, lastID int64, limit int) ([]chat_domain.Chat, error) {
+	chats, err := repo.dao.ChatHistory(ctx, uid, peerID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Chat, 0, len(chats))
+	for _, c := range chats {
+		res = append(res, repo.chatEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) GroupHistory(ctx context.Context, uid, groupID, lastID int64, limit int) ([]chat_domain.GroupMsg, error) {
+	msgs, err := repo.dao.GroupHistory(ctx, uid, groupID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.GroupMsg, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, repo.groupMsgEntityToDomain(m))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) DeleteChats(ctx context.Context, uid, peerID int64, ids []int64) error {
+	return repo.dao.DeleteChats(ctx, uid, peerID, ids)
+}
+
+func (repo *ChatRepositoryImpl) DeleteGroupMsgs(ctx context.Context, uid, groupID int64, ids []int64) error {
+	return repo.dao.DeleteGroupMsgs(ctx, uid, groupID, ids)
+}
+
+func (repo *ChatRepositoryImpl) ClearChat(ctx context.Context, uid, peerID int64) error {
+	return repo.dao.ClearChat(ctx, uid, peerID)
+}
+
+func (repo *ChatRepositoryImpl) ClearGroup(ctx context.Context, uid, groupID int64) error {
+	return repo.dao.ClearGroup(ctx, uid, groupID)
+}
+
+func (repo *ChatRepositoryImpl) PurgeChats(ctx context.Context, limit int) ([]int64, error) {
+	return repo.dao.PurgeChats(ctx, limit)
+}
+
+func (repo *ChatRepositoryImpl) FindHiddenMsgIDs(ctx context.Context, uid int64, msgIDs []int64) ([]int64, error) {
+	return repo.dao.FindHiddenMsgIDs(ctx, uid, msgIDs)
+}
+
+func (repo *ChatRepositoryImpl) FindConversation(ctx context.Context, uid int64, convType int8, convID int64) (chat_domain.Conversation, error) {
+	c, err := repo.dao.FindConversation(ctx, uid, convType, convID)
+	if err != nil {
+		return chat_domain.Conversation{}, err
+	}
+	return repo.conversationEntityToDomain(c), nil
+}
+
+func (repo *ChatRepositoryImpl) FindConversations(ctx context.Context, convType int8, convID int64) ([]chat_domain.Conversation, error) {
+	cs, err := repo.dao.FindConversations(ctx, convType, convID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Conversation, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, repo.conversationEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) FindUserConversations(ctx context.Context, uid int64, convType int8, convIDs []int64) ([]chat_domain.Conversation, error) {
+	cs, err := repo.dao.FindUserConversations(ctx, uid, convType, convIDs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Conversation, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, repo.conversationEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) UpdateReadMsgID(ctx context.Context, uid int64, convType int8, convID int64, msgID int64) (int64, error) {
+	return repo.dao.UpdateReadMsgID(ctx, uid, convType, convID, msgID)
+}
+
+func (repo *ChatRepositoryImpl) FindGroupSenders(ctx context.Context, groupID, fromID, toID, excludeUID int64) ([]int64, error) {
+	return repo.dao.FindGroupSenders(ctx, groupID, fromID, toID, excludeUID)
+}
+
+func (repo *ChatRepositoryImpl) FindUserSeq(ctx context.Context, uid int64) (chat_domain.UserSeq, error) {
+	us, err := repo.dao.FindUserSeq(ctx, uid)
+	if err != nil {
+		return chat_domain.UserSeq{}, err
+	}
+	return chat_domain.UserSeq{
+		UserID: us.UserID,
+		MaxSeq: us.MaxSeq,
+		AckSeq: us.AckSeq,
+	}, nil
+}
+
+func (repo *ChatRepositoryImpl) FindInbox(ctx context.Context, uid, seq int64, limit int) ([]chat_domain.InboxMsg, error) {
+	inboxes, err := repo.dao.FindInbox(ctx, uid, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.InboxMsg, 0, len(inboxes))
+	for _, i := range inboxes {
+		res = append(res, chat_domain.InboxMsg{
+			Seq:      i.Seq,
+			ConvType: i.ConvType,
+			MsgID:    i.MsgID,
+		})
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) Ack(ctx context.Context, uid, seq int64) error {
+	return repo.dao.Ack(ctx, uid, seq)
+}
+
+func (repo *ChatRepositoryImpl) FindMentions(ctx context.Context, uid, groupID, lastID int64, limit int) ([]chat_domain.Mention, error) {
+	mentions, err := repo.dao.FindMentions(ctx, uid, groupID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Mention, 0, len(mentions))
+	for _, m := range mentions {
+		res = append(res, chat_domain.Mention{
+			ID:         m.ID,
+			CreateTime: time.UnixMilli(m.CreateTime),
+			UserID:     m.UserID,
+			GroupID:    m.GroupID,
+			MsgID:      m.MsgID,
+			SendUserID: m.SendUserID,
+			IsAll:      m.IsAll,
+		})
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) PinMsg(ctx context.Context, convType int8, convID, peerID, msgID, uid int64, limit int) error {
+	return repo.dao.InsertPinnedMsg(ctx, chat_dao.PinnedMsg{
+		ConvType:  convType,
+		ConvID:    convID,
+		PeerID:    peerID,
+		MsgID:     msgID,
+		PinUserID: uid,
+	}, limit)
+}
+
+func (repo *ChatRepositoryImpl) UnpinMsg(ctx context.Context, convType int8, convID, peerID, msgID int64) (bool, error) {
+	return repo.dao.DeletePinnedMsg(ctx, chat_dao.PinnedMsg{
+		ConvType: convType,
+		ConvID:   convID,
+		PeerID:   peerID,
+		MsgID:    msgID,
+	})
+}
+
+func (repo *ChatRepositoryImpl) UnpinMsgByMsgID(ctx context.Context, convType int8, msgID int64) error {
+	return repo.dao.DeletePinnedMsgByMsgID(ctx, convType, msgID)
+}
+
+func (repo *ChatRepositoryImpl) FindPinnedMsgs(ctx context.Context, convType int8, convID, peerID int64) ([]chat_domain.PinRecord, error) {
+	pins, err := repo.dao.FindPinnedMsgs(ctx, convType, convID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.PinRecord, 0, len(pins))
+	for _, p := range pins {
+		res = append(res, chat_domain.PinRecord{
+			MsgID:     p.MsgID,
+			PinUserID: p.PinUserID,
+			PinTime:   time.UnixMilli(p.CreateTime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) PinConversation(ctx context.Context, uid int64, convType int8, convID int64, pinned bool, limit int) error {
+	return repo.dao.UpdateConversationPinned(ctx, uid, convType, convID, pinned, limit)
+}
+
+func (repo *ChatRepositoryImpl) FindConversationsByUser(ctx context.Context, uid int64) ([]chat_domain.Conversation, error) {
+	cs, err := repo.dao.FindConversationsByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]chat_domain.Conversation, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, repo.conversationEntityToDomain(c))
+	}
+	return res, nil
+}
+
+func (repo *ChatRepositoryImpl) FindLastChats(ctx context.Context, uid int64) (map[int64]int64, error) {
+	last, err := repo.dao.FindLastChats(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return lastMsgMap(last), nil
+}
+
+func (repo *ChatRepositoryImpl) FindLastGroupMsgs(ctx context.Context, uid int64, groupIDs []int64) (map[int64]int64, error) {
+	last, err := repo.dao.FindLastGroupMsgs(ctx, uid, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	return lastMsgMap(last), nil
+}
+
+func (repo *ChatRepositoryImpl) CountChatUnread(ctx context.Context, uid int64) (map[int64]int, error) {
+	counts, err := repo.dao.CountChatUnread(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return unreadMap(counts), nil
+}
+
+func (repo *ChatRepositoryImpl) CountGroupUnread(ctx context.Context, uid int64, groupIDs []int64) (map[int64]int, error) {
+	counts, err := repo.dao.CountGroupUnread(ctx, uid, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	return unreadMap(counts), nil
+}
+
+func (repo *ChatRepositoryImpl) chatDomainToEntity(c chat_domain.Chat) chat_dao.Chat {
+	return chat_dao.Chat{
+		ID:          c.ID,
+		CreateTime:  c.CreateTime.UnixMilli(),
+		UpdateTime:  c.UpdateTime.UnixMilli(),
+		MsgType:     c.MsgType,
+		MsgPreview:  c.MsgPreview,
+		Msg:         msgDomainToEntity(c.Msg),
+		SendUserID:  c.SendUserID,
+		RevUserID:   c.RevUserID,
+		Edited:      c.Edited,
+		TempGroupID: c.TempGroupID,
+	}
+}
+
+func (repo *ChatRepositoryImpl) chatEntityToDomain(c chat_dao.Chat) chat_domain.Chat {
+	return chat_domain.Chat{
+		ID:          c.ID,
+		CreateTime:  time.UnixMilli(c.CreateTime),
+		UpdateTime:  time.UnixMilli(c.UpdateTime),
+		MsgType:     c.MsgType,
+		MsgPreview:  c.MsgPreview,
+		Msg:         msgEntityToDomain(c.Msg),
+		SendUserID:  c.SendUserID,
+		RevUserID:   c.RevUserID,
+		Edited:      c.Edited,
+		TempGroupID: c.TempGroupID,
+	}
+}
+
+func (repo *ChatRepositoryImpl) groupMsgDomainToEntity(m chat_domain.GroupMsg) chat_dao.GroupMsg {
+	return chat_dao.GroupMsg{
+		ID:         m.ID,
+		CreateTime: m.CreateTime.UnixMilli(),
+		UpdateTime: m.UpdateTime.UnixMilli(),
+		MsgType:    m.MsgType,
+		MsgPreview: m.MsgPreview,
+		Msg:        msgDomainToEntity(m.Msg),
+		GroupID:    m.GroupID,
+		SendUserID: m.SendUserID,
+		Edited:     m.Edited,
+	}
+}
+
+func (repo *ChatRepositoryImpl) groupMsgEntityToDomain(m chat_dao.GroupMsg) chat_domain.GroupMsg {
+	return chat_domain.GroupMsg{
+		ID:         m.ID,
+		CreateTime: time.UnixMilli(m.CreateTime),
+		UpdateTime: time.UnixMilli(m.UpdateTime),
+		MsgType:    m.MsgType,
+		MsgPreview: m.MsgPreview,
+		Msg:        msgEntityToDomain(m.Msg),
+		GroupID:    m.GroupID,
+		SendUserID: m.SendUserID,
+		Edited:     m.Edited,
+	}
+}
+
+func (repo *ChatRepositoryImpl) conversationEntityToDomain(c chat_dao.Conversation) chat_domain.Conversation {
+	return chat_domain.Conversation{
+		ID:         c.ID,
+		CreateTime: time.UnixMilli(c.CreateTime),
+		UpdateTime: time.UnixMilli(c.UpdateTime),
+		UserID:     c.UserID,
+		ConvType:   c.ConvType,
+		ConvID:     c.ConvID,
+		ReadMsgID:  c.ReadMsgID,
+		ClearMsgID: c.ClearMsgID,
+		IsPinned:   c.IsPinned,
+		PinTime:    pinTime(c.PinTime),
+	}
+}
+
+// pinTime 未置顶时返回零值，而不是 1970 年
+func pinTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// 辅助函数：消息内容在领域层和表结构中的 JSON 结构一致，直接通过 JSON 转换
+func msgDomainToEntity(m chat_domain.Msg) chat_dao.Msg {
+	var res chat_dao.Msg
+	b, _ := json.Marshal(m)
+	_ = json.Unmarshal(b, &res)
+	return res
+}
+
+func msgEntityToDomain(m chat_dao.Msg) chat_domain.Msg {
+	var res chat_domain.Msg
+	b, _ := json.Marshal(m)
+	_ = json.Unmarshal(b, &res)
+	return res
+}
+
+// lastMsgMap 会话ID -> 最新消息ID
+func lastMsgMap(last []chat_dao.LastMsg) map[int64]int64 {
+	res := make(map[int64]int64, len(last))
+	for _, l := range last {
+		res[l.ConvID] = l.MsgID
+	}
+	return res
+}
+
+// unreadMap 会话ID -> 未读数
+func unreadMap(counts []chat_dao.UnreadCount) map[int64]int {
+	res := make(map[int64]int, len(counts))
+	for _, c := range counts {
+		res[c.ConvID] = c.Count
+	}
+	return res
+}
@@ -1 +1,660 @@
 package chat_dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"time"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	ErrMsgChanged     = errors.New("消息已经发生变化")
+)
+
+type ChatDao interface {
+	InsertChat(ctx context.Context, c Chat) (Chat, map[int64]int64, error)
+	InsertGroupMsg(ctx context.Context, m GroupMsg, uids []int64, mentioned []int64) (GroupMsg, map[int64]int64, error)
+	FindChatByID(ctx context.Context, id int64) (Chat, error)
+	FindGroupMsgByID(ctx context.Context, id int64) (GroupMsg, error)
+	FindChatsByIDs(ctx context.Context, ids []int64) ([]Chat, error)
+	FindGroupMsgsByIDs(ctx context.Context, ids []int64) ([]GroupMsg, error)
+	FindVisibleChats(ctx context.Context, uid int64, ids []int64) ([]Chat, error)
+	FindVisibleGroupMsgs(ctx context.Context, uid int64, ids []int64) ([]GroupMsg, error)
+	UpdateChatMsg(ctx context.Context, c Chat) error
+	UpdateGroupMsg(ctx context.Context, m GroupMsg) error
+	EditChat(ctx context.Context, old Chat, c Chat) error
+	EditGroupMsg(ctx context.Context, old GroupMsg, m GroupMsg) error
+	FindMsgEdits(ctx context.Context, convType int8, msgID int64) ([]MsgEdit, error)
+
+	InsertReaction(ctx context.Context, r Reaction) (bool, error)
+	DeleteReaction(ctx context.Context, r Reaction) (bool, error)
+	FindReactions(ctx context.Context, convType int8, msgIDs []int64) ([]Reaction, error)
+	CountTempChats(ctx context.Context, uid, peerID, since int64) (int64, error)
+
+	InsertPinnedMsg(ctx context.Context, p PinnedMsg, limit int) error
+	DeletePinnedMsg(ctx context.Context, p PinnedMsg) (bool, error)
+	DeletePinnedMsgByMsgID(ctx context.Context, convType int8, msgID int64) error
+	FindPinnedMsgs(ctx context.Context, convType int8, convID, peerID int64) ([]PinnedMsg, error)
+
+	UpdateConversationPinned(ctx context.Context, uid int64, convType int8, convID int64, pinned bool, limit int) error
+	FindConversationsByUser(ctx context.Context, uid int64) ([]Conversation, error)
+	FindLastChats(ctx context.Context, uid int64) ([]LastMsg, error)
+	FindLastGroupMsgs(ctx context.Context, uid int64, groupIDs []int64) ([]LastMsg, error)
+	CountChatUnread(ctx context.Context, uid int64) ([]UnreadCount, error)
+	CountGroupUnread(ctx context.Context, uid int64, groupIDs []int64) ([]UnreadCount, error)
+	ChatHistory(ctx context.Context, uid, peerID, lastID int64, limit int) ([]Chat, error)
+	GroupHistory(ctx context.Context, uid, groupID, lastID int64, limit int) ([]GroupMsg, error)
+
+	DeleteChats(ctx context.Context, uid, peerID int64, ids []int64) error
+	DeleteGroupMsgs(ctx context.Context, uid, groupID int64, ids []int64) error
+	ClearChat(ctx context.Context, uid, peerID int64) error
+	ClearGroup(ctx context.Context, uid, groupID int64) error
+	PurgeChats(ctx context.Context, limit int) ([]int64, error)
+	FindHiddenMsgIDs(ctx context.Context, uid int64, msgIDs []int64) ([]int64, error)
+
+	FindConversation(ctx context.Context, uid int64, convType int8, convID int64) (Conversation, error)
+	FindConversations(ctx context.Context, convType int8, convID int64) ([]Conversation, error)
+	FindUserConversations(ctx context.Context, uid int64, convType int8, convIDs []int64) ([]Conversation, error)
+	UpdateReadMsgID(ctx context.Context, uid int64, convType int8, convID int64, msgID int64) (int64, error)
+	FindGroupSenders(ctx context.Context, groupID, fromID, toID, excludeUID int64) ([]int64, error)
+
+	FindUserSeq(ctx context.Context, uid int64) (UserSeq, error)
+	FindInbox(ctx context.Context, uid, seq int64, limit int) ([]Inbox, error)
+	Ack(ctx context.Context, uid, seq int64) error
+
+	FindMentions(ctx context.Context, uid, groupID, lastID int64, limit int) ([]Mention, error)
+}
+
+type GormChatDAO struct {
+	db *gorm.DB
+}
+
+func NewChatDAO(db *gorm.DB) ChatDao {
+	return &GormChatDAO{db: db}
+}
+
+// InsertChat 保存私聊消息，并在同一个事务中投递到收发双方的收件箱
+// 返回每个用户分配到的收件箱序号
+func (dao *GormChatDAO) InsertChat(ctx context.Context, c Chat) (Chat, map[int64]int64, error) {
+	now := time.Now().UnixMilli()
+	c.CreateTime = now
+	c.UpdateTime = now
+	var seqs map[int64]int64
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&c).Error; err != nil {
+			return err
+		}
+		var err error
+		seqs, err = dao.deliver(tx, []int64{c.SendUserID, c.RevUserID}, 1, c.ID, now)
+		return err
+	})
+	return c, seqs, err
+}
+
+// InsertGroupMsg 保存群消息，并在同一个事务中投递到 uids 的收件箱、为 mentioned 生成@提醒
+// 返回每个用户分配到的收件箱序号
+func (dao *GormChatDAO) InsertGroupMsg(ctx context.Context, m GroupMsg, uids []int64, mentioned []int64) (GroupMsg, map[int64]int64, error) {
+	now := time.Now().UnixMilli()
+	m.CreateTime = now
+	m.UpdateTime = now
+	var seqs map[int64]int64
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		var err error
+		seqs, err = dao.deliver(tx, uids, 2, m.ID, now)
+		if err != nil || len(mentioned) == 0 {
+			return err
+		}
+		mentions := make([]Mention, 0, len(mentioned))
+		for _, uid := range mentioned {
+			mentions = append(mentions, Mention{
+				CreateTime: now,
+				UserID:     uid,
+				GroupID:    m.GroupID,
+				MsgID:      m.ID,
+				SendUserID: m.SendUserID,
+				IsAll:      m.Msg.AtMsg != nil && m.Msg.AtMsg.IsAll,
+			})
+		}
+		return tx.CreateInBatches(mentions, 500).Error
+	})
+	return m, seqs, err
+}
+
+// deliverBatch 投递时每条语句处理的用户数
+const deliverBatch = 500
+
+// deliver 为每个用户分配下一个收件箱序号并写入收件箱
+// 序号分配和消息写入在同一个事务中，事务回滚时序号也一起回滚，所以同一个用户的序号不会出现空洞
+// 每批用户用一条语句递增序号，再一次查询分配到的序号，群成员较多时也只需要少量语句
+func (dao *GormChatDAO) deliver(tx *gorm.DB, uids []int64, convType int8, msgID int64, now int64) (map[int64]int64, error) {
+	// 固定加锁顺序，避免并发投递的事务互相死锁
+	sorted := make([]int64, 0, len(uids))
+	seen := make(map[int64]bool, len(uids))
+	for _, uid := range uids {
+		if !seen[uid] {
+			seen[uid] = true
+			sorted = append(sorted, uid)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	seqs := make(map[int64]int64, len(sorted))
+	for start := 0; start < len(sorted); start += deliverBatch {
+		batch := sorted[start:min(start+deliverBatch, len(sorted))]
+		rows := make([]UserSeq, 0, len(batch))
+		for _, uid := range batch {
+			rows = append(rows, UserSeq{
+				CreateTime: now,
+				UpdateTime: now,
+				UserID:     uid,
+				MaxSeq:     1,
+			})
+		}
+		err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"max_seq":     gorm.Expr("max_seq + 1"),
+				"update_time": now,
+			}),
+		}).Create(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		var us []UserSeq
+		err = tx.Select("user_id", "max_seq").Where("user_id IN ?", batch).Find(&us).Error
+		if err != nil {
+			return nil, err
+		}
+		inboxes := make([]Inbox, 0, len(us))
+		for _, u := range us {
+			seqs[u.UserID] = u.MaxSeq
+			inboxes = append(inboxes, Inbox{
+				CreateTime: now,
+				UserID:     u.UserID,
+				Seq:        u.MaxSeq,
+				ConvType:   convType,
+				MsgID:      msgID,
+			})
+		}
+		if err = tx.Create(&inboxes).Error; err != nil {
+			return nil, err
+		}
+	}
+	return seqs, nil
+}
+
+func (dao *GormChatDAO) FindChatByID(ctx context.Context, id int64) (Chat, error) {
+	var c Chat
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&c).Error
+	return c, err
+}
+
+func (dao *GormChatDAO) FindGroupMsgByID(ctx context.Context, id int64) (GroupMsg, error) {
+	var m GroupMsg
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&m).Error
+	return m, err
+}
+
+func (dao *GormChatDAO) FindChatsByIDs(ctx context.Context, ids []int64) ([]Chat, error) {
+	var chats []Chat
+	if len(ids) == 0 {
+		return chats, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&chats).Error
+	return chats, err
+}
+
+func (dao *GormChatDAO) FindGroupMsgsByIDs(ctx context.Context, ids []int64) ([]GroupMsg, error) {
+	var msgs []GroupMsg
+	if len(ids) == 0 {
+		return msgs, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&msgs).Error
+	return msgs, err
+}
+
+// FindVisibleChats 按ID批量查询私聊消息，不包含 uid 已经删除的消息
+func (dao *GormChatDAO) FindVisibleChats(ctx context.Context, uid int64, ids []int64) ([]Chat, error) {
+	var chats []Chat
+	if len(ids) == 0 {
+		return chats, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Where("(send_user_id = ? AND send_user_deleted = ?) OR (rev_user_id = ? AND rev_user_deleted = ?)", uid, false, uid, false).
+		Find(&chats).Error
+	return chats, err
+}
+
+// FindVisibleGroupMsgs 按ID批量查询群消息，不包含 uid 清空游标之前和单独删除的消息
+func (dao *GormChatDAO) FindVisibleGroupMsgs(ctx context.Context, uid int64, ids []int64) ([]GroupMsg, error) {
+	var msgs []GroupMsg
+	if len(ids) == 0 {
+		return msgs, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Where(dao.groupMsgVisible(uid, "group_msgs.group_id", "group_msgs.id")).
+		Find(&msgs).Error
+	return msgs, err
+}
+
+// groupMsgVisible 群消息对 uid 可见的条件：在清空游标之后，并且没有被单独删除
+// groupCol、msgCol 为外层查询中群ID和消息ID的列名
+func (dao *GormChatDAO) groupMsgVisible(uid int64, groupCol, msgCol string) *gorm.DB {
+	return dao.db.
+		Where(msgCol+" > (?)", dao.db.Model(&Conversation{}).Select("COALESCE(MAX(clear_msg_id), 0)").
+			Where("conversations.user_id = ? AND conversations.conv_type = ? AND conversations.conv_id = "+groupCol, uid, 2)).
+		Where("NOT EXISTS (?)", dao.db.Model(&MsgHidden{}).Select("1").
+			Where("msg_hiddens.user_id = ? AND msg_hiddens.msg_id = "+msgCol, uid))
+}
+
+// UpdateChatMsg 更新私聊消息内容，撤回等场景使用
+func (dao *GormChatDAO) UpdateChatMsg(ctx context.Context, c Chat) error {
+	return dao.db.WithContext(ctx).Model(&Chat{}).Where("id = ?", c.ID).Updates(map[string]any{
+		"msg_type":    c.MsgType,
+		"msg_preview": c.MsgPreview,
+		"msg":         c.Msg,
+		"update_time": time.Now().UnixMilli(),
+	}).Error
+}
+
+// UpdateGroupMsg 更新群消息内容，撤回等场景使用
+func (dao *GormChatDAO) UpdateGroupMsg(ctx context.Context, m GroupMsg) error {
+	return dao.db.WithContext(ctx).Model(&GroupMsg{}).Where("id = ?", m.ID).Updates(map[string]any{
+		"msg_type":    m.MsgType,
+		"msg_preview": m.MsgPreview,
+		"msg":         m.Msg,
+		"update_time": time.Now().UnixMilli(),
+	}).Error
+}
+
+// EditChat 编辑私聊消息，旧版本写入编辑历史
+// 以 old 的类型和更新时间作为乐观锁，期间消息被撤回或者再次编辑时返回 ErrMsgChanged
+func (dao *GormChatDAO) EditChat(ctx context.Context, old Chat, c Chat) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return dao.edit(tx, &Chat{}, 1, old.ID, old.MsgType, old.UpdateTime, old.Msg, c.SendUserID, c.MsgPreview, c.Msg)
+	})
+}
+
+// EditGroupMsg 编辑群消息，规则同 EditChat
+func (dao *GormChatDAO) EditGroupMsg(ctx context.Context, old GroupMsg, m GroupMsg) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return dao.edit(tx, &GroupMsg{}, 2, old.ID, old.MsgType, old.UpdateTime, old.Msg, m.SendUserID, m.MsgPreview, m.Msg)
+	})
+}
+
+func (dao *GormChatDAO) edit(tx *gorm.DB, model any, convType int8, id int64, oldType int8, oldUpdateTime int64,
+	oldMsg Msg, uid int64, preview string, msg Msg) error {
+	now := time.Now().UnixMilli()
+	res := tx.Model(model).Where("id = ? AND msg_type = ? AND update_time = ?", id, oldType, oldUpdateTime).
+		Updates(map[string]any{
+			"msg_preview": preview,
+			"msg":         msg,
+			"edited":      true,
+			"update_time": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrMsgChanged
+	}
+	return tx.Create(&MsgEdit{
+		CreateTime: now,
+		ConvType:   convType,
+		MsgID:      id,
+		EditUserID: uid,
+		Msg:        oldMsg,
+		MsgTime:    oldUpdateTime,
+	}).Error
+}
+
+// FindMsgEdits 查询消息的编辑历史，按编辑时间正序
+func (dao *GormChatDAO) FindMsgEdits(ctx context.Context, convType int8, msgID int64) ([]MsgEdit, error) {
+	var edits []MsgEdit
+	err := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id = ?", convType, msgID).
+		Order("id ASC").
+		Find(&edits).Error
+	return edits, err
+}
+
+// InsertReaction 添加表情回应，已经添加过时返回 false
+func (dao *GormChatDAO) InsertReaction(ctx context.Context, r Reaction) (bool, error) {
+	r.CreateTime = time.Now().UnixMilli()
+	res := dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&r)
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteReaction 取消表情回应，本来就没有时返回 false
+func (dao *GormChatDAO) DeleteReaction(ctx context.Context, r Reaction) (bool, error) {
+	res := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id = ? AND user_id = ? AND emoji = ?", r.ConvType, r.MsgID, r.UserID, r.Emoji).
+		Delete(&Reaction{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// FindReactions 批量查询多条消息的表情回应，按添加时间正序
+func (dao *GormChatDAO) FindReactions(ctx context.Context, convType int8, msgIDs []int64) ([]Reaction, error) {
+	var rs []Reaction
+	if len(msgIDs) == 0 {
+		return rs, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id IN ?", convType, msgIDs).
+		Order("id ASC").
+		Find(&rs).Error
+	return rs, err
+}
+
+// ChatHistory 查询两个用户之间的私聊记录，按ID倒序，不包含 uid 已经删除的消息
+func (dao *GormChatDAO) ChatHistory(ctx context.Context, uid, peerID, lastID int64, limit int) ([]Chat, error) {
+	var chats []Chat
+	db := dao.db.WithContext(ctx).
+		Where("(send_user_id = ? AND rev_user_id = ? AND send_user_deleted = ?) OR (send_user_id = ? AND rev_user_id = ? AND rev_user_deleted = ?)",
+			uid, peerID, false, peerID, uid, false)
+	if lastID > 0 {
+		db = db.Where("id < ?", lastID)
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&chats).Error
+	return chats, err
+}
+
+// GroupHistory 查询群聊记录，按ID倒序，不包含 uid 清空游标之前和单独删除的消息
+func (dao *GormChatDAO) GroupHistory(ctx context.Context, uid, groupID, lastID int64, limit int) ([]GroupMsg, error) {
+	var msgs []GroupMsg
+	db := dao.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Where(dao.groupMsgVisible(uid, "group_msgs.group_id", "group_msgs.id"))
+	if lastID > 0 {
+		db = db.Where("id < ?", lastID)
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+// DeleteChats 为 uid 删除和 peerID 之间的私聊消息，只修改 uid 这一侧的删除标记
+func (dao *GormChatDAO) DeleteChats(ctx context.Context, uid, peerID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.hideChats(ctx, uid, peerID, ids)
+}
+
+// ClearChat 为 uid 清空和 peerID 之间的全部私聊消息
+func (dao *GormChatDAO) ClearChat(ctx context.Context, uid, peerID int64) error {
+	return dao.hideChats(ctx, uid, peerID, nil)
+}
+
+// hideChats 分别标记 uid 发出和收到的消息，ids 为空时标记整个会话
+func (dao *GormChatDAO) hideChats(ctx context.Context, uid, peerID int64, ids []int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		sent := tx.Model(&Chat{}).
+			Where("send_user_id = ? AND rev_user_id = ? AND send_user_deleted = ?", uid, peerID, false)
+		if len(ids) > 0 {
+			sent = sent.Where("id IN ?", ids)
+		}
+		err := sent.Updates(map[string]any{"send_user_deleted": true, "update_time": now}).Error
+		if err != nil {
+			return err
+		}
+		received := tx.Model(&Chat{}).
+			Where("send_user_id = ? AND rev_user_id = ? AND rev_user_deleted = ?", peerID, uid, false)
+		if len(ids) > 0 {
+			received = received.Where("id IN ?", ids)
+		}
+		return received.Updates(map[string]any{"rev_user_deleted": true, "update_time": now}).Error
+	})
+}
+
+// DeleteGroupMsgs 为 uid 删除群消息，不属于该群的消息ID会被忽略
+func (dao *GormChatDAO) DeleteGroupMsgs(ctx context.Context, uid, groupID int64, ids []int64) error {
+	var msgIDs []int64
+	err := dao.db.WithContext(ctx).Model(&GroupMsg{}).
+		Where("group_id = ? AND id IN ?", groupID, ids).
+		Pluck("id", &msgIDs).Error
+	if err != nil || len(msgIDs) == 0 {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	hiddens := make([]MsgHidden, 0, len(msgIDs))
+	for _, id := range msgIDs {
+		hiddens = append(hiddens, MsgHidden{
+			CreateTime: now,
+			UserID:     uid,
+			GroupID:    groupID,
+			MsgID:      id,
+		})
+	}
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&hiddens).Error
+}
+
+// ClearGroup 为 uid 清空群聊，把清空游标推进到群里当前最新的消息
+// 游标之前的单条删除记录已经没有意义，一并清理
+func (dao *GormChatDAO) ClearGroup(ctx context.Context, uid, groupID int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxID int64
+		err := tx.Model(&GroupMsg{}).Where("group_id = ?", groupID).
+			Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error
+		if err != nil || maxID == 0 {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		err = tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"clear_msg_id": gorm.Expr("GREATEST(clear_msg_id, VALUES(clear_msg_id))"),
+				"update_time":  now,
+			}),
+		}).Create(&Conversation{
+			CreateTime: now,
+			UpdateTime: now,
+			UserID:     uid,
+			ConvType:   2,
+			ConvID:     groupID,
+			ClearMsgID: maxID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND group_id = ? AND msg_id <= ?", uid, groupID, maxID).
+			Delete(&MsgHidden{}).Error
+	})
+}
+
+// FindHiddenMsgIDs 查询 msgIDs 中被 uid 单独删除的群消息
+func (dao *GormChatDAO) FindHiddenMsgIDs(ctx context.Context, uid int64, msgIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(msgIDs) == 0 {
+		return ids, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&MsgHidden{}).
+		Where("user_id = ? AND msg_id IN ?", uid, msgIDs).
+		Pluck("msg_id", &ids).Error
+	return ids, err
+}
+
+// PurgeChats 物理删除收发双方都已经删除的私聊消息，以及消息的表情回应、编辑历史和置顶记录
+// 收件箱中还有记录的消息说明有一方还没有确认，先不删除，保证客户端同步时序号连续；确认之后收件箱记录由 Ack 清理
+// 每次最多删除 limit 条，返回删除的消息ID
+func (dao *GormChatDAO) PurgeChats(ctx context.Context, limit int) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Chat{}).
+		Where("send_user_deleted = ? AND rev_user_deleted = ?", true, true).
+		Where("NOT EXISTS (?)", dao.db.Model(&Inbox{}).Select("1").
+			Where("inboxes.conv_type = ? AND inboxes.msg_id = chats.id", 1)).
+		Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	err = dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&Reaction{}, &MsgEdit{}, &PinnedMsg{}} {
+			if err := tx.Where("conv_type = ? AND msg_id IN ?", 1, ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id IN ?", ids).Delete(&Chat{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindConversation 查询用户在某个会话中的状态
+func (dao *GormChatDAO) FindConversation(ctx context.Context, uid int64, convType int8, convID int64) (Conversation, error) {
+	var c Conversation
+	err := dao.db.WithContext(ctx).
+		Where("user_id = ? AND conv_type = ? AND conv_id = ?", uid, convType, convID).
+		First(&c).Error
+	return c, err
+}
+
+// FindConversations 查询某个会话下所有用户的会话状态，群聊用来统计已读人数
+func (dao *GormChatDAO) FindConversations(ctx context.Context, convType int8, convID int64) ([]Conversation, error) {
+	var cs []Conversation
+	err := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND conv_id = ?", convType, convID).
+		Find(&cs).Error
+	return cs, err
+}
+
+// FindUserConversations 批量查询用户在多个会话中的状态
+func (dao *GormChatDAO) FindUserConversations(ctx context.Context, uid int64, convType int8, convIDs []int64) ([]Conversation, error) {
+	var cs []Conversation
+	if len(convIDs) == 0 {
+		return cs, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("user_id = ? AND conv_type = ? AND conv_id IN ?", uid, convType, convIDs).
+		Find(&cs).Error
+	return cs, err
+}
+
+// UpdateReadMsgID 推进已读游标，游标只会前进不会后退
+// 返回推进之前的游标，调用方据此计算这次新读了哪些消息
+func (dao *GormChatDAO) UpdateReadMsgID(ctx context.Context, uid int64, convType int8, convID int64, msgID int64) (int64, error) {
+	var old int64
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c Conversation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND conv_type = ? AND conv_id = ?", uid, convType, convID).
+			First(&c).Error
+		switch {
+		case err == nil:
+			old = c.ReadMsgID
+			if msgID <= old {
+				return nil
+			}
+			return tx.Model(&c).Updates(map[string]any{
+				"read_msg_id": msgID,
+				"update_time": time.Now().UnixMilli(),
+			}).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			now := time.Now().UnixMilli()
+			// 并发首次上报时以更大的游标为准
+			return tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.Assignments(map[string]any{
+					"read_msg_id": gorm.Expr("GREATEST(read_msg_id, VALUES(read_msg_id))"),
+					"update_time": now,
+				}),
+			}).Create(&Conversation{
+				CreateTime: now,
+				UpdateTime: now,
+				UserID:     uid,
+				ConvType:   convType,
+				ConvID:     convID,
+				ReadMsgID:  msgID,
+			}).Error
+		default:
+			return err
+		}
+	})
+	return old, err
+}
+
+// FindGroupSenders 查询群里 (fromID, toID] 区间内的消息发送者，排除 excludeUID
+func (dao *GormChatDAO) FindGroupSenders(ctx context.Context, groupID, fromID, toID, excludeUID int64) ([]int64, error) {
+	var uids []int64
+	err := dao.db.WithContext(ctx).Model(&GroupMsg{}).
+		Where("group_id = ? AND id > ? AND id <= ? AND send_user_id <> ?", groupID, fromID, toID, excludeUID).
+		Distinct().Pluck("send_user_id", &uids).Error
+	return uids, err
+}
+
+// FindUserSeq 查询用户的序号信息，没有收到过任何消息时返回零值
+func (dao *GormChatDAO) FindUserSeq(ctx context.Context, uid int64) (UserSeq, error) {
+	var us UserSeq
+	err := dao.db.WithContext(ctx).Where("user_id = ?", uid).First(&us).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return UserSeq{UserID: uid}, nil
+	}
+	return us, err
+}
+
+// FindInbox 按序号升序查询 seq 之后的收件箱记录
+func (dao *GormChatDAO) FindInbox(ctx context.Context, uid, seq int64, limit int) ([]Inbox, error) {
+	var inboxes []Inbox
+	err := dao.db.WithContext(ctx).
+		Where("user_id = ? AND seq > ?", uid, seq).
+		Order("seq ASC").Limit(limit).
+		Find(&inboxes).Error
+	return inboxes, err
+}
+
+// Ack 推进客户端确认的序号，并清理已经确认的收件箱记录
+// 确认的序号不能超过已分配的最大序号，也不会后退
+func (dao *GormChatDAO) Ack(ctx context.Context, uid, seq int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&UserSeq{}).
+			Where("user_id = ? AND ack_seq < ?", uid, seq).
+			Updates(map[string]any{
+				"ack_seq":     gorm.Expr("LEAST(?, max_seq)", seq),
+				"update_time": time.Now().UnixMilli(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		var us UserSeq
+		err = tx.Where("user_id = ?", uid).First(&us).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND seq <= ?", uid, us.AckSeq).Delete(&Inbox{}).Error
+	})
+}
+
+// FindMentions 查询用户被@的记录，按ID倒序，已经退出的群、清空和删除的消息不再返回
+func (dao *GormChatDAO) FindMentions(ctx context.Context, uid, groupID, lastID int64, limit int) ([]Mention, error) {
+	var mentions []Mention
+	db := dao.db.WithContext(ctx).
+		Where("user_id = ?", uid).
+		Where("group_id IN (?)", dao.db.Table("group_members").Select("group_id").Where("user_id = ?", uid)).
+		Where(dao.groupMsgVisible(uid, "mentions.group_id", "mentions.msg_id"))
+	if groupID > 0 {
+		db = db.Where("group_id = ?", groupID)
+	}
+	if lastID > 0 {
+		db = db.Where("id < ?", lastID)
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&mentions).Error
+	return mentions, err
+}
+
+// CountTempChats 统计 uid 在 since 之后通过临时会话发给 peerID 的消息数
+func (dao *GormChatDAO) CountTempChats(ctx context.Context, uid, peerID, since int64) (int64, error) {
+	var cnt int64
+	err := dao.db.WithContext(ctx).Model(&Chat{}).
+		Where("send_user_id = ? AND rev_user_id = ? AND temp_group_id > 0 AND create_time >= ?", uid, peerID, since).
+		Count(&cnt).Error
+	return cnt, err
+}
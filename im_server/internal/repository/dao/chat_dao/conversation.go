@@ -0,0 +1,168 @@
+package chat_dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var ErrPinLimit = errors.New("置顶数量已达上限")
+
+// InsertPinnedMsg 置顶消息，会话中的置顶数量达到 limit 时返回 ErrPinLimit，重复置顶不报错
+func (dao *GormChatDAO) InsertPinnedMsg(ctx context.Context, p PinnedMsg, limit int) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pins []PinnedMsg
+		// 锁住会话中已有的置顶，避免并发置顶超过上限
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("conv_type = ? AND conv_id = ? AND peer_id = ?", p.ConvType, p.ConvID, p.PeerID).
+			Find(&pins).Error
+		if err != nil {
+			return err
+		}
+		for _, pin := range pins {
+			if pin.MsgID == p.MsgID {
+				return nil
+			}
+		}
+		if len(pins) >= limit {
+			return ErrPinLimit
+		}
+		p.CreateTime = time.Now().UnixMilli()
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&p).Error
+	})
+}
+
+// DeletePinnedMsg 取消置顶，本来没有置顶时返回 false
+func (dao *GormChatDAO) DeletePinnedMsg(ctx context.Context, p PinnedMsg) (bool, error) {
+	res := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND conv_id = ? AND peer_id = ? AND msg_id = ?", p.ConvType, p.ConvID, p.PeerID, p.MsgID).
+		Delete(&PinnedMsg{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeletePinnedMsgByMsgID 消息撤回后取消它的置顶
+func (dao *GormChatDAO) DeletePinnedMsgByMsgID(ctx context.Context, convType int8, msgID int64) error {
+	return dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id = ?", convType, msgID).
+		Delete(&PinnedMsg{}).Error
+}
+
+// FindPinnedMsgs 查询会话中的置顶消息，最近置顶的在前
+func (dao *GormChatDAO) FindPinnedMsgs(ctx context.Context, convType int8, convID, peerID int64) ([]PinnedMsg, error) {
+	var pins []PinnedMsg
+	err := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND conv_id = ? AND peer_id = ?", convType, convID, peerID).
+		Order("id DESC").
+		Find(&pins).Error
+	return pins, err
+}
+
+// UpdateConversationPinned 置顶或取消置顶会话，置顶数量达到 limit 时返回 ErrPinLimit
+func (dao *GormChatDAO) UpdateConversationPinned(ctx context.Context, uid int64, convType int8, convID int64, pinned bool, limit int) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		if pinned {
+			var cs []Conversation
+			// 锁住用户已经置顶的会话，避免并发置顶超过上限
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_id = ? AND is_pinned = ?", uid, true).
+				Find(&cs).Error
+			if err != nil {
+				return err
+			}
+			cnt := 0
+			for _, c := range cs {
+				if c.ConvType != convType || c.ConvID != convID {
+					cnt++
+				}
+			}
+			if cnt >= limit {
+				return ErrPinLimit
+			}
+		}
+		var pinTime int64
+		if pinned {
+			pinTime = now
+		}
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"is_pinned":   pinned,
+				"pin_time":    pinTime,
+				"update_time": now,
+			}),
+		}).Create(&Conversation{
+			CreateTime: now,
+			UpdateTime: now,
+			UserID:     uid,
+			ConvType:   convType,
+			ConvID:     convID,
+			IsPinned:   pinned,
+			PinTime:    pinTime,
+		}).Error
+	})
+}
+
+// FindConversationsByUser 查询用户全部的会话状态
+func (dao *GormChatDAO) FindConversationsByUser(ctx context.Context, uid int64) ([]Conversation, error) {
+	var cs []Conversation
+	err := dao.db.WithContext(ctx).Where("user_id = ?", uid).Find(&cs).Error
+	return cs, err
+}
+
+// FindLastChats 按对方用户分组，查询每个私聊会话中 uid 没有删除的最新一条消息
+func (dao *GormChatDAO) FindLastChats(ctx context.Context, uid int64) ([]LastMsg, error) {
+	var res []LastMsg
+	err := dao.db.WithContext(ctx).Model(&Chat{}).
+		Select("IF(send_user_id = ?, rev_user_id, send_user_id) AS conv_id, MAX(id) AS msg_id", uid).
+		Where("(send_user_id = ? AND send_user_deleted = ?) OR (rev_user_id = ? AND rev_user_deleted = ?)", uid, false, uid, false).
+		Group("conv_id").
+		Scan(&res).Error
+	return res, err
+}
+
+// FindLastGroupMsgs 查询每个群中 uid 可见的最新一条消息，不包含清空游标之前和单独删除的消息
+func (dao *GormChatDAO) FindLastGroupMsgs(ctx context.Context, uid int64, groupIDs []int64) ([]LastMsg, error) {
+	var res []LastMsg
+	if len(groupIDs) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&GroupMsg{}).
+		Select("group_id AS conv_id, MAX(id) AS msg_id").
+		Where("group_id IN ?", groupIDs).
+		Where(dao.groupMsgVisible(uid, "group_msgs.group_id", "group_msgs.id")).
+		Group("group_id").
+		Scan(&res).Error
+	return res, err
+}
+
+// CountChatUnread 按对方用户分组，统计 uid 收到的已读游标之后的私聊消息数
+func (dao *GormChatDAO) CountChatUnread(ctx context.Context, uid int64) ([]UnreadCount, error) {
+	var res []UnreadCount
+	err := dao.db.WithContext(ctx).Table("chats").
+		Select("chats.send_user_id AS conv_id, COUNT(*) AS count").
+		Joins("LEFT JOIN conversations c ON c.user_id = ? AND c.conv_type = ? AND c.conv_id = chats.send_user_id", uid, 1).
+		Where("chats.rev_user_id = ? AND chats.rev_user_deleted = ? AND chats.id > COALESCE(c.read_msg_id, 0)", uid, false).
+		Group("chats.send_user_id").
+		Scan(&res).Error
+	return res, err
+}
+
+// CountGroupUnread 按群分组，统计已读游标和清空游标之后其他成员发送的、没有被 uid 单独删除的群消息数
+func (dao *GormChatDAO) CountGroupUnread(ctx context.Context, uid int64, groupIDs []int64) ([]UnreadCount, error) {
+	var res []UnreadCount
+	if len(groupIDs) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Table("group_msgs").
+		Select("group_msgs.group_id AS conv_id, COUNT(*) AS count").
+		Joins("LEFT JOIN conversations c ON c.user_id = ? AND c.conv_type = ? AND c.conv_id = group_msgs.group_id", uid, 2).
+		Where("group_msgs.group_id IN ? AND group_msgs.send_user_id <> ?", groupIDs, uid).
+		Where("group_msgs.id > GREATEST(COALESCE(c.read_msg_id, 0), COALESCE(c.clear_msg_id, 0))").
+		Where("NOT EXISTS (?)", dao.db.Model(&MsgHidden{}).Select("1").
+			Where("msg_hiddens.user_id = ? AND msg_hiddens.msg_id = group_msgs.id", uid)).
+		Group("group_msgs.group_id").
+		Scan(&res).Error
+	return res, err
+}
@@ -11,12 +11,18 @@ type Chat struct {
 	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
 	CreateTime int64  // 创建时间
 	UpdateTime int64  // 更新时间
-	MsgType    int8   // 消息类型 1 文本类型  2 图片消息  3 视频消息 4 文件消息 5 语音消息  6 语言通话  7 视频通话  8 撤回消息 9 回复消息 10 引用消息
+	MsgType    int8   // 消息类型 1 文本类型  2 图片消息  3 视频消息 4 文件消息 5 语音消息  6 语言通话  7 视频通话  8 撤回消息 9 回复消息 10 引用消息 11 @消息 12 合并转发
 	MsgPreview string `gorm:"size:64"`   // 消息预览
 	Msg        Msg    `gorm:"type:json"` // 消息内容
 
 	SendUserID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 发送者用户ID
 	RevUserID  int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 接收者用户ID
+
+	SendUserDeleted bool `gorm:"not null;default:false;index:idx_deleted,priority:1"` // 发送者是否已删除
+	RevUserDeleted  bool `gorm:"not null;default:false;index:idx_deleted,priority:2"` // 接收者是否已删除，双方都删除后由后台任务物理删除
+	Edited          bool `gorm:"not null;default:false"`                              // 是否编辑过
+
+	TempGroupID int64 // 临时会话来源群ID，好友之间的消息为 0
 }
 
 // GroupMsg 群消息表
@@ -24,12 +30,63 @@ type GroupMsg struct {
 	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
 	CreateTime int64  // 创建时间
 	UpdateTime int64  // 更新时间
-	MsgType    int8   // 消息类型 1 文本类型  2 图片消息  3 视频消息 4 文件消息 5 语音消息  6 语言通话  7 视频通话  8 撤回消息 9回复消息 10 引用消息
+	MsgType    int8   // 消息类型 1 文本类型  2 图片消息  3 视频消息 4 文件消息 5 语音消息  6 语言通话  7 视频通话  8 撤回消息 9回复消息 10 引用消息 11 @消息 12 合并转发
 	MsgPreview string `gorm:"size:64"`   // 消息预览
 	Msg        Msg    `gorm:"type:json"` // 消息内容
 
 	GroupID    int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 群ID
 	SendUserID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 发送者用户ID
+
+	Edited bool `gorm:"not null;default:false"` // 是否编辑过
+}
+
+// Conversation 用户会话表
+// 每个用户在每个会话中一行，记录已读游标等只属于该用户的会话状态
+type Conversation struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 创建时间
+	UpdateTime int64 // 更新时间
+	UserID     int64 `gorm:"not null;uniqueIndex:idx_user_conv,priority:1"`                           // 用户ID
+	ConvType   int8  `gorm:"not null;uniqueIndex:idx_user_conv,priority:2;index:idx_conv,priority:1"` // 会话类型 1 私聊 2 群聊
+	ConvID     int64 `gorm:"not null;uniqueIndex:idx_user_conv,priority:3;index:idx_conv,priority:2"` // 会话ID 私聊为对方用户ID 群聊为群ID
+	ReadMsgID  int64 // 已读游标，小于等于该ID的消息都已读
+	ClearMsgID int64 // 清空游标，群聊中小于等于该ID的消息对该用户不可见
+	IsPinned   bool  `gorm:"not null;default:false"` // 是否置顶
+	PinTime    int64 // 置顶时间，置顶会话之间按置顶时间倒序
+}
+
+// UserSeq 用户序号表
+// 每个用户一行，MaxSeq 是已分配的最大收件箱序号，AckSeq 是客户端确认收到的序号
+type UserSeq struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 创建时间
+	UpdateTime int64 // 更新时间
+	UserID     int64 `gorm:"not null;uniqueIndex"` // 用户ID
+	MaxSeq     int64 // 已分配的最大序号
+	AckSeq     int64 // 客户端已确认的序号，小于等于它的收件箱记录可以清理
+}
+
+// Inbox 用户收件箱表
+// 每条私聊消息投递给收发双方，每条群消息投递给全部群成员，同一个用户的 Seq 连续递增
+type Inbox struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 创建时间
+	UserID     int64 `gorm:"not null;uniqueIndex:idx_user_seq,priority:1"` // 用户ID
+	Seq        int64 `gorm:"not null;uniqueIndex:idx_user_seq,priority:2"` // 用户收件箱序号
+	ConvType   int8  // 会话类型 1 私聊 2 群聊
+	MsgID      int64 // 私聊为 Chat.ID 群聊为 GroupMsg.ID
+}
+
+// Mention @提醒表
+// 每个被@的用户一行，@所有人时展开为除发送者以外的全部群成员
+type Mention struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 创建时间
+	UserID     int64 `gorm:"not null;index"` // 被@的用户ID
+	GroupID    int64 `gorm:"not null;index"` // 群ID
+	MsgID      int64 `gorm:"not null;index"` // 群消息ID
+	SendUserID int64 // 发送者用户ID
+	IsAll      bool  // 是否来自@所有人
 }
 
 // Msg 消息表
@@ -46,6 +103,8 @@ type Msg struct {
 	ReplyMsg     *ReplyMsg     `json:"replyMsg"`     // 回复消息
 	QuoteMsg     *QuoteMsg     `json:"quoteMsg"`     // 引用消息
 	AtMsg        *AtMsg        `json:"atMsg"`        // @消息
+	MergeMsg     *MergeMsg     `json:"mergeMsg"`     // 合并转发
+	Forwarded    bool          `json:"forwarded"`    // 是否为转发的消息
 }
 
 // Scan 取出来时的数据
@@ -54,32 +113,48 @@ func (m *Msg) Scan(val interface{}) error {
 }
 
 // Value 入库时的数据
-func (m *Msg) Value() (driver.Value, error) {
+// 使用值接收者，Chat.Msg 和 GroupMsg.Msg 是值类型字段，入库时才能识别为 driver.Valuer
+func (m Msg) Value() (driver.Value, error) {
 	b, err := json.Marshal(m)
 	return string(b), err
 }
 
 type ImageMsg struct {
-	Title string `json:"title"`
-	Src   string `json:"src"`
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Title   string `json:"title"`
+	Src     string `json:"src"`
+	Thumb   string `json:"thumb"`   // 缩略图地址
+	Width   int    `json:"width"`   // 原图宽度（像素）
+	Height  int    `json:"height"`  // 原图高度（像素）
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
 }
 
 type VideoMsg struct {
-	Title string `json:"title"`
-	Src   string `json:"src"`
-	Time  int    `json:"time"` // 时长（秒）
+	FileID    int64  `json:"fileID"` // 上传附件返回的ID
+	Title     string `json:"title"`
+	Src       string `json:"src"`
+	Time      int    `json:"time"`      // 时长（秒）
+	Width     int    `json:"width"`     // 画面宽度（像素）
+	Height    int    `json:"height"`    // 画面高度（像素）
+	Poster    string `json:"poster"`    // 封面地址
+	HasPoster bool   `json:"hasPoster"` // 是否有封面
+	Blocked   bool   `json:"blocked"`   // 附件没有通过内容扫描，已被隔离
 }
 
 type FileMsg struct {
-	Title string `json:"title"`
-	Src   string `json:"src"`
-	Size  int64  `json:"size"` // 文件大小
-	Type  string `json:"type"` // 文件类型
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Title   string `json:"title"`
+	Src     string `json:"src"`
+	Size    int64  `json:"size"`    // 文件大小
+	Type    string `json:"type"`    // 文件类型
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
 }
 
 type VoiceMsg struct {
-	Src  string `json:"src"`
-	Time int    `json:"time"` // 时长（秒）
+	FileID  int64  `json:"fileID"` // 上传附件返回的ID
+	Src     string `json:"src"`
+	Time    int    `json:"time"`    // 时长（秒）
+	Blocked bool   `json:"blocked"` // 附件没有通过内容扫描，已被隔离
 }
 
 type VoiceCallMsg struct {
@@ -112,9 +187,24 @@ type QuoteMsg struct {
 }
 
 type AtMsg struct {
-	UserID  int64  `json:"userID"`  // 被@的用户ID
-	Content string `json:"content"` // @消息内容
-	Msg     *Msg   `json:"msg"`
+	UserID  int64   `json:"userID"`  // 被@的用户ID
+	UserIDs []int64 `json:"userIDs"` // 被@的多个用户ID
+	IsAll   bool    `json:"isAll"`   // 是否@所有人
+	Content string  `json:"content"` // @消息内容
+	Msg     *Msg    `json:"msg"`
+}
+
+type MergeMsg struct {
+	Title   string      `json:"title"`   // 标题
+	Summary []string    `json:"summary"` // 前几条消息的摘要
+	Items   []MergeItem `json:"items"`   // 全部被转发的消息
+}
+
+type MergeItem struct {
+	SendUserID   int64     `json:"sendUserID"`
+	SendNickname string    `json:"sendNickname"`
+	SendTime     time.Time `json:"sendTime"`
+	Msg          Msg       `json:"msg"`
 }
 
 // 映射实现
@@ -144,6 +234,10 @@ func GetMsgTypeText(MsgType int8) string {
 		return "回复消息"
 	case 10:
 		return "引用消息"
+	case 11:
+		return "@消息"
+	case 12:
+		return "合并转发"
 	default:
 		return "未知"
 	}
@@ -172,7 +266,68 @@ func GetMsgTypeValue(MsgType string) int8 {
 		return 9
 	case "引用消息":
 		return 10
+	case "@消息":
+		return 11
+	case "合并转发":
+		return 12
 	default:
 		return 99 // 未知或未指定
 	}
 }
+
+// MsgHidden 群消息删除表
+// 群消息由全体成员共享，用户删除单条群消息时只为自己记录一行，清空会话后由清空游标代替
+type MsgHidden struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 创建时间
+	UserID     int64 `gorm:"not null;uniqueIndex:idx_user_msg,priority:1;index:idx_user_group,priority:1"` // 用户ID
+	GroupID    int64 `gorm:"not null;index:idx_user_group,priority:2"`                                     // 群ID
+	MsgID      int64 `gorm:"not null;uniqueIndex:idx_user_msg,priority:2"`                                 // 群消息ID
+}
+
+// MsgEdit 消息编辑历史表
+// 每次编辑前把旧版本保存一行，当前版本始终在 Chat / GroupMsg 中
+type MsgEdit struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 编辑时间
+	ConvType   int8  `gorm:"not null;index:idx_conv_msg,priority:1"` // 会话类型 1 私聊 2 群聊
+	MsgID      int64 `gorm:"not null;index:idx_conv_msg,priority:2"` // 私聊为 Chat.ID 群聊为 GroupMsg.ID
+	EditUserID int64 // 编辑者用户ID
+	Msg        Msg   `gorm:"type:json"` // 编辑前的消息内容
+	MsgTime    int64 // 编辑前的版本产生的时间
+}
+
+// Reaction 消息表情回应表
+// 每个用户对每条消息的每种表情一行，重复添加不会产生多行
+type Reaction struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64  // 创建时间
+	ConvType   int8   `gorm:"not null;uniqueIndex:idx_msg_user_emoji,priority:1"`         // 会话类型 1 私聊 2 群聊
+	MsgID      int64  `gorm:"not null;uniqueIndex:idx_msg_user_emoji,priority:2"`         // 私聊为 Chat.ID 群聊为 GroupMsg.ID
+	UserID     int64  `gorm:"not null;uniqueIndex:idx_msg_user_emoji,priority:3"`         // 用户ID
+	Emoji      string `gorm:"not null;size:32;uniqueIndex:idx_msg_user_emoji,priority:4"` // 表情
+}
+
+// PinnedMsg 置顶消息表
+// 群聊由群主和管理员置顶，私聊双方都可以置顶，置顶对会话中的所有人可见
+type PinnedMsg struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 置顶时间
+	ConvType   int8  `gorm:"not null;uniqueIndex:idx_conv_msg,priority:1"` // 会话类型 1 私聊 2 群聊
+	ConvID     int64 `gorm:"not null;uniqueIndex:idx_conv_msg,priority:2"` // 群聊为群ID，私聊为双方中较小的用户ID
+	PeerID     int64 `gorm:"not null;uniqueIndex:idx_conv_msg,priority:3"` // 私聊为双方中较大的用户ID，群聊为 0
+	MsgID      int64 `gorm:"not null;uniqueIndex:idx_conv_msg,priority:4"` // 私聊为 Chat.ID 群聊为 GroupMsg.ID
+	PinUserID  int64 // 置顶操作者用户ID
+}
+
+// LastMsg 会话中最新的一条消息，会话列表使用
+type LastMsg struct {
+	ConvID int64
+	MsgID  int64
+}
+
+// UnreadCount 会话中的未读消息数，会话列表使用
+type UnreadCount struct {
+	ConvID int64
+	Count  int
+}
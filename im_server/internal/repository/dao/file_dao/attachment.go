@@ -0,0 +1,122 @@
+package file_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// InsertAttachment 保存上传的附件
+func (dao *GormFileDAO) InsertAttachment(ctx context.Context, a Attachment) (Attachment, error) {
+	now := time.Now().UnixMilli()
+	a.CreateTime = now
+	a.UpdateTime = now
+	err := dao.db.WithContext(ctx).Create(&a).Error
+	return a, err
+}
+
+// InsertAttachments 批量保存附件，转发消息时为新消息复制附件
+func (dao *GormFileDAO) InsertAttachments(ctx context.Context, as []Attachment) ([]Attachment, error) {
+	if len(as) == 0 {
+		return as, nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range as {
+		as[i].CreateTime = now
+		as[i].UpdateTime = now
+	}
+	err := dao.db.WithContext(ctx).Create(&as).Error
+	return as, err
+}
+
+// FindAttachmentsByIDs 批量查询附件
+func (dao *GormFileDAO) FindAttachmentsByIDs(ctx context.Context, ids []int64) ([]Attachment, error) {
+	var as []Attachment
+	if len(ids) == 0 {
+		return as, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&as).Error
+	return as, err
+}
+
+// ClaimAttachments 把上传者自己还没有绑定的附件占用给某个会话类型的消息
+// 只要有一个附件已经被占用或者不属于 uid，整体失败并返回 ErrAttachmentUsed
+func (dao *GormFileDAO) ClaimAttachments(ctx context.Context, uid int64, convType int8, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Attachment{}).
+			Where("id IN ? AND user_id = ? AND conv_type = ?", ids, uid, 0).
+			Updates(map[string]any{
+				"conv_type":   convType,
+				"update_time": time.Now().UnixMilli(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != int64(len(ids)) {
+			return ErrAttachmentUsed
+		}
+		return nil
+	})
+}
+
+// BindAttachments 消息保存成功后，记录占用的附件所属的消息，附件对应的文件记录增加一次引用
+func (dao *GormFileDAO) BindAttachments(ctx context.Context, ids []int64, msgID int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var as []Attachment
+		err := tx.Where("id IN ? AND msg_id = ?", ids, 0).Find(&as).Error
+		if err != nil || len(as) == 0 {
+			return err
+		}
+		aids := make([]int64, 0, len(as))
+		fileIDs := make([]int64, 0, len(as))
+		for _, a := range as {
+			aids = append(aids, a.ID)
+			fileIDs = append(fileIDs, a.FileID)
+		}
+		now := time.Now().UnixMilli()
+		err = tx.Model(&Attachment{}).
+			Where("id IN ? AND msg_id = ?", aids, 0).
+			Updates(map[string]any{
+				"msg_id":      msgID,
+				"bind_time":   now,
+				"update_time": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&File{}).
+			Where("id IN ?", fileIDs).
+			Update("refs", gorm.Expr("refs + ?", 1)).Error
+	})
+}
+
+// ReleaseAttachments 消息保存失败时释放占用的附件，让上传者可以重新发送
+func (dao *GormFileDAO) ReleaseAttachments(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Model(&Attachment{}).
+		Where("id IN ? AND msg_id = ?", ids, 0).
+		Updates(map[string]any{
+			"conv_type":   0,
+			"update_time": time.Now().UnixMilli(),
+		}).Error
+}
+
+// FindMsgAttachments 查询绑定到 msgIDs 中消息的附件
+func (dao *GormFileDAO) FindMsgAttachments(ctx context.Context, convType int8, msgIDs []int64) ([]Attachment, error) {
+	var as []Attachment
+	if len(msgIDs) == 0 {
+		return as, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id IN ?", convType, msgIDs).
+		Find(&as).Error
+	return as, err
+}
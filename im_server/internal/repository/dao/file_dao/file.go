@@ -1,13 +1,55 @@
 package file_dao
 
 import (
+	"errors"
+	"github.com/ink-yht/im/internal/repository/dao/group_dao"
 	"github.com/ink-yht/im/internal/repository/dao/user_dao"
 	"golang.org/x/net/context"
 	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	ErrAttachmentUsed = errors.New("附件已被使用")
 )
 
 type FileDao interface {
 	Avatar(ctx context.Context, u user_dao.User) error
+	GroupAvatar(ctx context.Context, groupID int64, avatar string) error
+
+	InsertFile(ctx context.Context, f File) (File, error)
+	FindFileByID(ctx context.Context, id int64) (File, error)
+
+	InsertAttachment(ctx context.Context, a Attachment) (Attachment, error)
+	InsertAttachments(ctx context.Context, as []Attachment) ([]Attachment, error)
+	FindAttachmentsByIDs(ctx context.Context, ids []int64) ([]Attachment, error)
+	ClaimAttachments(ctx context.Context, uid int64, convType int8, ids []int64) error
+	BindAttachments(ctx context.Context, ids []int64, msgID int64) error
+	ReleaseAttachments(ctx context.Context, ids []int64) error
+	FindMsgAttachments(ctx context.Context, convType int8, msgIDs []int64) ([]Attachment, error)
+
+	InsertUploadSession(ctx context.Context, s UploadSession) error
+	FindUploadSession(ctx context.Context, uploadID string) (UploadSession, error)
+	AppendUpload(ctx context.Context, uploadID string, offset, n, expireTime int64, write func() error) (bool, error)
+	ClaimUploadSession(ctx context.Context, uploadID string, expireTime int64) (bool, error)
+	ReleaseUploadSession(ctx context.Context, uploadID string) error
+	DeleteUploadSession(ctx context.Context, uploadID string) (bool, error)
+	FindExpiredUploadSessions(ctx context.Context, now int64, limit int) ([]UploadSession, error)
+
+	FileUsage(ctx context.Context, uid int64) (int64, int64, error)
+	ReleaseAvatars(ctx context.Context, uid, keepID int64) error
+	ReleaseGroupAvatars(ctx context.Context, groupID, keepID int64) error
+	FindExpiredAttachments(ctx context.Context, before int64, limit int) ([]Attachment, error)
+	DeleteAttachments(ctx context.Context, as []Attachment) error
+	FindUnreferencedFiles(ctx context.Context, before, afterID int64, limit int) ([]File, error)
+	DeleteFile(ctx context.Context, f File, purge func() error) (bool, error)
+	ReleaseFile(ctx context.Context, id int64) error
+
+	FindFileByHash(ctx context.Context, hash string) (File, error)
+	FindPendingScanFiles(ctx context.Context, afterID int64, limit int) ([]File, error)
+	MarkFilesClean(ctx context.Context, hash string) error
+	QuarantineFiles(ctx context.Context, hash, reason string) ([]Attachment, error)
 }
 
 type GormFileDAO struct {
@@ -21,3 +63,11 @@ func NewFileDAO(db *gorm.DB) FileDao {
 func (dao GormFileDAO) Avatar(ctx context.Context, u user_dao.User) error {
 	return dao.db.WithContext(ctx).Model(&u).Where("id = ?", u.ID).Update("Avatar", u.Avatar).Error
 }
+
+// GroupAvatar 更新群头像
+func (dao GormFileDAO) GroupAvatar(ctx context.Context, groupID int64, avatar string) error {
+	return dao.db.WithContext(ctx).Model(&group_dao.Group{}).Where("id = ?", groupID).Updates(map[string]any{
+		"avatar":      avatar,
+		"update_time": time.Now().UnixMilli(),
+	}).Error
+}
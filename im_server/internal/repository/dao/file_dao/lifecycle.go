@@ -0,0 +1,128 @@
+package file_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileUsage 统计用户上传的文件总大小和数量，内容相同的文件每次上传都计入
+func (dao *GormFileDAO) FileUsage(ctx context.Context, uid int64) (int64, int64, error) {
+	var res struct {
+		Size  int64
+		Count int64
+	}
+	err := dao.db.WithContext(ctx).Model(&File{}).
+		Select("COALESCE(SUM(size), 0) AS size, COUNT(*) AS count").
+		Where("user_id = ?", uid).
+		Scan(&res).Error
+	return res.Size, res.Count, err
+}
+
+// ReleaseAvatars 用户更换头像后，之前上传的头像不再被引用
+func (dao *GormFileDAO) ReleaseAvatars(ctx context.Context, uid, keepID int64) error {
+	return dao.db.WithContext(ctx).Model(&File{}).
+		Where("user_id = ? AND kind = ? AND id <> ? AND refs > ?", uid, "avatar", keepID, 0).
+		Update("refs", 0).Error
+}
+
+// ReleaseGroupAvatars 群更换头像后，之前上传的群头像不再被引用
+func (dao *GormFileDAO) ReleaseGroupAvatars(ctx context.Context, groupID, keepID int64) error {
+	return dao.db.WithContext(ctx).Model(&File{}).
+		Where("group_id = ? AND kind = ? AND id <> ? AND refs > ?", groupID, "group", keepID, 0).
+		Update("refs", 0).Error
+}
+
+// FindExpiredAttachments 查询绑定到消息的时间早于 before 的附件
+func (dao *GormFileDAO) FindExpiredAttachments(ctx context.Context, before int64, limit int) ([]Attachment, error) {
+	var as []Attachment
+	err := dao.db.WithContext(ctx).
+		Where("bind_time > ? AND bind_time < ?", 0, before).
+		Order("bind_time ASC").
+		Limit(limit).
+		Find(&as).Error
+	return as, err
+}
+
+// DeleteAttachments 删除附件记录，同时释放附件对文件记录的引用
+func (dao *GormFileDAO) DeleteAttachments(ctx context.Context, as []Attachment) error {
+	if len(as) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, a := range as {
+			res := tx.Where("id = ?", a.ID).Delete(&Attachment{})
+			if res.Error != nil {
+				return res.Error
+			}
+			// 只有绑定过消息的附件增加过引用
+			if res.RowsAffected == 0 || a.MsgID == 0 {
+				continue
+			}
+			err := tx.Model(&File{}).
+				Where("id = ? AND refs > ?", a.FileID, 0).
+				Update("refs", gorm.Expr("refs - ?", 1)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindUnreferencedFiles 按ID从 afterID 之后查询没有被引用且上传时间早于 before 的文件记录
+func (dao *GormFileDAO) FindUnreferencedFiles(ctx context.Context, before, afterID int64, limit int) ([]File, error) {
+	var fs []File
+	err := dao.db.WithContext(ctx).
+		Where("refs = ? AND create_time < ? AND id > ?", 0, before, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&fs).Error
+	return fs, err
+}
+
+// DeleteFile 删除没有被引用的文件记录和还没有发送的附件，返回是否删除
+// 附件正在被发送（已经占用但还没有绑定）时不删除；没有其他记录使用同一个文件时在同一个事务中调用 purge 删除存储后端中的文件，
+// 查询时锁住同样内容的记录，其他上传写入同样内容的记录需要等待事务结束；purge 失败时事务回滚，下次清理时重试
+func (dao *GormFileDAO) DeleteFile(ctx context.Context, f File, purge func() error) (bool, error) {
+	var deleted bool
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var claimed int64
+		err := tx.Model(&Attachment{}).
+			Where("file_id = ? AND conv_type <> ?", f.ID, 0).
+			Count(&claimed).Error
+		if err != nil || claimed > 0 {
+			return err
+		}
+		res := tx.Where("id = ? AND refs = ?", f.ID, 0).Delete(&File{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if err = tx.Where("file_id = ?", f.ID).Delete(&Attachment{}).Error; err != nil {
+			return err
+		}
+		var remain int64
+		err = tx.Model(&File{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hash = ? AND path = ?", f.Hash, f.Path).
+			Count(&remain).Error
+		if err != nil {
+			return err
+		}
+		if remain == 0 {
+			if err = purge(); err != nil {
+				return err
+			}
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
+
+// ReleaseFile 文件内容保存失败时释放刚写入的文件记录，之后由 DeleteFile 删除
+func (dao *GormFileDAO) ReleaseFile(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&File{}).
+		Where("id = ?", id).
+		Update("refs", 0).Error
+}
@@ -0,0 +1,20 @@
+package file_dao
+
+import (
+	"context"
+	"time"
+)
+
+// InsertFile 保存文件记录
+func (dao *GormFileDAO) InsertFile(ctx context.Context, f File) (File, error) {
+	f.CreateTime = time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Create(&f).Error
+	return f, err
+}
+
+// FindFileByID 查询文件记录
+func (dao *GormFileDAO) FindFileByID(ctx context.Context, id int64) (File, error) {
+	var f File
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&f).Error
+	return f, err
+}
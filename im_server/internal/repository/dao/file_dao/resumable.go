@@ -0,0 +1,97 @@
+package file_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// InsertUploadSession 创建断点续传任务
+func (dao *GormFileDAO) InsertUploadSession(ctx context.Context, s UploadSession) error {
+	now := time.Now().UnixMilli()
+	s.CreateTime = now
+	s.UpdateTime = now
+	return dao.db.WithContext(ctx).Create(&s).Error
+}
+
+// FindUploadSession 按任务ID查询断点续传任务
+func (dao *GormFileDAO) FindUploadSession(ctx context.Context, uploadID string) (UploadSession, error) {
+	var s UploadSession
+	err := dao.db.WithContext(ctx).Where("upload_id = ?", uploadID).First(&s).Error
+	return s, err
+}
+
+// AppendUpload 锁住上传中的任务，调用 write 把分片追加到已上传的内容后面，再推进已上传的大小并顺延过期时间
+// 已上传的大小不等于 offset 或任务正在完成时不调用 write，返回 false；write 失败时事务回滚
+func (dao *GormFileDAO) AppendUpload(ctx context.Context, uploadID string, offset, n, expireTime int64, write func() error) (bool, error) {
+	var ok bool
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var s UploadSession
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("upload_id = ?", uploadID).
+			First(&s).Error
+		if err != nil {
+			return err
+		}
+		if s.Status != 0 || s.Offset != offset {
+			return nil
+		}
+		if err = write(); err != nil {
+			return err
+		}
+		err = tx.Model(&UploadSession{}).
+			Where("id = ?", s.ID).
+			Updates(map[string]any{
+				"offset":      offset + n,
+				"expire_time": expireTime,
+				"update_time": time.Now().UnixMilli(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	})
+	return ok, err
+}
+
+// ClaimUploadSession 把已经上传完的任务标记为正在完成，同时顺延过期时间，避免完成过程中被清理任务删除
+// 任务已经被其他请求标记时返回 false
+func (dao *GormFileDAO) ClaimUploadSession(ctx context.Context, uploadID string, expireTime int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&UploadSession{}).
+		Where("upload_id = ? AND status = 0 AND `offset` = size", uploadID).
+		Updates(map[string]any{
+			"status":      1,
+			"expire_time": expireTime,
+			"update_time": time.Now().UnixMilli(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// ReleaseUploadSession 完成失败时恢复为上传中，客户端可以重新完成
+func (dao *GormFileDAO) ReleaseUploadSession(ctx context.Context, uploadID string) error {
+	return dao.db.WithContext(ctx).Model(&UploadSession{}).
+		Where("upload_id = ? AND status = 1", uploadID).
+		Updates(map[string]any{
+			"status":      0,
+			"update_time": time.Now().UnixMilli(),
+		}).Error
+}
+
+// DeleteUploadSession 删除断点续传任务，任务已经不存在时返回 false
+func (dao *GormFileDAO) DeleteUploadSession(ctx context.Context, uploadID string) (bool, error) {
+	res := dao.db.WithContext(ctx).Where("upload_id = ?", uploadID).Delete(&UploadSession{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// FindExpiredUploadSessions 查询已经过期的断点续传任务
+func (dao *GormFileDAO) FindExpiredUploadSessions(ctx context.Context, now int64, limit int) ([]UploadSession, error) {
+	var ss []UploadSession
+	err := dao.db.WithContext(ctx).
+		Where("expire_time < ?", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&ss).Error
+	return ss, err
+}
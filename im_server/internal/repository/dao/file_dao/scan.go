@@ -0,0 +1,56 @@
+package file_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// FindFileByHash 查询同样内容最近一次上传的文件记录
+func (dao *GormFileDAO) FindFileByHash(ctx context.Context, hash string) (File, error) {
+	var f File
+	err := dao.db.WithContext(ctx).Where("hash = ?", hash).Order("id DESC").First(&f).Error
+	return f, err
+}
+
+// FindPendingScanFiles 按ID从 afterID 之后查询等待扫描（scan_status 为 1）的文件记录
+func (dao *GormFileDAO) FindPendingScanFiles(ctx context.Context, afterID int64, limit int) ([]File, error) {
+	var fs []File
+	err := dao.db.WithContext(ctx).
+		Where("scan_status = ? AND id > ?", 1, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&fs).Error
+	return fs, err
+}
+
+// MarkFilesClean 同样内容的文件记录都标记为扫描通过
+func (dao *GormFileDAO) MarkFilesClean(ctx context.Context, hash string) error {
+	return dao.db.WithContext(ctx).Model(&File{}).
+		Where("hash = ? AND scan_status = ?", hash, 1).
+		Update("scan_status", 0).Error
+}
+
+// QuarantineFiles 隔离同样内容的全部文件记录和它们的附件，返回已经绑定到消息上的附件
+func (dao *GormFileDAO) QuarantineFiles(ctx context.Context, hash, reason string) ([]Attachment, error) {
+	var as []Attachment
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&File{}).Where("hash = ?", hash).Updates(map[string]any{
+			"scan_status": 2,
+			"scan_reason": reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		fileIDs := tx.Model(&File{}).Select("id").Where("hash = ?", hash)
+		err = tx.Model(&Attachment{}).Where("file_id IN (?)", fileIDs).Updates(map[string]any{
+			"blocked":     true,
+			"update_time": time.Now().UnixMilli(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("file_id IN (?) AND msg_id > ?", fileIDs, 0).Find(&as).Error
+	})
+	return as, err
+}
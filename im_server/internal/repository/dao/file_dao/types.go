@@ -0,0 +1,62 @@
+package file_dao
+
+// Attachment 聊天附件表，上传后归上传者所有，发送消息时绑定到消息上
+type Attachment struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64  // 上传时间
+	UpdateTime int64  // 更新时间
+	UserID     int64  `gorm:"not null;index"`  // 上传者用户ID
+	Kind       string `gorm:"size:8;not null"` // 附件类型 image video file voice
+	Title      string `gorm:"size:128"`        // 原始文件名
+	Size       int64  // 文件大小（字节）
+	MimeType   string `gorm:"size:128"` // 根据文件内容识别的类型
+	Type       string `gorm:"size:16"`  // 文件扩展名
+	Duration   int    // 音视频时长（秒）
+	Width      int    // 图片、视频宽度（像素）
+	Height     int    // 图片、视频高度（像素）
+	HasThumb   bool   // 是否有缩略图或视频封面
+	FileID     int64  `gorm:"not null;index"`                              // 文件记录ID
+	ConvType   int8   `gorm:"not null;default:0;index:idx_msg,priority:1"` // 绑定的消息所在的会话类型，未绑定为 0
+	MsgID      int64  `gorm:"not null;default:0;index:idx_msg,priority:2"` // 绑定的消息ID，未绑定为 0
+	BindTime   int64  `gorm:"not null;default:0;index"`                    // 绑定到消息的时间，超过保留期限后清理
+	Blocked    bool   `gorm:"not null;default:false"`                      // 文件没有通过内容扫描，已被隔离
+}
+
+// File 文件记录表，每次上传一行
+// 文件内容按 SHA-256 保存在 Path 下，Hash 相同的记录共用同一个文件，Name 只作为元数据保存
+// Refs 为引用计数，头像被用户资料或群资料引用，附件被消息引用，没有引用的记录由后台任务清理，最后一条记录删除时删除文件
+type File struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64  // 上传时间
+	UserID     int64  `gorm:"not null;index"`         // 上传者用户ID
+	Kind       string `gorm:"size:8;not null"`        // 文件用途 avatar group image video file voice
+	Name       string `gorm:"size:128"`               // 原始文件名
+	Hash       string `gorm:"size:64;not null;index"` // 文件内容的 SHA-256
+	Size       int64  // 文件大小（字节）
+	MimeType   string `gorm:"size:128"`          // 根据文件内容识别的类型
+	Path       string `gorm:"size:256;not null"` // 存储后端中的对象路径
+	Width      int    // 图片、视频宽度（像素）
+	Height     int    // 图片、视频高度（像素）
+	Duration   int    // 音视频时长（秒）
+	Thumb      string `gorm:"size:256"`                 // 图片缩略图或视频封面的对象路径
+	Refs       int    `gorm:"not null;default:0;index"` // 引用计数
+	GroupID    int64  `gorm:"not null;default:0;index"` // 群头像所属的群ID，其他文件为 0
+	ScanStatus int8   `gorm:"not null;default:0;index"` // 内容扫描状态 0 通过或不需要扫描 1 等待扫描 2 已隔离
+	ScanReason string `gorm:"size:128"`                 // 隔离原因
+}
+
+// UploadSession 断点续传任务表，已上传的内容保存在本地临时文件中，完成或过期后删除
+type UploadSession struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64  // 创建时间
+	UpdateTime int64  // 更新时间
+	UploadID   string `gorm:"size:32;not null;uniqueIndex"` // 返回给客户端的任务ID
+	UserID     int64  `gorm:"not null;index"`               // 上传者用户ID
+	Kind       string `gorm:"size:8;not null"`              // 附件类型
+	Name       string `gorm:"size:128"`                     // 原始文件名
+	Size       int64  // 文件总大小（字节）
+	Hash       string `gorm:"size:64;not null"` // 客户端声明的 SHA-256
+	Offset     int64  // 已上传的大小
+	Status     int8   `gorm:"not null;default:0"` // 0 上传中 1 正在完成
+	ExpireTime int64  `gorm:"not null;index"`     // 过期时间
+}
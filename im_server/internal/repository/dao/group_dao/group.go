@@ -1 +1,154 @@
 package group_dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	ErrMemberExists   = errors.New("已经是群成员")
+	ErrVerifyHandled  = errors.New("验证请求已处理")
+	ErrGroupFull      = errors.New("群成员已满")
+	ErrLinkUnusable   = errors.New("邀请链接已失效")
+)
+
+type GroupDao interface {
+	FindByID(ctx context.Context, id int64) (Group, error)
+	FindByIDs(ctx context.Context, ids []int64) ([]Group, error)
+	FindMember(ctx context.Context, groupID, uid int64) (GroupMember, error)
+	FindMembers(ctx context.Context, groupID int64) ([]GroupMember, error)
+	CountMembers(ctx context.Context, groupID int64) (int64, error)
+	FindGroupIDsByUser(ctx context.Context, uid int64) ([]int64, error)
+	SearchGroups(ctx context.Context, keyword string, groupID, lastID int64, limit int) ([]Group, error)
+	CountMembersByGroups(ctx context.Context, groupIDs []int64) (map[int64]int64, error)
+	FindMembersByUsers(ctx context.Context, groupIDs, uids []int64) ([]GroupMember, error)
+	FindMemberPage(ctx context.Context, groupID int64, keyword string, offset, limit int) ([]MemberUser, int64, error)
+	UpdateMemberNickname(ctx context.Context, groupID, uid int64, nickname string) error
+
+	InsertNotice(ctx context.Context, n GroupNotice) (GroupNotice, error)
+	UpdateNotice(ctx context.Context, n GroupNotice, resetConfirm bool) error
+	UpdateNoticePinned(ctx context.Context, id int64, pinned bool) error
+	DeleteNotice(ctx context.Context, id int64) error
+	FindNoticeByID(ctx context.Context, id int64) (GroupNotice, error)
+	FindNotices(ctx context.Context, groupID int64) ([]GroupNotice, error)
+	InsertNoticeConfirm(ctx context.Context, noticeID, uid int64) error
+	FindNoticeConfirms(ctx context.Context, noticeID int64) ([]GroupNoticeConfirm, error)
+	FindConfirmedNoticeIDs(ctx context.Context, uid int64, noticeIDs []int64) ([]int64, error)
+
+	InsertMember(ctx context.Context, m GroupMember, linkID int64) error
+	InsertVerify(ctx context.Context, v GroupVerify) (GroupVerify, error)
+	FindVerifyByID(ctx context.Context, id int64) (GroupVerify, error)
+	FindPendingVerify(ctx context.Context, groupID, uid int64) (GroupVerify, error)
+	FindPendingVerifies(ctx context.Context, groupID int64) ([]GroupVerify, error)
+	FindPendingVerifyGroupIDs(ctx context.Context, uid int64, groupIDs []int64) ([]int64, error)
+	UpdateVerifyStatus(ctx context.Context, id int64, status int8) error
+	InsertInviteLink(ctx context.Context, link GroupInviteLink) (GroupInviteLink, error)
+	FindInviteLinkByID(ctx context.Context, id int64) (GroupInviteLink, error)
+	FindInviteLinkByToken(ctx context.Context, token string) (GroupInviteLink, error)
+	FindInviteLinks(ctx context.Context, groupID int64) ([]GroupInviteLink, error)
+	RevokeInviteLink(ctx context.Context, id int64) error
+}
+
+type GormGroupDAO struct {
+	db *gorm.DB
+}
+
+func NewGroupDAO(db *gorm.DB) GroupDao {
+	return &GormGroupDAO{db: db}
+}
+
+// FindByID 查询群信息
+func (dao *GormGroupDAO) FindByID(ctx context.Context, id int64) (Group, error) {
+	var group Group
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&group).Error
+	return group, err
+}
+
+// FindByIDs 批量查询群信息
+func (dao *GormGroupDAO) FindByIDs(ctx context.Context, ids []int64) ([]Group, error) {
+	var groups []Group
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&groups).Error
+	return groups, err
+}
+
+// FindMember 查询某个用户在群里的成员信息，不是群成员时返回 ErrRecordNotFound
+func (dao *GormGroupDAO) FindMember(ctx context.Context, groupID, uid int64) (GroupMember, error) {
+	var member GroupMember
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ? AND user_id = ?", groupID, uid).
+		First(&member).Error
+	return member, err
+}
+
+// FindMembers 查询群的全部成员
+func (dao *GormGroupDAO) FindMembers(ctx context.Context, groupID int64) ([]GroupMember, error) {
+	var members []GroupMember
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Order("id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// CountMembers 统计群成员数量
+func (dao *GormGroupDAO) CountMembers(ctx context.Context, groupID int64) (int64, error) {
+	var cnt int64
+	err := dao.db.WithContext(ctx).Model(&GroupMember{}).
+		Where("group_id = ?", groupID).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+// FindGroupIDsByUser 查询用户加入的全部群ID
+func (dao *GormGroupDAO) FindGroupIDsByUser(ctx context.Context, uid int64) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&GroupMember{}).
+		Where("user_id = ?", uid).
+		Pluck("group_id", &ids).Error
+	return ids, err
+}
+
+// SearchGroups 搜索允许被搜索的群，keyword 是数字时同时按群ID精确匹配，按ID正序分页
+func (dao *GormGroupDAO) SearchGroups(ctx context.Context, keyword string, groupID, lastID int64, limit int) ([]Group, error) {
+	var groups []Group
+	query := dao.db.WithContext(ctx).Where("is_search = ? AND id > ?", true, lastID)
+	if groupID > 0 {
+		query = query.Where("(id = ? OR title LIKE ?)", groupID, "%"+escapeLike(keyword)+"%")
+	} else {
+		query = query.Where("title LIKE ?", "%"+escapeLike(keyword)+"%")
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&groups).Error
+	return groups, err
+}
+
+// CountMembersByGroups 批量统计群成员数量
+func (dao *GormGroupDAO) CountMembersByGroups(ctx context.Context, groupIDs []int64) (map[int64]int64, error) {
+	type count struct {
+		GroupID int64
+		Count   int64
+	}
+	var counts []count
+	res := make(map[int64]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&GroupMember{}).
+		Select("group_id, COUNT(*) AS count").
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&counts).Error
+	for _, c := range counts {
+		res[c.GroupID] = c.Count
+	}
+	return res, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,184 @@
+package group_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// InsertMember 添加群成员，已经是群成员时返回 ErrMemberExists，群规模已满时返回 ErrGroupFull
+// linkID 不为 0 时在同一个事务中使用一次邀请链接，链接不可用时返回 ErrLinkUnusable，入群失败时不占用次数
+func (dao *GormGroupDAO) InsertMember(ctx context.Context, m GroupMember, linkID int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var (
+			g   Group
+			cnt int64
+		)
+		// 锁住群信息，避免同一个用户并发加入两次，也避免并发入群超过群规模
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", m.GroupID).
+			First(&g).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&GroupMember{}).
+			Where("group_id = ? AND user_id = ?", m.GroupID, m.UserID).
+			Count(&cnt).Error
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrMemberExists
+		}
+		if g.Size > 0 {
+			err = tx.Model(&GroupMember{}).Where("group_id = ?", m.GroupID).Count(&cnt).Error
+			if err != nil {
+				return err
+			}
+			if cnt >= int64(g.Size) {
+				return ErrGroupFull
+			}
+		}
+		if err = useInviteLink(tx, linkID); err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		m.CreateTime = now
+		m.UpdateTime = now
+		return tx.Omit(clause.Associations).Create(&m).Error
+	})
+}
+
+// InsertVerify 创建入群验证请求，通过邀请链接提交时在同一个事务中使用一次链接，规则同 InsertMember
+func (dao *GormGroupDAO) InsertVerify(ctx context.Context, v GroupVerify) (GroupVerify, error) {
+	now := time.Now().UnixMilli()
+	v.CreateTime = now
+	v.UpdateTime = now
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := useInviteLink(tx, v.InviteLinkID); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&v).Error
+	})
+	return v, err
+}
+
+// FindVerifyByID 查询入群验证请求
+func (dao *GormGroupDAO) FindVerifyByID(ctx context.Context, id int64) (GroupVerify, error) {
+	var v GroupVerify
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&v).Error
+	return v, err
+}
+
+// FindPendingVerify 查询用户在群里还没有处理的验证请求，没有时返回 ErrRecordNotFound
+func (dao *GormGroupDAO) FindPendingVerify(ctx context.Context, groupID, uid int64) (GroupVerify, error) {
+	var v GroupVerify
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ? AND user_id = ? AND status = ?", groupID, uid, 0).
+		First(&v).Error
+	return v, err
+}
+
+// FindPendingVerifies 查询群里全部还没有处理的验证请求
+func (dao *GormGroupDAO) FindPendingVerifies(ctx context.Context, groupID int64) ([]GroupVerify, error) {
+	var vs []GroupVerify
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ? AND status = ?", groupID, 0).
+		Order("id ASC").
+		Find(&vs).Error
+	return vs, err
+}
+
+// UpdateVerifyStatus 处理验证请求，只能处理未操作的请求，已经处理过时返回 ErrVerifyHandled
+func (dao *GormGroupDAO) UpdateVerifyStatus(ctx context.Context, id int64, status int8) error {
+	res := dao.db.WithContext(ctx).Model(&GroupVerify{}).
+		Where("id = ? AND status = ?", id, 0).
+		Updates(map[string]any{
+			"status":      status,
+			"update_time": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrVerifyHandled
+	}
+	return nil
+}
+
+// InsertInviteLink 创建邀请链接
+func (dao *GormGroupDAO) InsertInviteLink(ctx context.Context, link GroupInviteLink) (GroupInviteLink, error) {
+	now := time.Now().UnixMilli()
+	link.CreateTime = now
+	link.UpdateTime = now
+	err := dao.db.WithContext(ctx).Create(&link).Error
+	return link, err
+}
+
+// FindInviteLinkByID 查询邀请链接
+func (dao *GormGroupDAO) FindInviteLinkByID(ctx context.Context, id int64) (GroupInviteLink, error) {
+	var link GroupInviteLink
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&link).Error
+	return link, err
+}
+
+// FindInviteLinkByToken 按邀请码查询邀请链接
+func (dao *GormGroupDAO) FindInviteLinkByToken(ctx context.Context, token string) (GroupInviteLink, error) {
+	var link GroupInviteLink
+	err := dao.db.WithContext(ctx).Where("token = ?", token).First(&link).Error
+	return link, err
+}
+
+// FindInviteLinks 查询群里全部没有撤销的邀请链接，包括已经过期和用完的
+func (dao *GormGroupDAO) FindInviteLinks(ctx context.Context, groupID int64) ([]GroupInviteLink, error) {
+	var links []GroupInviteLink
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ? AND revoked = ?", groupID, false).
+		Order("id DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// RevokeInviteLink 撤销邀请链接
+func (dao *GormGroupDAO) RevokeInviteLink(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&GroupInviteLink{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"revoked":     true,
+			"update_time": time.Now().UnixMilli(),
+		}).Error
+}
+
+// useInviteLink 在事务中使用一次邀请链接，链接已撤销、过期或次数用完时返回 ErrLinkUnusable，id 为 0 时不做任何事
+func useInviteLink(tx *gorm.DB, id int64) error {
+	if id == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	res := tx.Model(&GroupInviteLink{}).
+		Where("id = ? AND revoked = ? AND expire_time > ? AND (max_uses = 0 OR uses < max_uses)", id, false, now).
+		Updates(map[string]any{
+			"uses":        gorm.Expr("uses + 1"),
+			"update_time": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLinkUnusable
+	}
+	return nil
+}
+
+// FindPendingVerifyGroupIDs 在 groupIDs 中查询用户有未处理验证请求的群ID
+func (dao *GormGroupDAO) FindPendingVerifyGroupIDs(ctx context.Context, uid int64, groupIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(groupIDs) == 0 {
+		return ids, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&GroupVerify{}).
+		Where("user_id = ? AND status = ? AND group_id IN ?", uid, 0, groupIDs).
+		Pluck("group_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,67 @@
+package group_dao
+
+import (
+	"context"
+	"strconv"
+)
+
+// MemberUser 群成员和用户信息的联表查询结果
+type MemberUser struct {
+	ID              int64
+	CreateTime      int64
+	UpdateTime      int64
+	MemberNickname  string
+	Role            int
+	ProhibitionTime int64
+	GroupID         int64
+	UserID          int64
+	Nickname        string
+	Avatar          string
+}
+
+// FindMembersByUsers 批量查询 uids 在 groupIDs 这些群里的成员信息
+func (dao *GormGroupDAO) FindMembersByUsers(ctx context.Context, groupIDs, uids []int64) ([]GroupMember, error) {
+	var members []GroupMember
+	if len(groupIDs) == 0 || len(uids) == 0 {
+		return members, nil
+	}
+	err := dao.db.WithContext(ctx).
+		Where("group_id IN ? AND user_id IN ?", groupIDs, uids).
+		Find(&members).Error
+	return members, err
+}
+
+// FindMemberPage 分页查询群成员，群主、管理员在前，同一角色按入群先后排序
+// keyword 按群昵称、用户昵称模糊匹配，是数字时同时按用户ID精确匹配
+func (dao *GormGroupDAO) FindMemberPage(ctx context.Context, groupID int64, keyword string, offset, limit int) ([]MemberUser, int64, error) {
+	query := dao.db.WithContext(ctx).Table("group_members AS gm").
+		Joins("LEFT JOIN users AS u ON u.id = gm.user_id").
+		Where("gm.group_id = ?", groupID)
+	if keyword != "" {
+		like := "%" + escapeLike(keyword) + "%"
+		if uid, err := strconv.ParseInt(keyword, 10, 64); err == nil {
+			query = query.Where("(gm.member_nickname LIKE ? OR u.nickname LIKE ? OR gm.user_id = ?)", like, like, uid)
+		} else {
+			query = query.Where("(gm.member_nickname LIKE ? OR u.nickname LIKE ?)", like, like)
+		}
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var members []MemberUser
+	err := query.
+		Select("gm.id, gm.create_time, gm.update_time, gm.member_nickname, gm.role, gm.prohibition_time, gm.group_id, gm.user_id, u.nickname, u.avatar").
+		Order("gm.role ASC, gm.id ASC").
+		Offset(offset).Limit(limit).
+		Scan(&members).Error
+	return members, total, err
+}
+
+// UpdateMemberNickname 修改群昵称
+// 禁言时长从 update_time 开始计算，这里不能刷新 update_time
+func (dao *GormGroupDAO) UpdateMemberNickname(ctx context.Context, groupID, uid int64, nickname string) error {
+	return dao.db.WithContext(ctx).Model(&GroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, uid).
+		Update("member_nickname", nickname).Error
+}
@@ -0,0 +1,107 @@
+package group_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// InsertNotice 发布群公告
+func (dao *GormGroupDAO) InsertNotice(ctx context.Context, n GroupNotice) (GroupNotice, error) {
+	now := time.Now().UnixMilli()
+	n.CreateTime = now
+	n.UpdateTime = now
+	if n.IsPinned {
+		n.PinTime = now
+	}
+	err := dao.db.WithContext(ctx).Create(&n).Error
+	return n, err
+}
+
+// UpdateNotice 编辑群公告，resetConfirm 为 true 时清空已有的确认记录，成员需要重新确认
+func (dao *GormGroupDAO) UpdateNotice(ctx context.Context, n GroupNotice, resetConfirm bool) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&GroupNotice{}).Where("id = ?", n.ID).Updates(map[string]any{
+			"title":        n.Title,
+			"content":      n.Content,
+			"need_confirm": n.NeedConfirm,
+			"edit_user_id": n.EditUserID,
+			"update_time":  time.Now().UnixMilli(),
+		}).Error
+		if err != nil || !resetConfirm {
+			return err
+		}
+		return tx.Where("notice_id = ?", n.ID).Delete(&GroupNoticeConfirm{}).Error
+	})
+}
+
+// UpdateNoticePinned 置顶或取消置顶群公告
+func (dao *GormGroupDAO) UpdateNoticePinned(ctx context.Context, id int64, pinned bool) error {
+	var pinTime int64
+	if pinned {
+		pinTime = time.Now().UnixMilli()
+	}
+	return dao.db.WithContext(ctx).Model(&GroupNotice{}).Where("id = ?", id).Updates(map[string]any{
+		"is_pinned": pinned,
+		"pin_time":  pinTime,
+	}).Error
+}
+
+// DeleteNotice 删除群公告和它的确认记录
+func (dao *GormGroupDAO) DeleteNotice(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notice_id = ?", id).Delete(&GroupNoticeConfirm{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&GroupNotice{}).Error
+	})
+}
+
+// FindNoticeByID 查询群公告，不存在时返回 ErrRecordNotFound
+func (dao *GormGroupDAO) FindNoticeByID(ctx context.Context, id int64) (GroupNotice, error) {
+	var n GroupNotice
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&n).Error
+	return n, err
+}
+
+// FindNotices 查询群的全部公告，置顶的在前，其余按发布时间倒序
+func (dao *GormGroupDAO) FindNotices(ctx context.Context, groupID int64) ([]GroupNotice, error) {
+	var notices []GroupNotice
+	err := dao.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Order("is_pinned DESC, pin_time DESC, id DESC").
+		Find(&notices).Error
+	return notices, err
+}
+
+// InsertNoticeConfirm 确认群公告，重复确认不报错
+func (dao *GormGroupDAO) InsertNoticeConfirm(ctx context.Context, noticeID, uid int64) error {
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&GroupNoticeConfirm{
+		CreateTime: time.Now().UnixMilli(),
+		NoticeID:   noticeID,
+		UserID:     uid,
+	}).Error
+}
+
+// FindNoticeConfirms 查询群公告的全部确认记录
+func (dao *GormGroupDAO) FindNoticeConfirms(ctx context.Context, noticeID int64) ([]GroupNoticeConfirm, error) {
+	var confirms []GroupNoticeConfirm
+	err := dao.db.WithContext(ctx).
+		Where("notice_id = ?", noticeID).
+		Order("id ASC").
+		Find(&confirms).Error
+	return confirms, err
+}
+
+// FindConfirmedNoticeIDs 在 noticeIDs 中查询 uid 已经确认过的公告ID
+func (dao *GormGroupDAO) FindConfirmedNoticeIDs(ctx context.Context, uid int64, noticeIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(noticeIDs) == 0 {
+		return ids, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&GroupNoticeConfirm{}).
+		Where("user_id = ? AND notice_id IN ?", uid, noticeIDs).
+		Pluck("notice_id", &ids).Error
+	return ids, err
+}
@@ -25,10 +25,12 @@ type GroupVerify struct {
 	CreateTime           int64   // 创建时间
 	UpdateTime           int64   // 更新时间
 	GroupModel           Group   `gorm:"foreignKey:GroupID"` // 群
-	Status               int8    // 验证状态
+	Status               int8    // 验证状态 0 未操作 1 同意 2 拒绝
 	AdditionalMessages   string  `gorm:"size:32"`   // 附加消息
 	VerificationQuestion *string `gorm:"type:json"` // 验证问题
-	Type                 int8    // 验证类型
+	Type                 int8    // 验证类型 1 主动申请 2 成员邀请 3 邀请链接
+	InviterID            int64   // 邀请人用户ID，主动申请为 0
+	InviteLinkID         int64   // 通过邀请链接加入时的链接ID
 
 	GroupID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 群ID
 	UserID  int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 用户ID
@@ -41,9 +43,80 @@ type GroupMember struct {
 	UpdateTime      int64  // 更新时间
 	GroupModel      Group  `gorm:"foreignKey:GroupID"` // 群
 	MemberNickname  string `gorm:"size:32"`            // 群成员昵称
-	Role            int    // 成员角色
+	Role            int    // 成员角色 1 群主 2 管理员 3 普通成员
 	ProhibitionTime int64  // 禁言时间（单位：分钟，0 表示未禁言）
 
 	GroupID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 群ID
 	UserID  int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 用户ID
 }
+
+// GroupNotice 群公告表
+type GroupNotice struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime  int64  // 发布时间
+	UpdateTime  int64  // 更新时间
+	Title       string `gorm:"size:64"`                // 标题
+	Content     string `gorm:"type:text"`              // 内容
+	NeedConfirm bool   `gorm:"not null;default:false"` // 是否需要群成员确认
+	IsPinned    bool   `gorm:"not null;default:false"` // 是否置顶
+	PinTime     int64  // 置顶时间，置顶公告之间按置顶时间倒序
+	UserID      int64  // 发布者用户ID
+	EditUserID  int64  // 最后编辑者用户ID，未编辑为 0
+
+	GroupID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 群ID
+}
+
+// GroupNoticeConfirm 群公告确认表，每个成员对每条公告最多一行
+type GroupNoticeConfirm struct {
+	ID         int64 `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64 // 确认时间
+	NoticeID   int64 `gorm:"not null;uniqueIndex:idx_notice_user,priority:1"`       // 公告ID
+	UserID     int64 `gorm:"not null;uniqueIndex:idx_notice_user,priority:2;index"` // 用户ID
+}
+
+// GroupInviteLink 群邀请链接表
+type GroupInviteLink struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID
+	CreateTime int64  // 创建时间
+	UpdateTime int64  // 更新时间
+	Token      string `gorm:"size:32;not null;uniqueIndex"` // 链接中的邀请码
+	CreatorID  int64  // 创建者用户ID
+	ExpireTime int64  // 过期时间
+	MaxUses    int    // 最多使用次数，0 表示不限
+	Uses       int    // 已使用次数
+	Revoked    bool   `gorm:"not null;default:false"` // 是否已撤销
+
+	GroupID int64 `gorm:"not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // 群ID
+}
+
+// 映射实现
+
+// 成员角色
+
+// GetRoleText 响应给前端：在返回数据时，将数值转为文本
+func GetRoleText(role int) string {
+	switch role {
+	case 1:
+		return "群主"
+	case 2:
+		return "管理员"
+	case 3:
+		return "普通成员"
+	default:
+		return "未知"
+	}
+}
+
+// GetRoleValue 后端接受处理时解析为数值存储
+func GetRoleValue(role string) int {
+	switch role {
+	case "群主":
+		return 1
+	case "管理员":
+		return 2
+	case "普通成员":
+		return 3
+	default:
+		return 99 // 未知或未指定
+	}
+}
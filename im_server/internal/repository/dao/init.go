@@ -2,7 +2,9 @@ package dao
 
 import (
 	"github.com/ink-yht/im/internal/repository/dao/chat_dao"
+	"github.com/ink-yht/im/internal/repository/dao/file_dao"
 	"github.com/ink-yht/im/internal/repository/dao/group_dao"
+	"github.com/ink-yht/im/internal/repository/dao/search_dao"
 	"github.com/ink-yht/im/internal/repository/dao/user_dao"
 	"gorm.io/gorm"
 )
@@ -14,11 +16,28 @@ func InitTables(db *gorm.DB) error {
 		&user_dao.FriendRequest{}, // 好友验证表
 		&user_dao.UserConf{},      // 用户配置表
 
-		&group_dao.Group{},       // 群信息表
-		&group_dao.GroupMember{}, // 群成员表
-		&group_dao.GroupVerify{}, // 群验证表
+		&group_dao.Group{},              // 群信息表
+		&group_dao.GroupMember{},        // 群成员表
+		&group_dao.GroupVerify{},        // 群验证表
+		&group_dao.GroupNotice{},        // 群公告表
+		&group_dao.GroupNoticeConfirm{}, // 群公告确认表
+		&group_dao.GroupInviteLink{},    // 群邀请链接表
 
-		&chat_dao.Chat{},     // 用户消息表
-		&chat_dao.GroupMsg{}, // 群消息表
+		&chat_dao.Chat{},         // 用户消息表
+		&chat_dao.GroupMsg{},     // 群消息表
+		&chat_dao.Conversation{}, // 用户会话表
+		&chat_dao.UserSeq{},      // 用户序号表
+		&chat_dao.Inbox{},        // 用户收件箱表
+		&chat_dao.Mention{},      // @提醒表
+		&chat_dao.MsgHidden{},    // 群消息删除表
+		&chat_dao.MsgEdit{},      // 消息编辑历史表
+		&chat_dao.Reaction{},     // 消息表情回应表
+		&chat_dao.PinnedMsg{},    // 置顶消息表
+
+		&search_dao.MsgIndex{}, // 消息全文索引表
+
+		&file_dao.File{},          // 文件记录表
+		&file_dao.Attachment{},    // 聊天附件表
+		&file_dao.UploadSession{}, // 断点续传任务表
 	)
 }
@@ -0,0 +1,130 @@
+package search_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchDao 消息索引的存储后端，可以替换为 bleve、ES 等其他实现
+type SearchDao interface {
+	Insert(ctx context.Context, docs []MsgIndex) error
+	Delete(ctx context.Context, convType int8, msgIDs []int64) error
+	DeleteByOwner(ctx context.Context, ownerID int64, convType int8, convID int64, msgIDs []int64) error
+	UpdateText(ctx context.Context, convType int8, msgID int64, text string) error
+	Search(ctx context.Context, q Query) ([]MsgIndex, error)
+}
+
+// Query 搜索条件，私聊只搜索 OwnerID 自己的索引，群聊只搜索 GroupIDs 中的群
+type Query struct {
+	Keyword    string
+	OwnerID    int64
+	GroupIDs   []int64
+	ConvType   int8
+	ConvID     int64
+	SendUserID int64
+	MsgType    int8
+	StartTime  int64
+	EndTime    int64
+	LastID     int64
+	Limit      int
+}
+
+// MySQLSearchDAO 基于 MySQL FULLTEXT 索引和 ngram 分词的实现
+type MySQLSearchDAO struct {
+	db *gorm.DB
+}
+
+func NewSearchDAO(db *gorm.DB) SearchDao {
+	return &MySQLSearchDAO{db: db}
+}
+
+func (dao *MySQLSearchDAO) Insert(ctx context.Context, docs []MsgIndex) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Create(&docs).Error
+}
+
+// Delete 删除消息的全部索引，用于撤回等对所有人生效的场景
+func (dao *MySQLSearchDAO) Delete(ctx context.Context, convType int8, msgIDs []int64) error {
+	if len(msgIDs) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).
+		Where("conv_type = ? AND msg_id IN ?", convType, msgIDs).
+		Delete(&MsgIndex{}).Error
+}
+
+// DeleteByOwner 删除某个用户在会话中的索引，msgIDs 为空时删除整个会话
+func (dao *MySQLSearchDAO) DeleteByOwner(ctx context.Context, ownerID int64, convType int8, convID int64, msgIDs []int64) error {
+	db := dao.db.WithContext(ctx).
+		Where("owner_id = ? AND conv_type = ? AND conv_id = ?", ownerID, convType, convID)
+	if len(msgIDs) > 0 {
+		db = db.Where("msg_id IN ?", msgIDs)
+	}
+	return db.Delete(&MsgIndex{}).Error
+}
+
+// UpdateText 消息编辑后更新已有索引的文本，已经被删除的索引不会恢复
+func (dao *MySQLSearchDAO) UpdateText(ctx context.Context, convType int8, msgID int64, text string) error {
+	return dao.db.WithContext(ctx).Model(&MsgIndex{}).
+		Where("conv_type = ? AND msg_id = ?", convType, msgID).
+		Update("text", text).Error
+}
+
+// Search 按ID倒序返回命中的索引
+// ngram 默认按两个字切分，单个字的关键词走不到全文索引，退化为 LIKE
+func (dao *MySQLSearchDAO) Search(ctx context.Context, q Query) ([]MsgIndex, error) {
+	var docs []MsgIndex
+	db := dao.db.WithContext(ctx)
+	if utf8.RuneCountInString(q.Keyword) < 2 {
+		db = db.Where("text LIKE ?", "%"+escapeLike(q.Keyword)+"%")
+	} else {
+		db = db.Where("MATCH(text) AGAINST(? IN BOOLEAN MODE)", `"`+strings.ReplaceAll(q.Keyword, `"`, " ")+`"`)
+	}
+
+	switch q.ConvType {
+	case 1:
+		db = db.Where("conv_type = ? AND owner_id = ?", 1, q.OwnerID)
+	case 2:
+		db = db.Where("conv_type = ? AND conv_id IN ?", 2, nonEmpty(q.GroupIDs))
+	default:
+		db = db.Where("((conv_type = ? AND owner_id = ?) OR (conv_type = ? AND conv_id IN ?))",
+			1, q.OwnerID, 2, nonEmpty(q.GroupIDs))
+	}
+	// 会话ID和会话类型一起过滤，避免私聊的对方用户ID匹配到同样ID的群
+	if q.ConvID > 0 {
+		db = db.Where("conv_type = ? AND conv_id = ?", q.ConvType, q.ConvID)
+	}
+	if q.SendUserID > 0 {
+		db = db.Where("send_user_id = ?", q.SendUserID)
+	}
+	if q.MsgType > 0 {
+		db = db.Where("msg_type = ?", q.MsgType)
+	}
+	if q.StartTime > 0 {
+		db = db.Where("msg_time >= ?", q.StartTime)
+	}
+	if q.EndTime > 0 {
+		db = db.Where("msg_time < ?", q.EndTime)
+	}
+	if q.LastID > 0 {
+		db = db.Where("id < ?", q.LastID)
+	}
+	err := db.Order("id DESC").Limit(q.Limit).Find(&docs).Error
+	return docs, err
+}
+
+// nonEmpty 空切片会生成 IN () 语法错误，用一个不存在的ID代替
+func nonEmpty(ids []int64) []int64 {
+	if len(ids) == 0 {
+		return []int64{0}
+	}
+	return ids
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
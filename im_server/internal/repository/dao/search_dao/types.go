@@ -0,0 +1,17 @@
+package search_dao
+
+// MsgIndex 消息全文索引表
+// 私聊消息为收发双方各建一行，OwnerID 为所属用户，ConvID 为对方用户ID，删除消息时只删除自己那一行
+// 群消息只建一行，OwnerID 为 0，ConvID 为群ID，可见性由群成员关系决定
+type MsgIndex struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"` // ID，同时作为搜索结果的分页游标
+	CreateTime int64  // 创建时间
+	ConvType   int8   `gorm:"not null;index:idx_owner_conv,priority:2;index:idx_conv_msg,priority:1"` // 会话类型 1 私聊 2 群聊
+	ConvID     int64  `gorm:"not null;index:idx_owner_conv,priority:3"`                               // 会话ID 私聊为对方用户ID 群聊为群ID
+	OwnerID    int64  `gorm:"not null;index:idx_owner_conv,priority:1"`                               // 所属用户ID，群消息为 0
+	MsgID      int64  `gorm:"not null;index:idx_conv_msg,priority:2"`                                 // 私聊为 Chat.ID 群聊为 GroupMsg.ID
+	SendUserID int64  // 发送者用户ID
+	MsgType    int8   // 消息类型
+	MsgTime    int64  // 消息发送时间
+	Text       string `gorm:"type:text;index:idx_text,class:FULLTEXT,option:WITH PARSER ngram"` // 被索引的文本，ngram 分词以支持中文
+}
@@ -1 +1,39 @@
 package user_dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+type FriendDao interface {
+	IsFriend(ctx context.Context, uid, friendID int64) (bool, error)
+}
+
+type GormFriendDAO struct {
+	db *gorm.DB
+}
+
+func NewFriendDAO(db *gorm.DB) FriendDao {
+	return &GormFriendDAO{db: db}
+}
+
+// IsFriend 判断两个用户是否为好友
+// 双方之间至少有一条好友记录，并且任意一方都没有拉黑对方
+func (dao *GormFriendDAO) IsFriend(ctx context.Context, uid, friendID int64) (bool, error) {
+	var friends []Friend
+	err := dao.db.WithContext(ctx).
+		Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", uid, friendID, friendID, uid).
+		Find(&friends).Error
+	if err != nil {
+		return false, err
+	}
+	if len(friends) == 0 {
+		return false, nil
+	}
+	for _, f := range friends {
+		if f.Status != 1 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
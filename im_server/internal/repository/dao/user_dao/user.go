@@ -18,6 +18,7 @@ type UserDao interface {
 	Insert(ctx context.Context, u User) error
 	FindByEmail(ctx context.Context, email string) (User, error)
 	FindByID(ctx context.Context, id int64) (User, error)
+	FindByIDs(ctx context.Context, ids []int64) ([]User, error)
 	UpdateInfo(ctx context.Context, u User) error
 }
 
@@ -85,6 +86,16 @@ func (dao *GormUserDAO) FindByID(ctx context.Context, id int64) (User, error) {
 	return user, err
 }
 
+// FindByIDs 批量查询用户基本信息，不加载用户配置
+func (dao *GormUserDAO) FindByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	var users []User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := dao.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // FindByEmail 查询邮箱
 func (dao *GormUserDAO) FindByEmail(ctx context.Context, email string) (User, error) {
 	var user User
@@ -96,13 +107,15 @@ func (dao *GormUserDAO) FindByEmail(ctx context.Context, email string) (User, er
 func (dao *GormUserDAO) Insert(ctx context.Context, u User) error {
 	// 写入数据库
 
-	avatarPath := "/uploads/avatar/logo.png" // 默认头像路径
+	// 注册后会生成默认头像，生成失败时使用统一的默认头像
+	if u.Avatar == "" {
+		u.Avatar = "/uploads/avatar/logo.png"
+	}
 
 	// 毫秒
 	now := time.Now().UnixMilli()
 	u.CreateTime = now
 	u.UpdateTime = now
-	u.Avatar = avatarPath
 	u.UserConf.CreateTime = now
 	u.UserConf.UpdateTime = now
 
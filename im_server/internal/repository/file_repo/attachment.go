@@ -0,0 +1,113 @@
+package file_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"time"
+)
+
+func (repo *FileRepositoryImpl) CreateAttachment(ctx context.Context, a file_domain.Attachment) (file_domain.Attachment, error) {
+	res, err := repo.dao.InsertAttachment(ctx, attachmentDomainToEntity(a))
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	return attachmentEntityToDomain(res), nil
+}
+
+func (repo *FileRepositoryImpl) CreateAttachments(ctx context.Context, as []file_domain.Attachment) ([]file_domain.Attachment, error) {
+	entities := make([]file_dao.Attachment, 0, len(as))
+	for _, a := range as {
+		entities = append(entities, attachmentDomainToEntity(a))
+	}
+	entities, err := repo.dao.InsertAttachments(ctx, entities)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.Attachment, 0, len(entities))
+	for _, a := range entities {
+		res = append(res, attachmentEntityToDomain(a))
+	}
+	return res, nil
+}
+
+func (repo *FileRepositoryImpl) FindAttachmentsByIDs(ctx context.Context, ids []int64) ([]file_domain.Attachment, error) {
+	as, err := repo.dao.FindAttachmentsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.Attachment, 0, len(as))
+	for _, a := range as {
+		res = append(res, attachmentEntityToDomain(a))
+	}
+	return res, nil
+}
+
+func (repo *FileRepositoryImpl) ClaimAttachments(ctx context.Context, uid int64, convType int8, ids []int64) error {
+	return repo.dao.ClaimAttachments(ctx, uid, convType, ids)
+}
+
+func (repo *FileRepositoryImpl) BindAttachments(ctx context.Context, ids []int64, msgID int64) error {
+	return repo.dao.BindAttachments(ctx, ids, msgID)
+}
+
+func (repo *FileRepositoryImpl) ReleaseAttachments(ctx context.Context, ids []int64) error {
+	return repo.dao.ReleaseAttachments(ctx, ids)
+}
+
+func (repo *FileRepositoryImpl) FindMsgAttachments(ctx context.Context, convType int8, msgIDs []int64) ([]file_domain.Attachment, error) {
+	as, err := repo.dao.FindMsgAttachments(ctx, convType, msgIDs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.Attachment, 0, len(as))
+	for _, a := range as {
+		res = append(res, attachmentEntityToDomain(a))
+	}
+	return res, nil
+}
+
+func attachmentDomainToEntity(a file_domain.Attachment) file_dao.Attachment {
+	return file_dao.Attachment{
+		ID:       a.ID,
+		UserID:   a.UserID,
+		Kind:     a.Kind,
+		Title:    a.Title,
+		Size:     a.Size,
+		MimeType: a.MimeType,
+		Type:     a.Type,
+		Duration: a.Duration,
+		Width:    a.Width,
+		Height:   a.Height,
+		HasThumb: a.HasThumb,
+		FileID:   a.FileID,
+		ConvType: a.ConvType,
+		MsgID:    a.MsgID,
+		Blocked:  a.Blocked,
+	}
+}
+
+func attachmentEntityToDomain(a file_dao.Attachment) file_domain.Attachment {
+	res := file_domain.Attachment{
+		ID:         a.ID,
+		CreateTime: time.UnixMilli(a.CreateTime),
+		UserID:     a.UserID,
+		Kind:       a.Kind,
+		Title:      a.Title,
+		Size:       a.Size,
+		MimeType:   a.MimeType,
+		Type:       a.Type,
+		Duration:   a.Duration,
+		Width:      a.Width,
+		Height:     a.Height,
+		HasThumb:   a.HasThumb,
+		FileID:     a.FileID,
+		ConvType:   a.ConvType,
+		MsgID:      a.MsgID,
+		Blocked:    a.Blocked,
+	}
+	if a.BindTime > 0 {
+		res.BindTime = time.UnixMilli(a.BindTime)
+	}
+	return res
+}
@@ -3,6 +3,7 @@ package file_repo
 import (
 	"database/sql"
 	"github.com/goccy/go-json"
+	"github.com/ink-yht/im/internal/domain/file_domain"
 	"github.com/ink-yht/im/internal/domain/user_domain"
 	"github.com/ink-yht/im/internal/repository/dao/file_dao"
 	"github.com/ink-yht/im/internal/repository/dao/user_dao"
@@ -10,8 +11,47 @@ import (
 	"time"
 )
 
+var (
+	ErrRecordNotFound = file_dao.ErrRecordNotFound
+	ErrAttachmentUsed = file_dao.ErrAttachmentUsed
+)
+
 type FileRepository interface {
 	Avatar(ctx context.Context, user user_domain.User) error
+	GroupAvatar(ctx context.Context, groupID int64, avatar string) error
+
+	CreateFile(ctx context.Context, f file_domain.File) (file_domain.File, error)
+	FindFileByID(ctx context.Context, id int64) (file_domain.File, error)
+
+	CreateAttachment(ctx context.Context, a file_domain.Attachment) (file_domain.Attachment, error)
+	CreateAttachments(ctx context.Context, as []file_domain.Attachment) ([]file_domain.Attachment, error)
+	FindAttachmentsByIDs(ctx context.Context, ids []int64) ([]file_domain.Attachment, error)
+	ClaimAttachments(ctx context.Context, uid int64, convType int8, ids []int64) error
+	BindAttachments(ctx context.Context, ids []int64, msgID int64) error
+	ReleaseAttachments(ctx context.Context, ids []int64) error
+	FindMsgAttachments(ctx context.Context, convType int8, msgIDs []int64) ([]file_domain.Attachment, error)
+
+	CreateUploadSession(ctx context.Context, s file_domain.UploadSession) error
+	FindUploadSession(ctx context.Context, uploadID string) (file_domain.UploadSession, error)
+	AppendUpload(ctx context.Context, uploadID string, offset, n int64, expireTime time.Time, write func() error) (bool, error)
+	ClaimUploadSession(ctx context.Context, uploadID string, expireTime time.Time) (bool, error)
+	ReleaseUploadSession(ctx context.Context, uploadID string) error
+	DeleteUploadSession(ctx context.Context, uploadID string) (bool, error)
+	FindExpiredUploadSessions(ctx context.Context, limit int) ([]file_domain.UploadSession, error)
+
+	FileUsage(ctx context.Context, uid int64) (file_domain.Usage, error)
+	ReleaseAvatars(ctx context.Context, uid, keepID int64) error
+	ReleaseGroupAvatars(ctx context.Context, groupID, keepID int64) error
+	FindExpiredAttachments(ctx context.Context, before time.Time, limit int) ([]file_domain.Attachment, error)
+	DeleteAttachments(ctx context.Context, as []file_domain.Attachment) error
+	FindUnreferencedFiles(ctx context.Context, before time.Time, afterID int64, limit int) ([]file_domain.File, error)
+	DeleteFile(ctx context.Context, f file_domain.File, purge func() error) (bool, error)
+	ReleaseFile(ctx context.Context, id int64) error
+
+	FindFileByHash(ctx context.Context, hash string) (file_domain.File, error)
+	FindPendingScanFiles(ctx context.Context, afterID int64, limit int) ([]file_domain.File, error)
+	MarkFilesClean(ctx context.Context, hash string) error
+	QuarantineFiles(ctx context.Context, hash, reason string) ([]file_domain.Attachment, error)
 }
 
 type FileRepositoryImpl struct {
@@ -28,6 +68,10 @@ func (repo *FileRepositoryImpl) Avatar(ctx context.Context, user user_domain.Use
 	return repo.dao.Avatar(ctx, repo.domainToEntity(user))
 }
 
+func (repo *FileRepositoryImpl) GroupAvatar(ctx context.Context, groupID int64, avatar string) error {
+	return repo.dao.GroupAvatar(ctx, groupID, avatar)
+}
+
 func (repo *FileRepositoryImpl) domainToEntity(u user_domain.User) user_dao.User {
 	var verificationQuestionJSON []byte
 	if u.UserConf.VerificationQuestion != nil {
@@ -0,0 +1,67 @@
+package file_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"time"
+)
+
+func (repo *FileRepositoryImpl) FileUsage(ctx context.Context, uid int64) (file_domain.Usage, error) {
+	size, count, err := repo.dao.FileUsage(ctx, uid)
+	if err != nil {
+		return file_domain.Usage{}, err
+	}
+	return file_domain.Usage{
+		Used:  size,
+		Files: count,
+	}, nil
+}
+
+func (repo *FileRepositoryImpl) ReleaseAvatars(ctx context.Context, uid, keepID int64) error {
+	return repo.dao.ReleaseAvatars(ctx, uid, keepID)
+}
+
+func (repo *FileRepositoryImpl) ReleaseGroupAvatars(ctx context.Context, groupID, keepID int64) error {
+	return repo.dao.ReleaseGroupAvatars(ctx, groupID, keepID)
+}
+
+func (repo *FileRepositoryImpl) FindExpiredAttachments(ctx context.Context, before time.Time, limit int) ([]file_domain.Attachment, error) {
+	as, err := repo.dao.FindExpiredAttachments(ctx, before.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.Attachment, 0, len(as))
+	for _, a := range as {
+		res = append(res, attachmentEntityToDomain(a))
+	}
+	return res, nil
+}
+
+func (repo *FileRepositoryImpl) DeleteAttachments(ctx context.Context, as []file_domain.Attachment) error {
+	entities := make([]file_dao.Attachment, 0, len(as))
+	for _, a := range as {
+		entities = append(entities, attachmentDomainToEntity(a))
+	}
+	return repo.dao.DeleteAttachments(ctx, entities)
+}
+
+func (repo *FileRepositoryImpl) FindUnreferencedFiles(ctx context.Context, before time.Time, afterID int64, limit int) ([]file_domain.File, error) {
+	fs, err := repo.dao.FindUnreferencedFiles(ctx, before.UnixMilli(), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.File, 0, len(fs))
+	for _, f := range fs {
+		res = append(res, fileEntityToDomain(f))
+	}
+	return res, nil
+}
+
+func (repo *FileRepositoryImpl) DeleteFile(ctx context.Context, f file_domain.File, purge func() error) (bool, error) {
+	return repo.dao.DeleteFile(ctx, fileDomainToEntity(f), purge)
+}
+
+func (repo *FileRepositoryImpl) ReleaseFile(ctx context.Context, id int64) error {
+	return repo.dao.ReleaseFile(ctx, id)
+}
@@ -0,0 +1,67 @@
+package file_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"time"
+)
+
+func (repo *FileRepositoryImpl) CreateFile(ctx context.Context, f file_domain.File) (file_domain.File, error) {
+	res, err := repo.dao.InsertFile(ctx, fileDomainToEntity(f))
+	if err != nil {
+		return file_domain.File{}, err
+	}
+	return fileEntityToDomain(res), nil
+}
+
+func (repo *FileRepositoryImpl) FindFileByID(ctx context.Context, id int64) (file_domain.File, error) {
+	f, err := repo.dao.FindFileByID(ctx, id)
+	if err != nil {
+		return file_domain.File{}, err
+	}
+	return fileEntityToDomain(f), nil
+}
+
+func fileDomainToEntity(f file_domain.File) file_dao.File {
+	return file_dao.File{
+		ID:         f.ID,
+		UserID:     f.UserID,
+		Kind:       f.Kind,
+		Name:       f.Name,
+		Hash:       f.Hash,
+		Size:       f.Size,
+		MimeType:   f.MimeType,
+		Path:       f.Path,
+		Width:      f.Width,
+		Height:     f.Height,
+		Duration:   f.Duration,
+		Thumb:      f.Thumb,
+		Refs:       f.Refs,
+		GroupID:    f.GroupID,
+		ScanStatus: f.ScanStatus,
+		ScanReason: f.ScanReason,
+	}
+}
+
+func fileEntityToDomain(f file_dao.File) file_domain.File {
+	return file_domain.File{
+		ID:         f.ID,
+		CreateTime: time.UnixMilli(f.CreateTime),
+		UserID:     f.UserID,
+		Kind:       f.Kind,
+		Name:       f.Name,
+		Hash:       f.Hash,
+		Size:       f.Size,
+		MimeType:   f.MimeType,
+		Path:       f.Path,
+		Width:      f.Width,
+		Height:     f.Height,
+		Duration:   f.Duration,
+		Thumb:      f.Thumb,
+		Refs:       f.Refs,
+		GroupID:    f.GroupID,
+		ScanStatus: f.ScanStatus,
+		ScanReason: f.ScanReason,
+	}
+}
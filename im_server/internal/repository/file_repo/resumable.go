@@ -0,0 +1,71 @@
+package file_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"time"
+)
+
+func (repo *FileRepositoryImpl) CreateUploadSession(ctx context.Context, s file_domain.UploadSession) error {
+	return repo.dao.InsertUploadSession(ctx, file_dao.UploadSession{
+		UploadID:   s.UploadID,
+		UserID:     s.UserID,
+		Kind:       s.Kind,
+		Name:       s.Name,
+		Size:       s.Size,
+		Hash:       s.Hash,
+		Offset:     s.Offset,
+		ExpireTime: s.ExpireTime.UnixMilli(),
+	})
+}
+
+func (repo *FileRepositoryImpl) FindUploadSession(ctx context.Context, uploadID string) (file_domain.UploadSession, error) {
+	s, err := repo.dao.FindUploadSession(ctx, uploadID)
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	return uploadSessionEntityToDomain(s), nil
+}
+
+func (repo *FileRepositoryImpl) AppendUpload(ctx context.Context, uploadID string, offset, n int64, expireTime time.Time, write func() error) (bool, error) {
+	return repo.dao.AppendUpload(ctx, uploadID, offset, n, expireTime.UnixMilli(), write)
+}
+
+func (repo *FileRepositoryImpl) ClaimUploadSession(ctx context.Context, uploadID string, expireTime time.Time) (bool, error) {
+	return repo.dao.ClaimUploadSession(ctx, uploadID, expireTime.UnixMilli())
+}
+
+func (repo *FileRepositoryImpl) ReleaseUploadSession(ctx context.Context, uploadID string) error {
+	return repo.dao.ReleaseUploadSession(ctx, uploadID)
+}
+
+func (repo *FileRepositoryImpl) DeleteUploadSession(ctx context.Context, uploadID string) (bool, error) {
+	return repo.dao.DeleteUploadSession(ctx, uploadID)
+}
+
+func (repo *FileRepositoryImpl) FindExpiredUploadSessions(ctx context.Context, limit int) ([]file_domain.UploadSession, error) {
+	ss, err := repo.dao.FindExpiredUploadSessions(ctx, time.Now().UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.UploadSession, 0, len(ss))
+	for _, s := range ss {
+		res = append(res, uploadSessionEntityToDomain(s))
+	}
+	return res, nil
+}
+
+func uploadSessionEntityToDomain(s file_dao.UploadSession) file_domain.UploadSession {
+	return file_domain.UploadSession{
+		UploadID:   s.UploadID,
+		UserID:     s.UserID,
+		Kind:       s.Kind,
+		Name:       s.Name,
+		Size:       s.Size,
+		Hash:       s.Hash,
+		Offset:     s.Offset,
+		Completing: s.Status == 1,
+		ExpireTime: time.UnixMilli(s.ExpireTime),
+	}
+}
@@ -0,0 +1,42 @@
+package file_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+)
+
+func (repo *FileRepositoryImpl) FindFileByHash(ctx context.Context, hash string) (file_domain.File, error) {
+	f, err := repo.dao.FindFileByHash(ctx, hash)
+	if err != nil {
+		return file_domain.File{}, err
+	}
+	return fileEntityToDomain(f), nil
+}
+
+func (repo *FileRepositoryImpl) FindPendingScanFiles(ctx context.Context, afterID int64, limit int) ([]file_domain.File, error) {
+	fs, err := repo.dao.FindPendingScanFiles(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.File, 0, len(fs))
+	for _, f := range fs {
+		res = append(res, fileEntityToDomain(f))
+	}
+	return res, nil
+}
+
+func (repo *FileRepositoryImpl) MarkFilesClean(ctx context.Context, hash string) error {
+	return repo.dao.MarkFilesClean(ctx, hash)
+}
+
+func (repo *FileRepositoryImpl) QuarantineFiles(ctx context.Context, hash, reason string) ([]file_domain.Attachment, error) {
+	as, err := repo.dao.QuarantineFiles(ctx, hash, reason)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]file_domain.Attachment, 0, len(as))
+	for _, a := range as {
+		res = append(res, attachmentEntityToDomain(a))
+	}
+	return res, nil
+}
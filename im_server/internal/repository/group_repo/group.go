@@ -0,0 +1,437 @@
+package group_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/repository/dao/group_dao"
+	"time"
+)
+
+var (
+	ErrRecordNotFound = group_dao.ErrRecordNotFound
+	ErrMemberExists   = group_dao.ErrMemberExists
+	ErrVerifyHandled  = group_dao.ErrVerifyHandled
+	ErrGroupFull      = group_dao.ErrGroupFull
+	ErrLinkUnusable   = group_dao.ErrLinkUnusable
+)
+
+type GroupRepository interface {
+	FindByID(ctx context.Context, id int64) (group_domain.Group, error)
+	FindByIDs(ctx context.Context, ids []int64) ([]group_domain.Group, error)
+	FindMember(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error)
+	FindMembers(ctx context.Context, groupID int64) ([]group_domain.GroupMember, error)
+	CountMembers(ctx context.Context, groupID int64) (int64, error)
+	FindGroupIDsByUser(ctx context.Context, uid int64) ([]int64, error)
+	SearchGroups(ctx context.Context, keyword string, groupID, lastID int64, limit int) ([]group_domain.Group, error)
+	CountMembersByGroups(ctx context.Context, groupIDs []int64) (map[int64]int64, error)
+	FindMembersByUsers(ctx context.Context, groupIDs, uids []int64) ([]group_domain.GroupMember, error)
+	FindMemberPage(ctx context.Context, groupID int64, keyword string, offset, limit int) ([]group_domain.MemberItem, int64, error)
+	UpdateMemberNickname(ctx context.Context, groupID, uid int64, nickname string) error
+
+	CreateNotice(ctx context.Context, n group_domain.Notice) (group_domain.Notice, error)
+	UpdateNotice(ctx context.Context, n group_domain.Notice, resetConfirm bool) error
+	UpdateNoticePinned(ctx context.Context, id int64, pinned bool) error
+	DeleteNotice(ctx context.Context, id int64) error
+	FindNoticeByID(ctx context.Context, id int64) (group_domain.Notice, error)
+	FindNotices(ctx context.Context, groupID int64) ([]group_domain.Notice, error)
+	ConfirmNotice(ctx context.Context, noticeID, uid int64) error
+	FindNoticeConfirms(ctx context.Context, noticeID int64) ([]group_domain.NoticeConfirmRecord, error)
+	FindConfirmedNoticeIDs(ctx context.Context, uid int64, noticeIDs []int64) ([]int64, error)
+
+	AddMember(ctx context.Context, groupID, uid int64, role int, linkID int64) error
+	CreateVerify(ctx context.Context, v group_domain.Verify) (group_domain.Verify, error)
+	FindVerifyByID(ctx context.Context, id int64) (group_domain.Verify, error)
+	FindPendingVerify(ctx context.Context, groupID, uid int64) (group_domain.Verify, error)
+	FindPendingVerifies(ctx context.Context, groupID int64) ([]group_domain.Verify, error)
+	FindPendingVerifyGroupIDs(ctx context.Context, uid int64, groupIDs []int64) ([]int64, error)
+	UpdateVerifyStatus(ctx context.Context, id int64, status int8) error
+	CreateInviteLink(ctx context.Context, link group_domain.InviteLink) (group_domain.InviteLink, error)
+	FindInviteLinkByID(ctx context.Context, id int64) (group_domain.InviteLink, error)
+	FindInviteLinkByToken(ctx context.Context, token string) (group_domain.InviteLink, error)
+	FindInviteLinks(ctx context.Context, groupID int64) ([]group_domain.InviteLink, error)
+	RevokeInviteLink(ctx context.Context, id int64) error
+}
+
+type GroupRepositoryImpl struct {
+	dao group_dao.GroupDao
+}
+
+func NewGroupRepository(dao group_dao.GroupDao) GroupRepository {
+	return &GroupRepositoryImpl{
+		dao: dao,
+	}
+}
+
+func (repo *GroupRepositoryImpl) FindByID(ctx context.Context, id int64) (group_domain.Group, error) {
+	g, err := repo.dao.FindByID(ctx, id)
+	if err != nil {
+		return group_domain.Group{}, err
+	}
+	return repo.entityToDomain(g), nil
+}
+
+func (repo *GroupRepositoryImpl) FindByIDs(ctx context.Context, ids []int64) ([]group_domain.Group, error) {
+	groups, err := repo.dao.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.Group, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, repo.entityToDomain(g))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) FindMember(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error) {
+	m, err := repo.dao.FindMember(ctx, groupID, uid)
+	if err != nil {
+		return group_domain.GroupMember{}, err
+	}
+	return repo.memberEntityToDomain(m), nil
+}
+
+func (repo *GroupRepositoryImpl) FindMembers(ctx context.Context, groupID int64) ([]group_domain.GroupMember, error) {
+	members, err := repo.dao.FindMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.GroupMember, 0, len(members))
+	for _, m := range members {
+		res = append(res, repo.memberEntityToDomain(m))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) CountMembers(ctx context.Context, groupID int64) (int64, error) {
+	return repo.dao.CountMembers(ctx, groupID)
+}
+
+func (repo *GroupRepositoryImpl) FindGroupIDsByUser(ctx context.Context, uid int64) ([]int64, error) {
+	return repo.dao.FindGroupIDsByUser(ctx, uid)
+}
+
+func (repo *GroupRepositoryImpl) SearchGroups(ctx context.Context, keyword string, groupID, lastID int64, limit int) ([]group_domain.Group, error) {
+	groups, err := repo.dao.SearchGroups(ctx, keyword, groupID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.Group, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, repo.entityToDomain(g))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) CountMembersByGroups(ctx context.Context, groupIDs []int64) (map[int64]int64, error) {
+	return repo.dao.CountMembersByGroups(ctx, groupIDs)
+}
+
+func (repo *GroupRepositoryImpl) FindMembersByUsers(ctx context.Context, groupIDs, uids []int64) ([]group_domain.GroupMember, error) {
+	members, err := repo.dao.FindMembersByUsers(ctx, groupIDs, uids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.GroupMember, 0, len(members))
+	for _, m := range members {
+		res = append(res, repo.memberEntityToDomain(m))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) FindMemberPage(ctx context.Context, groupID int64, keyword string, offset, limit int) ([]group_domain.MemberItem, int64, error) {
+	members, total, err := repo.dao.FindMemberPage(ctx, groupID, keyword, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	res := make([]group_domain.MemberItem, 0, len(members))
+	for _, m := range members {
+		member := group_domain.GroupMember{
+			ID:              m.ID,
+			CreateTime:      time.UnixMilli(m.CreateTime),
+			UpdateTime:      time.UnixMilli(m.UpdateTime),
+			MemberNickname:  m.MemberNickname,
+			Role:            m.Role,
+			ProhibitionTime: m.ProhibitionTime,
+			GroupID:         m.GroupID,
+			UserID:          m.UserID,
+		}
+		res = append(res, group_domain.MemberItem{
+			UserID:         m.UserID,
+			Nickname:       m.Nickname,
+			MemberNickname: m.MemberNickname,
+			DisplayName:    member.DisplayName(m.Nickname),
+			Avatar:         m.Avatar,
+			Role:           m.Role,
+			IsProhibited:   member.IsProhibited(),
+			ProhibitionEnd: member.ProhibitionEnd(),
+			JoinTime:       member.CreateTime,
+		})
+	}
+	return res, total, nil
+}
+
+func (repo *GroupRepositoryImpl) UpdateMemberNickname(ctx context.Context, groupID, uid int64, nickname string) error {
+	return repo.dao.UpdateMemberNickname(ctx, groupID, uid, nickname)
+}
+
+func (repo *GroupRepositoryImpl) CreateNotice(ctx context.Context, n group_domain.Notice) (group_domain.Notice, error) {
+	entity, err := repo.dao.InsertNotice(ctx, repo.noticeDomainToEntity(n))
+	if err != nil {
+		return group_domain.Notice{}, err
+	}
+	return repo.noticeEntityToDomain(entity), nil
+}
+
+func (repo *GroupRepositoryImpl) UpdateNotice(ctx context.Context, n group_domain.Notice, resetConfirm bool) error {
+	return repo.dao.UpdateNotice(ctx, repo.noticeDomainToEntity(n), resetConfirm)
+}
+
+func (repo *GroupRepositoryImpl) UpdateNoticePinned(ctx context.Context, id int64, pinned bool) error {
+	return repo.dao.UpdateNoticePinned(ctx, id, pinned)
+}
+
+func (repo *GroupRepositoryImpl) DeleteNotice(ctx context.Context, id int64) error {
+	return repo.dao.DeleteNotice(ctx, id)
+}
+
+func (repo *GroupRepositoryImpl) FindNoticeByID(ctx context.Context, id int64) (group_domain.Notice, error) {
+	n, err := repo.dao.FindNoticeByID(ctx, id)
+	if err != nil {
+		return group_domain.Notice{}, err
+	}
+	return repo.noticeEntityToDomain(n), nil
+}
+
+func (repo *GroupRepositoryImpl) FindNotices(ctx context.Context, groupID int64) ([]group_domain.Notice, error) {
+	notices, err := repo.dao.FindNotices(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.Notice, 0, len(notices))
+	for _, n := range notices {
+		res = append(res, repo.noticeEntityToDomain(n))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) ConfirmNotice(ctx context.Context, noticeID, uid int64) error {
+	return repo.dao.InsertNoticeConfirm(ctx, noticeID, uid)
+}
+
+func (repo *GroupRepositoryImpl) FindNoticeConfirms(ctx context.Context, noticeID int64) ([]group_domain.NoticeConfirmRecord, error) {
+	confirms, err := repo.dao.FindNoticeConfirms(ctx, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.NoticeConfirmRecord, 0, len(confirms))
+	for _, c := range confirms {
+		res = append(res, group_domain.NoticeConfirmRecord{
+			UserID:      c.UserID,
+			ConfirmTime: time.UnixMilli(c.CreateTime),
+		})
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) FindConfirmedNoticeIDs(ctx context.Context, uid int64, noticeIDs []int64) ([]int64, error) {
+	return repo.dao.FindConfirmedNoticeIDs(ctx, uid, noticeIDs)
+}
+
+func (repo *GroupRepositoryImpl) AddMember(ctx context.Context, groupID, uid int64, role int, linkID int64) error {
+	return repo.dao.InsertMember(ctx, group_dao.GroupMember{
+		GroupID: groupID,
+		UserID:  uid,
+		Role:    role,
+	}, linkID)
+}
+
+func (repo *GroupRepositoryImpl) CreateVerify(ctx context.Context, v group_domain.Verify) (group_domain.Verify, error) {
+	entity, err := repo.dao.InsertVerify(ctx, group_dao.GroupVerify{
+		Status:             v.Status,
+		AdditionalMessages: v.AdditionalMessages,
+		Type:               v.Type,
+		InviterID:          v.InviterID,
+		InviteLinkID:       v.InviteLinkID,
+		GroupID:            v.GroupID,
+		UserID:             v.UserID,
+	})
+	if err != nil {
+		return group_domain.Verify{}, err
+	}
+	return repo.verifyEntityToDomain(entity), nil
+}
+
+func (repo *GroupRepositoryImpl) FindVerifyByID(ctx context.Context, id int64) (group_domain.Verify, error) {
+	v, err := repo.dao.FindVerifyByID(ctx, id)
+	if err != nil {
+		return group_domain.Verify{}, err
+	}
+	return repo.verifyEntityToDomain(v), nil
+}
+
+func (repo *GroupRepositoryImpl) FindPendingVerify(ctx context.Context, groupID, uid int64) (group_domain.Verify, error) {
+	v, err := repo.dao.FindPendingVerify(ctx, groupID, uid)
+	if err != nil {
+		return group_domain.Verify{}, err
+	}
+	return repo.verifyEntityToDomain(v), nil
+}
+
+func (repo *GroupRepositoryImpl) FindPendingVerifies(ctx context.Context, groupID int64) ([]group_domain.Verify, error) {
+	vs, err := repo.dao.FindPendingVerifies(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.Verify, 0, len(vs))
+	for _, v := range vs {
+		res = append(res, repo.verifyEntityToDomain(v))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) FindPendingVerifyGroupIDs(ctx context.Context, uid int64, groupIDs []int64) ([]int64, error) {
+	return repo.dao.FindPendingVerifyGroupIDs(ctx, uid, groupIDs)
+}
+
+func (repo *GroupRepositoryImpl) UpdateVerifyStatus(ctx context.Context, id int64, status int8) error {
+	return repo.dao.UpdateVerifyStatus(ctx, id, status)
+}
+
+func (repo *GroupRepositoryImpl) CreateInviteLink(ctx context.Context, link group_domain.InviteLink) (group_domain.InviteLink, error) {
+	entity, err := repo.dao.InsertInviteLink(ctx, group_dao.GroupInviteLink{
+		Token:      link.Token,
+		CreatorID:  link.CreatorID,
+		ExpireTime: link.ExpireTime.UnixMilli(),
+		MaxUses:    link.MaxUses,
+		GroupID:    link.GroupID,
+	})
+	if err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	return repo.inviteLinkEntityToDomain(entity), nil
+}
+
+func (repo *GroupRepositoryImpl) FindInviteLinkByID(ctx context.Context, id int64) (group_domain.InviteLink, error) {
+	link, err := repo.dao.FindInviteLinkByID(ctx, id)
+	if err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	return repo.inviteLinkEntityToDomain(link), nil
+}
+
+func (repo *GroupRepositoryImpl) FindInviteLinkByToken(ctx context.Context, token string) (group_domain.InviteLink, error) {
+	link, err := repo.dao.FindInviteLinkByToken(ctx, token)
+	if err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	return repo.inviteLinkEntityToDomain(link), nil
+}
+
+func (repo *GroupRepositoryImpl) FindInviteLinks(ctx context.Context, groupID int64) ([]group_domain.InviteLink, error) {
+	links, err := repo.dao.FindInviteLinks(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.InviteLink, 0, len(links))
+	for _, l := range links {
+		res = append(res, repo.inviteLinkEntityToDomain(l))
+	}
+	return res, nil
+}
+
+func (repo *GroupRepositoryImpl) RevokeInviteLink(ctx context.Context, id int64) error {
+	return repo.dao.RevokeInviteLink(ctx, id)
+}
+
+func (repo *GroupRepositoryImpl) entityToDomain(g group_dao.Group) group_domain.Group {
+	return group_domain.Group{
+		ID:                   g.ID,
+		CreateTime:           time.UnixMilli(g.CreateTime),
+		UpdateTime:           time.UnixMilli(g.UpdateTime),
+		Title:                g.Title,
+		Abstract:             g.Abstract,
+		Avatar:               g.Avatar,
+		IsSearch:             g.IsSearch,
+		Verification:         g.Verification,
+		VerificationQuestion: g.VerificationQuestion,
+		IsInvite:             g.IsInvite,
+		IsTemporarySession:   g.IsTemporarySession,
+		IsProhibition:        g.IsProhibition,
+		Size:                 g.Size,
+		Creator:              g.Creator,
+	}
+}
+
+func (repo *GroupRepositoryImpl) memberEntityToDomain(m group_dao.GroupMember) group_domain.GroupMember {
+	return group_domain.GroupMember{
+		ID:              m.ID,
+		CreateTime:      time.UnixMilli(m.CreateTime),
+		UpdateTime:      time.UnixMilli(m.UpdateTime),
+		MemberNickname:  m.MemberNickname,
+		Role:            m.Role,
+		ProhibitionTime: m.ProhibitionTime,
+		GroupID:         m.GroupID,
+		UserID:          m.UserID,
+	}
+}
+
+func (repo *GroupRepositoryImpl) noticeDomainToEntity(n group_domain.Notice) group_dao.GroupNotice {
+	return group_dao.GroupNotice{
+		ID:          n.ID,
+		Title:       n.Title,
+		Content:     n.Content,
+		NeedConfirm: n.NeedConfirm,
+		IsPinned:    n.IsPinned,
+		UserID:      n.UserID,
+		EditUserID:  n.EditUserID,
+		GroupID:     n.GroupID,
+	}
+}
+
+func (repo *GroupRepositoryImpl) noticeEntityToDomain(n group_dao.GroupNotice) group_domain.Notice {
+	res := group_domain.Notice{
+		ID:          n.ID,
+		CreateTime:  time.UnixMilli(n.CreateTime),
+		UpdateTime:  time.UnixMilli(n.UpdateTime),
+		GroupID:     n.GroupID,
+		Title:       n.Title,
+		Content:     n.Content,
+		NeedConfirm: n.NeedConfirm,
+		IsPinned:    n.IsPinned,
+		UserID:      n.UserID,
+		EditUserID:  n.EditUserID,
+	}
+	if n.PinTime > 0 {
+		res.PinTime = time.UnixMilli(n.PinTime)
+	}
+	return res
+}
+
+func (repo *GroupRepositoryImpl) verifyEntityToDomain(v group_dao.GroupVerify) group_domain.Verify {
+	return group_domain.Verify{
+		ID:                 v.ID,
+		CreateTime:         time.UnixMilli(v.CreateTime),
+		UpdateTime:         time.UnixMilli(v.UpdateTime),
+		GroupID:            v.GroupID,
+		UserID:             v.UserID,
+		InviterID:          v.InviterID,
+		InviteLinkID:       v.InviteLinkID,
+		Type:               v.Type,
+		Status:             v.Status,
+		AdditionalMessages: v.AdditionalMessages,
+	}
+}
+
+func (repo *GroupRepositoryImpl) inviteLinkEntityToDomain(l group_dao.GroupInviteLink) group_domain.InviteLink {
+	return group_domain.InviteLink{
+		ID:         l.ID,
+		CreateTime: time.UnixMilli(l.CreateTime),
+		GroupID:    l.GroupID,
+		Token:      l.Token,
+		CreatorID:  l.CreatorID,
+		ExpireTime: time.UnixMilli(l.ExpireTime),
+		MaxUses:    l.MaxUses,
+		Uses:       l.Uses,
+		Revoked:    l.Revoked,
+	}
+}
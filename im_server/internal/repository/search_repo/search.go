@@ -0,0 +1,91 @@
+package search_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/search_domain"
+	"github.com/ink-yht/im/internal/repository/dao/search_dao"
+	"time"
+)
+
+type SearchRepository interface {
+	Index(ctx context.Context, docs []search_domain.MsgDoc) error
+	Remove(ctx context.Context, convType int8, msgIDs []int64) error
+	RemoveByOwner(ctx context.Context, ownerID int64, convType int8, convID int64, msgIDs []int64) error
+	UpdateText(ctx context.Context, convType int8, msgID int64, text string) error
+	Search(ctx context.Context, req search_domain.SearchRequest, groupIDs []int64) ([]search_domain.MsgDoc, error)
+}
+
+type SearchRepositoryImpl struct {
+	dao search_dao.SearchDao
+}
+
+func NewSearchRepository(dao search_dao.SearchDao) SearchRepository {
+	return &SearchRepositoryImpl{
+		dao: dao,
+	}
+}
+
+func (repo *SearchRepositoryImpl) Index(ctx context.Context, docs []search_domain.MsgDoc) error {
+	now := time.Now().UnixMilli()
+	entities := make([]search_dao.MsgIndex, 0, len(docs))
+	for _, d := range docs {
+		entities = append(entities, search_dao.MsgIndex{
+			CreateTime: now,
+			ConvType:   d.ConvType,
+			ConvID:     d.ConvID,
+			OwnerID:    d.OwnerID,
+			MsgID:      d.MsgID,
+			SendUserID: d.SendUserID,
+			MsgType:    d.MsgType,
+			MsgTime:    d.MsgTime.UnixMilli(),
+			Text:       d.Text,
+		})
+	}
+	return repo.dao.Insert(ctx, entities)
+}
+
+func (repo *SearchRepositoryImpl) Remove(ctx context.Context, convType int8, msgIDs []int64) error {
+	return repo.dao.Delete(ctx, convType, msgIDs)
+}
+
+func (repo *SearchRepositoryImpl) RemoveByOwner(ctx context.Context, ownerID int64, convType int8, convID int64, msgIDs []int64) error {
+	return repo.dao.DeleteByOwner(ctx, ownerID, convType, convID, msgIDs)
+}
+
+func (repo *SearchRepositoryImpl) UpdateText(ctx context.Context, convType int8, msgID int64, text string) error {
+	return repo.dao.UpdateText(ctx, convType, msgID, text)
+}
+
+func (repo *SearchRepositoryImpl) Search(ctx context.Context, req search_domain.SearchRequest, groupIDs []int64) ([]search_domain.MsgDoc, error) {
+	docs, err := repo.dao.Search(ctx, search_dao.Query{
+		Keyword:    req.Keyword,
+		OwnerID:    req.UserID,
+		GroupIDs:   groupIDs,
+		ConvType:   req.ConvType,
+		ConvID:     req.ConvID,
+		SendUserID: req.SendUserID,
+		MsgType:    req.MsgType,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		LastID:     req.LastID,
+		Limit:      req.PageLimit(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]search_domain.MsgDoc, 0, len(docs))
+	for _, d := range docs {
+		res = append(res, search_domain.MsgDoc{
+			ID:         d.ID,
+			ConvType:   d.ConvType,
+			ConvID:     d.ConvID,
+			OwnerID:    d.OwnerID,
+			MsgID:      d.MsgID,
+			SendUserID: d.SendUserID,
+			MsgType:    d.MsgType,
+			MsgTime:    time.UnixMilli(d.MsgTime),
+			Text:       d.Text,
+		})
+	}
+	return res, nil
+}
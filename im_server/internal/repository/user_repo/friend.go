@@ -0,0 +1,24 @@
+package user_repo
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/repository/dao/user_dao"
+)
+
+type FriendRepository interface {
+	IsFriend(ctx context.Context, uid, friendID int64) (bool, error)
+}
+
+type FriendRepositoryImpl struct {
+	dao user_dao.FriendDao
+}
+
+func NewFriendRepository(dao user_dao.FriendDao) FriendRepository {
+	return &FriendRepositoryImpl{
+		dao: dao,
+	}
+}
+
+func (repo *FriendRepositoryImpl) IsFriend(ctx context.Context, uid, friendID int64) (bool, error) {
+	return repo.dao.IsFriend(ctx, uid, friendID)
+}
@@ -18,6 +18,7 @@ type UserRepository interface {
 	Create(ctx context.Context, user user_domain.User) error
 	FindByEmail(ctx context.Context, email string) (user user_domain.User, err error)
 	FindByID(ctx context.Context, id int64) (user_domain.User, error)
+	FindByIDs(ctx context.Context, ids []int64) ([]user_domain.User, error)
 	UpdateInfo(ctx context.Context, user user_domain.User) error
 }
 
@@ -43,6 +44,18 @@ func (repo *UserRepositoryImpl) FindByID(ctx context.Context, id int64) (user_do
 	return repo.entityToDomain(daoUser), nil
 }
 
+func (repo *UserRepositoryImpl) FindByIDs(ctx context.Context, ids []int64) ([]user_domain.User, error) {
+	users, err := repo.dao.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]user_domain.User, 0, len(users))
+	for _, u := range users {
+		res = append(res, repo.entityToDomain(u))
+	}
+	return res, nil
+}
+
 func (repo *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (user user_domain.User, err error) {
 	daoUser, err := repo.dao.FindByEmail(ctx, email)
 	if err != nil {
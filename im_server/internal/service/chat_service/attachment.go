@@ -0,0 +1,198 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("附件不存在")
+	ErrAttachmentUsed     = errors.New("附件已被其他消息使用，请使用转发")
+	ErrAttachmentKind     = errors.New("附件类型和消息类型不一致")
+	ErrAttachmentBlocked  = errors.New("附件没有通过安全检查，不能发送")
+)
+
+// msgAttachment 图片、视频、文件、语音消息引用的附件ID和需要的附件类型
+func msgAttachment(msg *chat_domain.Msg) (int64, string) {
+	switch {
+	case msg.Type == chat_domain.MsgTypeImage && msg.ImageMsg != nil:
+		return msg.ImageMsg.FileID, file_domain.KindImage
+	case msg.Type == chat_domain.MsgTypeVideo && msg.VideoMsg != nil:
+		return msg.VideoMsg.FileID, file_domain.KindVideo
+	case msg.Type == chat_domain.MsgTypeFile && msg.FileMsg != nil:
+		return msg.FileMsg.FileID, file_domain.KindFile
+	case msg.Type == chat_domain.MsgTypeVoice && msg.VoiceMsg != nil:
+		return msg.VoiceMsg.FileID, file_domain.KindVoice
+	}
+	return 0, ""
+}
+
+// fillAttachment 用服务端保存的附件信息覆盖客户端上报的内容
+// 地址不保存在消息中，返回给客户端时通过 signFiles 生成签名地址
+func fillAttachment(msg *chat_domain.Msg, a file_domain.Attachment) {
+	switch msg.Type {
+	case chat_domain.MsgTypeImage:
+		msg.ImageMsg.Title = a.Title
+		msg.ImageMsg.Src = ""
+		msg.ImageMsg.Thumb = ""
+		msg.ImageMsg.Width = a.Width
+		msg.ImageMsg.Height = a.Height
+	case chat_domain.MsgTypeVideo:
+		msg.VideoMsg.Title = a.Title
+		msg.VideoMsg.Src = ""
+		msg.VideoMsg.Time = a.Duration
+		msg.VideoMsg.Width = a.Width
+		msg.VideoMsg.Height = a.Height
+		msg.VideoMsg.Poster = ""
+		msg.VideoMsg.HasPoster = a.HasThumb
+	case chat_domain.MsgTypeFile:
+		msg.FileMsg.Title = a.Title
+		msg.FileMsg.Src = ""
+		msg.FileMsg.Size = a.Size
+		msg.FileMsg.Type = a.Type
+	case chat_domain.MsgTypeVoice:
+		msg.VoiceMsg.Src = ""
+		msg.VoiceMsg.Time = a.Duration
+	}
+}
+
+// claimAttachment 占用消息引用的附件，只能使用自己上传且还没有发送过的附件
+// 返回占用的附件ID，消息不带附件时返回 0
+func (svc *ChatServiceImpl) claimAttachment(ctx context.Context, uid int64, convType int8, msg *chat_domain.Msg) (int64, error) {
+	fileID, kind := msgAttachment(msg)
+	if fileID == 0 {
+		return 0, nil
+	}
+	as, err := svc.fileRepo.FindAttachmentsByIDs(ctx, []int64{fileID})
+	if err != nil {
+		return 0, err
+	}
+	if len(as) == 0 || as[0].UserID != uid {
+		return 0, ErrAttachmentNotFound
+	}
+	if as[0].Kind != kind {
+		return 0, ErrAttachmentKind
+	}
+	if as[0].Blocked {
+		return 0, ErrAttachmentBlocked
+	}
+	if as[0].Attached() {
+		return 0, ErrAttachmentUsed
+	}
+	err = svc.fileRepo.ClaimAttachments(ctx, uid, convType, []int64{fileID})
+	if errors.Is(err, file_repo.ErrAttachmentUsed) {
+		return 0, ErrAttachmentUsed
+	}
+	if err != nil {
+		return 0, err
+	}
+	fillAttachment(msg, as[0])
+	return fileID, nil
+}
+
+// settleAttachment 消息保存成功后把附件绑定到消息上，失败时释放附件，让用户可以重新发送
+func (svc *ChatServiceImpl) settleAttachment(ctx context.Context, fileID, msgID int64, createErr error) {
+	if fileID == 0 {
+		return
+	}
+	var err error
+	if createErr != nil {
+		err = svc.fileRepo.ReleaseAttachments(ctx, []int64{fileID})
+	} else {
+		err = svc.fileRepo.BindAttachments(ctx, []int64{fileID}, msgID)
+	}
+	if err != nil {
+		svc.l.Error("更新附件绑定的消息失败",
+			logger.Int64("fileID", fileID),
+			logger.Int64("msgID", msgID),
+			logger.Error("err", err))
+	}
+}
+
+// copyAttachments 转发时为目标会话中的新消息复制一份附件，替换消息中的附件ID
+// 每条消息使用自己的附件，分别计算文件的引用、保留期限和隔离状态；已经被清理的附件保持原样，和原消息一样不能再下载
+// 返回的附件已经占用，消息保存后调用 settleAttachments
+func (svc *ChatServiceImpl) copyAttachments(ctx context.Context, convType int8, msg chat_domain.Msg) (chat_domain.Msg, []file_domain.Attachment, error) {
+	ids := msg.FileIDs()
+	if len(ids) == 0 {
+		return msg, nil, nil
+	}
+	as, err := svc.fileRepo.FindAttachmentsByIDs(ctx, ids)
+	if err != nil || len(as) == 0 {
+		return msg, nil, err
+	}
+	copies := make([]file_domain.Attachment, 0, len(as))
+	for _, a := range as {
+		a.ID = 0
+		a.ConvType = convType
+		a.MsgID = 0
+		copies = append(copies, a)
+	}
+	copies, err = svc.fileRepo.CreateAttachments(ctx, copies)
+	if err != nil {
+		return chat_domain.Msg{}, nil, err
+	}
+	res, err := msg.Clone()
+	if err != nil {
+		return chat_domain.Msg{}, nil, err
+	}
+	newIDs := make(map[int64]int64, len(as))
+	for i, a := range as {
+		newIDs[a.ID] = copies[i].ID
+	}
+	res.ReplaceFileIDs(newIDs)
+	return res, copies, nil
+}
+
+// settleAttachments 转发的消息保存成功后把复制的附件绑定到消息上，失败时删除复制的附件
+func (svc *ChatServiceImpl) settleAttachments(ctx context.Context, as []file_domain.Attachment, msgID int64, createErr error) {
+	if len(as) == 0 {
+		return
+	}
+	var err error
+	if createErr != nil {
+		err = svc.fileRepo.DeleteAttachments(ctx, as)
+	} else {
+		ids := make([]int64, 0, len(as))
+		for _, a := range as {
+			ids = append(ids, a.ID)
+		}
+		err = svc.fileRepo.BindAttachments(ctx, ids, msgID)
+	}
+	if err != nil {
+		svc.l.Error("更新转发附件绑定的消息失败",
+			logger.Int64("msgID", msgID),
+			logger.Error("err", err))
+	}
+}
+
+// signFiles 为返回给客户端的消息生成附件的签名下载地址，ids 为 msgs 中每条消息的ID
+// 只为绑定到这条消息、以及它回复或引用的消息上的附件签名，消息内容中的其他附件ID不生成地址
+func (svc *ChatServiceImpl) signFiles(ctx context.Context, convType int8, ids []int64, msgs []*chat_domain.Msg) error {
+	msgIDs := append(append([]int64{}, ids...), refMsgIDs(msgs)...)
+	as, err := svc.fileRepo.FindMsgAttachments(ctx, convType, msgIDs)
+	if err != nil {
+		return err
+	}
+	bound := make(map[int64][]int64, len(msgIDs))
+	for _, a := range as {
+		bound[a.MsgID] = append(bound[a.MsgID], a.ID)
+	}
+	for i, msg := range msgs {
+		files := make(map[int64]bool)
+		for _, id := range bound[ids[i]] {
+			files[id] = true
+		}
+		if refID := msg.RefMsgID(); refID > 0 {
+			for _, id := range bound[refID] {
+				files[id] = true
+			}
+		}
+		msg.SignFiles(svc.signer, files)
+	}
+	return nil
+}
@@ -0,0 +1,615 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/domain/search_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/repository/search_repo"
+	"github.com/ink-yht/im/internal/repository/user_repo"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+var (
+	ErrNotFriend         = errors.New("对方不是你的好友")
+	ErrNotGroupMember    = errors.New("你不是该群成员")
+	ErrProhibition       = errors.New("你已被禁言")
+	ErrMsgNotFound       = errors.New("消息不存在")
+	ErrNoPermission      = errors.New("没有权限")
+	ErrConvType          = errors.New("会话类型错误")
+	ErrRefMsgNotFound    = errors.New("被引用的消息不存在")
+	ErrRefWithdrawn      = errors.New("被引用的消息已撤回")
+	ErrAtInChat          = errors.New("私聊中不能@成员")
+	ErrAtNotMember       = errors.New("被@的用户不是群成员")
+	ErrAtAllNoPermission = errors.New("只有群主和管理员可以@所有人")
+	ErrWithdrawn         = errors.New("消息已撤回")
+	ErrWithdrawExpire    = errors.New("消息发送超过 2 分钟，不能撤回")
+)
+
+// ChatService 定义了消息服务的接口
+type ChatService interface {
+	SendChat(ctx context.Context, req chat_domain.SendChatRequest) (chat_domain.Chat, error)
+	SendGroupMsg(ctx context.Context, req chat_domain.SendGroupMsgRequest) (chat_domain.GroupMsg, error)
+	ChatHistory(ctx context.Context, req chat_domain.HistoryRequest) ([]chat_domain.Chat, error)
+	GroupHistory(ctx context.Context, req chat_domain.HistoryRequest) ([]chat_domain.GroupMsg, error)
+	Read(ctx context.Context, req chat_domain.ReadRequest) error
+	GroupMsgReaders(ctx context.Context, uid, msgID int64) (chat_domain.GroupMsgReaders, error)
+	Sync(ctx context.Context, req chat_domain.SyncRequest) (chat_domain.SyncResult, error)
+	Ack(ctx context.Context, req chat_domain.AckRequest) error
+	Withdraw(ctx context.Context, req chat_domain.WithdrawRequest) error
+	Mentions(ctx context.Context, req chat_domain.MentionRequest) ([]chat_domain.Mention, error)
+	Delete(ctx context.Context, req chat_domain.DeleteRequest) error
+	Clear(ctx context.Context, req chat_domain.ClearRequest) error
+	PurgeDeleted(ctx context.Context) (int, error)
+	Search(ctx context.Context, req search_domain.SearchRequest) (search_domain.SearchResult, error)
+	Forward(ctx context.Context, req chat_domain.ForwardRequest) ([]chat_domain.ForwardedMsg, error)
+	Edit(ctx context.Context, req chat_domain.EditRequest) error
+	EditHistory(ctx context.Context, uid int64, convType int8, msgID int64) ([]chat_domain.MsgEdit, error)
+	AddReaction(ctx context.Context, req chat_domain.ReactionRequest) error
+	RemoveReaction(ctx context.Context, req chat_domain.ReactionRequest) error
+	PinMsg(ctx context.Context, req chat_domain.PinRequest) error
+	UnpinMsg(ctx context.Context, req chat_domain.PinRequest) error
+	PinnedMsgs(ctx context.Context, req chat_domain.PinnedListRequest) ([]chat_domain.PinnedMsg, error)
+	PinConversation(ctx context.Context, req chat_domain.PinConversationRequest) error
+	Conversations(ctx context.Context, uid int64) ([]chat_domain.ConversationItem, error)
+}
+
+// ChatServiceImpl 实现了 ChatService 接口
+type ChatServiceImpl struct {
+	repo       chat_repo.ChatRepository
+	groupRepo  group_repo.GroupRepository
+	friendRepo user_repo.FriendRepository
+	userRepo   user_repo.UserRepository
+	searchRepo search_repo.SearchRepository
+	fileRepo   file_repo.FileRepository
+	signer     file_service.URLSigner
+	push       push_service.PushService
+	l          logger.Logger
+}
+
+func NewChatService(repo chat_repo.ChatRepository, groupRepo group_repo.GroupRepository,
+	friendRepo user_repo.FriendRepository, userRepo user_repo.UserRepository,
+	searchRepo search_repo.SearchRepository, fileRepo file_repo.FileRepository,
+	signer file_service.URLSigner, push push_service.PushService, l logger.Logger) ChatService {
+	return &ChatServiceImpl{
+		repo:       repo,
+		groupRepo:  groupRepo,
+		friendRepo: friendRepo,
+		userRepo:   userRepo,
+		searchRepo: searchRepo,
+		fileRepo:   fileRepo,
+		signer:     signer,
+		push:       push,
+		l:          l,
+	}
+}
+
+func (svc *ChatServiceImpl) SendChat(ctx context.Context, req chat_domain.SendChatRequest) (chat_domain.Chat, error) {
+	if err := req.Msg.Validate(); err != nil {
+		return chat_domain.Chat{}, err
+	}
+	tempGroupID, err := svc.checkChatSend(ctx, req.SendUserID, req.RevUserID, req.GroupID)
+	if err != nil {
+		return chat_domain.Chat{}, err
+	}
+	if req.Msg.Type == chat_domain.MsgTypeAt {
+		return chat_domain.Chat{}, ErrAtInChat
+	}
+	if err = svc.resolveChatRef(ctx, &req.Msg, req.SendUserID, req.RevUserID); err != nil {
+		return chat_domain.Chat{}, err
+	}
+	fileID, err := svc.claimAttachment(ctx, req.SendUserID, chat_domain.ConvTypeChat, &req.Msg)
+	if err != nil {
+		return chat_domain.Chat{}, err
+	}
+	return svc.createChat(ctx, req.SendUserID, req.RevUserID, tempGroupID, req.Msg, func(msgID int64, err error) {
+		svc.settleAttachment(ctx, fileID, msgID, err)
+	})
+}
+
+func (svc *ChatServiceImpl) SendGroupMsg(ctx context.Context, req chat_domain.SendGroupMsgRequest) (chat_domain.GroupMsg, error) {
+	if err := req.Msg.Validate(); err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	member, err := svc.checkGroupSend(ctx, req.GroupID, req.SendUserID)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	if err = svc.resolveGroupRef(ctx, &req.Msg, req.GroupID); err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+
+	members, err := svc.groupRepo.FindMembers(ctx, req.GroupID)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	mentioned, err := resolveAt(&req.Msg, member, members)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	fileID, err := svc.claimAttachment(ctx, req.SendUserID, chat_domain.ConvTypeGroup, &req.Msg)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+	return svc.createGroupMsg(ctx, req.SendUserID, req.GroupID, req.Msg, members, mentioned, func(msgID int64, err error) {
+		svc.settleAttachment(ctx, fileID, msgID, err)
+	})
+}
+
+// checkChatSend 校验 uid 能否给 peerID 发送私聊消息
+// 不是好友时，可以通过 groupID 指定的群发起临时会话，返回临时会话的来源群ID
+func (svc *ChatServiceImpl) checkChatSend(ctx context.Context, uid, peerID, groupID int64) (int64, error) {
+	ok, err := svc.friendRepo.IsFriend(ctx, uid, peerID)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return 0, nil
+	}
+	if groupID == 0 {
+		return 0, ErrNotFriend
+	}
+	if err = svc.checkTempSession(ctx, uid, peerID, groupID); err != nil {
+		return 0, err
+	}
+	return groupID, nil
+}
+
+// checkGroupSend 校验 uid 能否在群里发言，返回发送者的成员信息
+func (svc *ChatServiceImpl) checkGroupSend(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error) {
+	member, err := svc.findMember(ctx, groupID, uid)
+	if err != nil {
+		return group_domain.GroupMember{}, err
+	}
+	group, err := svc.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		return group_domain.GroupMember{}, err
+	}
+	// 全员禁言只对普通成员生效
+	if (group.IsProhibition && !member.IsAdmin()) || member.IsProhibited() {
+		return group_domain.GroupMember{}, ErrProhibition
+	}
+	return member, nil
+}
+
+// createChat 保存已经校验过的私聊消息，建立索引并推送
+// settle 在消息保存之后、生成附件地址之前调用，绑定或者释放消息占用的附件，保存失败时 msgID 为 0
+func (svc *ChatServiceImpl) createChat(ctx context.Context, uid, peerID, tempGroupID int64, msg chat_domain.Msg,
+	settle func(msgID int64, err error)) (chat_domain.Chat, error) {
+	c, seqs, err := svc.repo.CreateChat(ctx, chat_domain.Chat{
+		MsgType:     msg.Type,
+		MsgPreview:  msg.Preview(),
+		Msg:         msg,
+		SendUserID:  uid,
+		RevUserID:   peerID,
+		TempGroupID: tempGroupID,
+	})
+	settle(c.ID, err)
+	if err != nil {
+		return chat_domain.Chat{}, err
+	}
+
+	svc.indexChat(ctx, c)
+	if err = svc.signFiles(ctx, chat_domain.ConvTypeChat, []int64{c.ID}, []*chat_domain.Msg{&c.Msg}); err != nil {
+		svc.l.Error("生成附件下载地址失败", logger.Int64("msgID", c.ID), logger.Error("err", err))
+	}
+	// 发送者的其他端也需要同步
+	svc.pushInbox(ctx, seqs, chat_domain.InboxMsg{
+		ConvType: chat_domain.ConvTypeChat,
+		MsgID:    c.ID,
+		Chat:     &c,
+	})
+	return c, nil
+}
+
+// createGroupMsg 保存已经校验过的群消息，投递给 members，并提醒 mentioned 中的用户，settle 同 createChat
+func (svc *ChatServiceImpl) createGroupMsg(ctx context.Context, uid, groupID int64, msg chat_domain.Msg,
+	members []group_domain.GroupMember, mentioned []int64, settle func(msgID int64, err error)) (chat_domain.GroupMsg, error) {
+	m, seqs, err := svc.repo.CreateGroupMsg(ctx, chat_domain.GroupMsg{
+		MsgType:    msg.Type,
+		MsgPreview: msg.Preview(),
+		Msg:        msg,
+		GroupID:    groupID,
+		SendUserID: uid,
+	}, memberIDs(members), mentioned)
+	settle(m.ID, err)
+	if err != nil {
+		return chat_domain.GroupMsg{}, err
+	}
+
+	svc.indexGroupMsg(ctx, m)
+	m.SendNickname = svc.senderNickname(ctx, uid, members)
+	if err = svc.signFiles(ctx, chat_domain.ConvTypeGroup, []int64{m.ID}, []*chat_domain.Msg{&m.Msg}); err != nil {
+		svc.l.Error("生成附件下载地址失败", logger.Int64("msgID", m.ID), logger.Error("err", err))
+	}
+	svc.pushInbox(ctx, seqs, chat_domain.InboxMsg{
+		ConvType: chat_domain.ConvTypeGroup,
+		MsgID:    m.ID,
+		GroupMsg: &m,
+	})
+	if len(mentioned) > 0 {
+		svc.push.Push(ctx, mentioned, push_service.Event{
+			Type: push_service.EventAt,
+			Data: chat_domain.AtEvent{
+				GroupID:    m.GroupID,
+				MsgID:      m.ID,
+				SendUserID: m.SendUserID,
+				IsAll:      m.Msg.AtMsg.IsAll,
+			},
+		})
+	}
+	return m, nil
+}
+
+func (svc *ChatServiceImpl) ChatHistory(ctx context.Context, req chat_domain.HistoryRequest) ([]chat_domain.Chat, error) {
+	chats, err := svc.repo.ChatHistory(ctx, req.UserID, req.ConvID, req.LastID, req.PageLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	// 对方的已读游标决定我发出的消息是否已读
+	peerConv, err := svc.repo.FindConversation(ctx, req.ConvID, chat_domain.ConvTypeChat, req.UserID)
+	if err != nil && !errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return nil, err
+	}
+	for i := range chats {
+		if chats[i].SendUserID == req.UserID {
+			chats[i].IsRead = chats[i].ID <= peerConv.ReadMsgID
+		}
+	}
+	if err = svc.viewChats(ctx, req.UserID, chats); err != nil {
+		return nil, err
+	}
+	return chats, nil
+}
+
+func (svc *ChatServiceImpl) GroupHistory(ctx context.Context, req chat_domain.HistoryRequest) ([]chat_domain.GroupMsg, error) {
+	if _, err := svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+		return nil, err
+	}
+	msgs, err := svc.repo.GroupHistory(ctx, req.UserID, req.ConvID, req.LastID, req.PageLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := svc.groupRepo.FindMembers(ctx, req.ConvID)
+	if err != nil {
+		return nil, err
+	}
+	cursors, err := svc.readCursors(ctx, req.ConvID, members)
+	if err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		if msgs[i].SendUserID != req.UserID {
+			continue
+		}
+		msgs[i].MemberCount = len(members) - 1
+		for uid, readMsgID := range cursors {
+			if uid != msgs[i].SendUserID && readMsgID >= msgs[i].ID {
+				msgs[i].ReadCount++
+			}
+		}
+	}
+	if err = svc.viewGroupMsgs(ctx, req.UserID, msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (svc *ChatServiceImpl) Read(ctx context.Context, req chat_domain.ReadRequest) error {
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		return svc.readChat(ctx, req)
+	case chat_domain.ConvTypeGroup:
+		return svc.readGroup(ctx, req)
+	default:
+		return ErrConvType
+	}
+}
+
+func (svc *ChatServiceImpl) readChat(ctx context.Context, req chat_domain.ReadRequest) error {
+	c, err := svc.repo.FindChatByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !(c.SendUserID == req.UserID && c.RevUserID == req.ConvID) &&
+		!(c.SendUserID == req.ConvID && c.RevUserID == req.UserID) {
+		return ErrMsgNotFound
+	}
+
+	old, err := svc.repo.UpdateReadMsgID(ctx, req.UserID, chat_domain.ConvTypeChat, req.ConvID, req.MsgID)
+	if err != nil {
+		return err
+	}
+	if req.MsgID <= old {
+		return nil
+	}
+	svc.push.Push(ctx, []int64{req.ConvID}, push_service.Event{
+		Type: push_service.EventRead,
+		Data: chat_domain.ReadEvent{
+			ConvType:  chat_domain.ConvTypeChat,
+			ConvID:    req.UserID,
+			UserID:    req.UserID,
+			ReadMsgID: req.MsgID,
+		},
+	})
+	return nil
+}
+
+func (svc *ChatServiceImpl) readGroup(ctx context.Context, req chat_domain.ReadRequest) error {
+	if _, err := svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+		return err
+	}
+	m, err := svc.repo.FindGroupMsgByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) || (err == nil && m.GroupID != req.ConvID) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	old, err := svc.repo.UpdateReadMsgID(ctx, req.UserID, chat_domain.ConvTypeGroup, req.ConvID, req.MsgID)
+	if err != nil {
+		return err
+	}
+	if req.MsgID <= old {
+		return nil
+	}
+	// 只通知这次新读到的消息的发送者
+	senders, err := svc.repo.FindGroupSenders(ctx, req.ConvID, old, req.MsgID, req.UserID)
+	if err != nil {
+		return err
+	}
+	svc.push.Push(ctx, senders, push_service.Event{
+		Type: push_service.EventRead,
+		Data: chat_domain.ReadEvent{
+			ConvType:  chat_domain.ConvTypeGroup,
+			ConvID:    req.ConvID,
+			UserID:    req.UserID,
+			ReadMsgID: req.MsgID,
+		},
+	})
+	return nil
+}
+
+func (svc *ChatServiceImpl) GroupMsgReaders(ctx context.Context, uid, msgID int64) (chat_domain.GroupMsgReaders, error) {
+	m, err := svc.repo.FindGroupMsgByID(ctx, msgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return chat_domain.GroupMsgReaders{}, ErrMsgNotFound
+	}
+	if err != nil {
+		return chat_domain.GroupMsgReaders{}, err
+	}
+	member, err := svc.findMember(ctx, m.GroupID, uid)
+	if err != nil {
+		return chat_domain.GroupMsgReaders{}, err
+	}
+	if !member.IsAdmin() {
+		return chat_domain.GroupMsgReaders{}, ErrNoPermission
+	}
+
+	members, err := svc.groupRepo.FindMembers(ctx, m.GroupID)
+	if err != nil {
+		return chat_domain.GroupMsgReaders{}, err
+	}
+	convs, err := svc.repo.FindConversations(ctx, chat_domain.ConvTypeGroup, m.GroupID)
+	if err != nil {
+		return chat_domain.GroupMsgReaders{}, err
+	}
+	convMap := make(map[int64]chat_domain.Conversation, len(convs))
+	for _, c := range convs {
+		convMap[c.UserID] = c
+	}
+
+	res := chat_domain.GroupMsgReaders{
+		MsgID:  msgID,
+		Read:   []chat_domain.Reader{},
+		Unread: []chat_domain.Reader{},
+	}
+	for _, mem := range members {
+		if mem.UserID == m.SendUserID {
+			continue
+		}
+		reader := chat_domain.Reader{
+			UserID:         mem.UserID,
+			MemberNickname: mem.MemberNickname,
+		}
+		c, ok := convMap[mem.UserID]
+		if ok && c.ReadMsgID >= msgID {
+			reader.ReadTime = c.UpdateTime
+			res.Read = append(res.Read, reader)
+		} else {
+			res.Unread = append(res.Unread, reader)
+		}
+	}
+	return res, nil
+}
+
+// Sync 拉取 Seq 之后的收件箱消息，私聊和群聊按序号合并在同一批返回
+func (svc *ChatServiceImpl) Sync(ctx context.Context, req chat_domain.SyncRequest) (chat_domain.SyncResult, error) {
+	us, err := svc.repo.FindUserSeq(ctx, req.UserID)
+	if err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+	limit := req.PageLimit()
+	inboxes, err := svc.repo.FindInbox(ctx, req.UserID, req.Seq, limit)
+	if err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+
+	// 按会话类型分别批量加载消息，避免逐条查询
+	// 自己已经删除、清空的消息不再返回内容，但保留收件箱记录，客户端的序号仍然是连续的
+	var chatIDs, groupMsgIDs []int64
+	for _, i := range inboxes {
+		if i.ConvType == chat_domain.ConvTypeGroup {
+			groupMsgIDs = append(groupMsgIDs, i.MsgID)
+		} else {
+			chatIDs = append(chatIDs, i.MsgID)
+		}
+	}
+	chats, err := svc.repo.FindVisibleChats(ctx, req.UserID, chatIDs)
+	if err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+	groupMsgs, err := svc.repo.FindVisibleGroupMsgs(ctx, req.UserID, groupMsgIDs)
+	if err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+	if err = svc.viewChats(ctx, req.UserID, chats); err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+	if err = svc.viewGroupMsgs(ctx, req.UserID, groupMsgs); err != nil {
+		return chat_domain.SyncResult{}, err
+	}
+	chatMap := make(map[int64]*chat_domain.Chat, len(chats))
+	for i := range chats {
+		chatMap[chats[i].ID] = &chats[i]
+	}
+	groupMsgMap := make(map[int64]*chat_domain.GroupMsg, len(groupMsgs))
+	for i := range groupMsgs {
+		groupMsgMap[groupMsgs[i].ID] = &groupMsgs[i]
+	}
+	for i := range inboxes {
+		if inboxes[i].ConvType == chat_domain.ConvTypeGroup {
+			inboxes[i].GroupMsg = groupMsgMap[inboxes[i].MsgID]
+		} else {
+			inboxes[i].Chat = chatMap[inboxes[i].MsgID]
+		}
+	}
+
+	return chat_domain.SyncResult{
+		Msgs:    inboxes,
+		MaxSeq:  us.MaxSeq,
+		AckSeq:  us.AckSeq,
+		HasMore: len(inboxes) == limit,
+	}, nil
+}
+
+// Ack 确认收到 Seq 及之前的消息，服务端据此清理收件箱
+func (svc *ChatServiceImpl) Ack(ctx context.Context, req chat_domain.AckRequest) error {
+	if req.Seq <= 0 {
+		return nil
+	}
+	return svc.repo.Ack(ctx, req.UserID, req.Seq)
+}
+
+// pushInbox 按每个用户分配到的序号推送新消息，客户端发现序号不连续时调用 Sync 补齐
+func (svc *ChatServiceImpl) pushInbox(ctx context.Context, seqs map[int64]int64, msg chat_domain.InboxMsg) {
+	for uid, seq := range seqs {
+		msg.Seq = seq
+		svc.push.Push(ctx, []int64{uid}, push_service.Event{
+			Type: push_service.EventMsg,
+			Data: msg,
+		})
+	}
+}
+
+// findMember 查询群成员，不是群成员时返回 ErrNotGroupMember
+func (svc *ChatServiceImpl) findMember(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error) {
+	member, err := svc.groupRepo.FindMember(ctx, groupID, uid)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return group_domain.GroupMember{}, ErrNotGroupMember
+	}
+	return member, err
+}
+
+// msgAudience 一条消息所在的会话，以及消息被操作后需要通知的用户
+type msgAudience struct {
+	SendUserID int64                    // 私聊的发送者
+	RevUserID  int64                    // 私聊的接收者
+	GroupID    int64                    // 群聊的群ID，私聊为 0
+	Member     group_domain.GroupMember // 群聊中操作者的成员信息
+	Withdrawn  bool                     // 消息是否已经撤回
+	ConvIDs    map[int64]int64          // 需要推送的用户，以及该用户看到的会话ID，私聊双方看到的会话ID不同
+}
+
+// findMsgAudience 查询 uid 要操作的消息所在的会话，私聊只有收发双方、群聊只有群成员可以操作
+// 表情回应、置顶等操作按返回的 ConvIDs 推送给会话中的每个用户
+func (svc *ChatServiceImpl) findMsgAudience(ctx context.Context, uid int64, convType int8, msgID int64) (msgAudience, error) {
+	switch convType {
+	case chat_domain.ConvTypeChat:
+		c, err := svc.repo.FindChatByID(ctx, msgID)
+		if errors.Is(err, chat_repo.ErrRecordNotFound) || (err == nil && c.SendUserID != uid && c.RevUserID != uid) {
+			return msgAudience{}, ErrMsgNotFound
+		}
+		if err != nil {
+			return msgAudience{}, err
+		}
+		peerID := c.SendUserID
+		if peerID == uid {
+			peerID = c.RevUserID
+		}
+		return msgAudience{
+			SendUserID: c.SendUserID,
+			RevUserID:  c.RevUserID,
+			Withdrawn:  c.MsgType == chat_domain.MsgTypeWithdraw,
+			ConvIDs:    map[int64]int64{uid: peerID, peerID: uid},
+		}, nil
+	case chat_domain.ConvTypeGroup:
+		m, err := svc.repo.FindGroupMsgByID(ctx, msgID)
+		if errors.Is(err, chat_repo.ErrRecordNotFound) {
+			return msgAudience{}, ErrMsgNotFound
+		}
+		if err != nil {
+			return msgAudience{}, err
+		}
+		member, err := svc.findMember(ctx, m.GroupID, uid)
+		if err != nil {
+			return msgAudience{}, err
+		}
+		members, err := svc.groupRepo.FindMembers(ctx, m.GroupID)
+		if err != nil {
+			return msgAudience{}, err
+		}
+		convIDs := make(map[int64]int64, len(members))
+		for _, mem := range members {
+			convIDs[mem.UserID] = m.GroupID
+		}
+		return msgAudience{
+			GroupID:   m.GroupID,
+			Member:    member,
+			Withdrawn: m.MsgType == chat_domain.MsgTypeWithdraw,
+			ConvIDs:   convIDs,
+		}, nil
+	default:
+		return msgAudience{}, ErrConvType
+	}
+}
+
+// readCursors 群成员的已读游标，已经退群的用户不计入
+func (svc *ChatServiceImpl) readCursors(ctx context.Context, groupID int64,
+	members []group_domain.GroupMember) (map[int64]int64, error) {
+	convs, err := svc.repo.FindConversations(ctx, chat_domain.ConvTypeGroup, groupID)
+	if err != nil {
+		return nil, err
+	}
+	isMember := make(map[int64]bool, len(members))
+	for _, m := range members {
+		isMember[m.UserID] = true
+	}
+	res := make(map[int64]int64, len(convs))
+	for _, c := range convs {
+		if isMember[c.UserID] {
+			res[c.UserID] = c.ReadMsgID
+		}
+	}
+	return res, nil
+}
+
+func memberIDs(members []group_domain.GroupMember) []int64 {
+	res := make([]int64, 0, len(members))
+	for _, m := range members {
+		res = append(res, m.UserID)
+	}
+	return res
+}
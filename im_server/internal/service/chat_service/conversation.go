@@ -0,0 +1,193 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/repository/user_repo"
+	"sort"
+)
+
+var ErrUserNotFound = errors.New("用户不存在")
+
+// PinConversation 置顶或取消置顶会话，只影响自己的会话列表
+func (svc *ChatServiceImpl) PinConversation(ctx context.Context, req chat_domain.PinConversationRequest) error {
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		_, err := svc.userRepo.FindByID(ctx, req.ConvID)
+		if errors.Is(err, user_repo.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+	case chat_domain.ConvTypeGroup:
+		if _, err := svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+			return err
+		}
+	default:
+		return ErrConvType
+	}
+	err := svc.repo.PinConversation(ctx, req.UserID, req.ConvType, req.ConvID, req.Pinned, chat_domain.MaxPinnedConversations)
+	if errors.Is(err, chat_repo.ErrPinLimit) {
+		return chat_domain.ErrPinnedConvTooMany
+	}
+	return err
+}
+
+// Conversations 查询会话列表，置顶会话在前并按置顶时间倒序，其余按最新消息时间倒序
+func (svc *ChatServiceImpl) Conversations(ctx context.Context, uid int64) ([]chat_domain.ConversationItem, error) {
+	convs, err := svc.repo.FindConversationsByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	type convKey struct {
+		convType int8
+		convID   int64
+	}
+	states := make(map[convKey]chat_domain.Conversation, len(convs))
+	for _, c := range convs {
+		states[convKey{c.ConvType, c.ConvID}] = c
+	}
+
+	// 私聊：有未删除消息的会话，加上置顶但还没有消息的会话
+	lastChats, err := svc.repo.FindLastChats(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range convs {
+		if c.ConvType == chat_domain.ConvTypeChat && c.IsPinned {
+			if _, ok := lastChats[c.ConvID]; !ok {
+				lastChats[c.ConvID] = 0
+			}
+		}
+	}
+	// 群聊：加入的全部群
+	groupIDs, err := svc.groupRepo.FindGroupIDsByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	lastGroupMsgs, err := svc.repo.FindLastGroupMsgs(ctx, uid, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	chatUnread, err := svc.repo.CountChatUnread(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	groupUnread, err := svc.repo.CountGroupUnread(ctx, uid, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	peerIDs := make([]int64, 0, len(lastChats))
+	chatIDs := make([]int64, 0, len(lastChats))
+	for peerID, msgID := range lastChats {
+		peerIDs = append(peerIDs, peerID)
+		if msgID > 0 {
+			chatIDs = append(chatIDs, msgID)
+		}
+	}
+	groupMsgIDs := make([]int64, 0, len(lastGroupMsgs))
+	for _, msgID := range lastGroupMsgs {
+		groupMsgIDs = append(groupMsgIDs, msgID)
+	}
+	chats, err := svc.repo.FindChatsByIDs(ctx, chatIDs)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := svc.repo.FindGroupMsgsByIDs(ctx, groupMsgIDs)
+	if err != nil {
+		return nil, err
+	}
+	users, err := svc.userRepo.FindByIDs(ctx, peerIDs)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := svc.groupRepo.FindByIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 群聊的最新消息预览带上发送者的群昵称
+	keys := make([]memberKey, 0, len(msgs))
+	for _, m := range msgs {
+		keys = append(keys, memberKey{m.GroupID, m.SendUserID})
+	}
+	senderNames, err := svc.groupNicknames(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	chatByID := make(map[int64]chat_domain.Chat, len(chats))
+	for _, c := range chats {
+		chatByID[c.ID] = c
+	}
+	msgByID := make(map[int64]chat_domain.GroupMsg, len(msgs))
+	for _, m := range msgs {
+		msgByID[m.ID] = m
+	}
+
+	items := make([]chat_domain.ConversationItem, 0, len(users)+len(groups))
+	for _, u := range users {
+		item := chat_domain.ConversationItem{
+			ConvType: chat_domain.ConvTypeChat,
+			ConvID:   u.ID,
+			Name:     u.Nickname,
+			Avatar:   u.Avatar,
+			Unread:   chatUnread[u.ID],
+		}
+		if c, ok := chatByID[lastChats[u.ID]]; ok {
+			item.LastMsgID = c.ID
+			item.LastMsgType = c.MsgType
+			item.LastPreview = c.MsgPreview
+			item.LastSender = c.SendUserID
+			item.LastTime = c.CreateTime
+			item.TempGroupID = c.TempGroupID
+		}
+		if state, ok := states[convKey{chat_domain.ConvTypeChat, u.ID}]; ok {
+			item.IsPinned = state.IsPinned
+			item.PinTime = state.PinTime
+		}
+		items = append(items, item)
+	}
+	for _, g := range groups {
+		item := chat_domain.ConversationItem{
+			ConvType: chat_domain.ConvTypeGroup,
+			ConvID:   g.ID,
+			Name:     g.Title,
+			Avatar:   g.Avatar,
+			Unread:   groupUnread[g.ID],
+		}
+		state := states[convKey{chat_domain.ConvTypeGroup, g.ID}]
+		if m, ok := msgByID[lastGroupMsgs[g.ID]]; ok {
+			item.LastMsgID = m.ID
+			item.LastMsgType = m.MsgType
+			item.LastPreview = m.MsgPreview
+			if name := senderNames[memberKey{m.GroupID, m.SendUserID}]; name != "" && m.MsgType != chat_domain.MsgTypeWithdraw {
+				item.LastPreview = name + "：" + m.MsgPreview
+			}
+			item.LastSender = m.SendUserID
+			item.LastTime = m.CreateTime
+		}
+		item.IsPinned = state.IsPinned
+		item.PinTime = state.PinTime
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.IsPinned != b.IsPinned {
+			return a.IsPinned
+		}
+		if a.IsPinned && !a.PinTime.Equal(b.PinTime) {
+			return a.PinTime.After(b.PinTime)
+		}
+		if !a.LastTime.Equal(b.LastTime) {
+			return a.LastTime.After(b.LastTime)
+		}
+		return a.LastMsgID > b.LastMsgID
+	})
+	return items, nil
+}
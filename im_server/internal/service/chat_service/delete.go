@@ -0,0 +1,106 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/service/push_service"
+)
+
+// purgeBatch 后台清理任务每批物理删除的消息数
+const purgeBatch = 500
+
+// Delete 为自己删除消息，对方和其他群成员不受影响
+func (svc *ChatServiceImpl) Delete(ctx context.Context, req chat_domain.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	var err error
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		if err = svc.repo.DeleteChats(ctx, req.UserID, req.ConvID, req.MsgIDs); err != nil {
+			return err
+		}
+		err = svc.searchRepo.RemoveByOwner(ctx, req.UserID, chat_domain.ConvTypeChat, req.ConvID, req.MsgIDs)
+	case chat_domain.ConvTypeGroup:
+		if _, err = svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+			return err
+		}
+		err = svc.repo.DeleteGroupMsgs(ctx, req.UserID, req.ConvID, req.MsgIDs)
+	default:
+		return ErrConvType
+	}
+	if err != nil {
+		return err
+	}
+	svc.pushDelete(ctx, req.UserID, chat_domain.DeleteEvent{
+		ConvType: req.ConvType,
+		ConvID:   req.ConvID,
+		MsgIDs:   req.MsgIDs,
+	})
+	return nil
+}
+
+// Clear 为自己清空会话，之后的新消息正常可见
+func (svc *ChatServiceImpl) Clear(ctx context.Context, req chat_domain.ClearRequest) error {
+	var err error
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		if err = svc.repo.ClearChat(ctx, req.UserID, req.ConvID); err != nil {
+			return err
+		}
+		err = svc.searchRepo.RemoveByOwner(ctx, req.UserID, chat_domain.ConvTypeChat, req.ConvID, nil)
+	case chat_domain.ConvTypeGroup:
+		if _, err = svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+			return err
+		}
+		err = svc.repo.ClearGroup(ctx, req.UserID, req.ConvID)
+	default:
+		return ErrConvType
+	}
+	if err != nil {
+		return err
+	}
+	svc.pushDelete(ctx, req.UserID, chat_domain.DeleteEvent{
+		ConvType: req.ConvType,
+		ConvID:   req.ConvID,
+	})
+	return nil
+}
+
+// PurgeDeleted 分批物理删除收发双方都已经删除的私聊消息，返回删除的总条数
+// 消息绑定的附件一并删除，释放对文件记录的引用，之后文件由清理任务回收
+func (svc *ChatServiceImpl) PurgeDeleted(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		ids, err := svc.repo.PurgeChats(ctx, purgeBatch)
+		if err != nil {
+			return total, err
+		}
+		total += len(ids)
+		if err = svc.releaseMsgAttachments(ctx, chat_domain.ConvTypeChat, ids); err != nil {
+			return total, err
+		}
+		if len(ids) < purgeBatch {
+			return total, nil
+		}
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+}
+
+// releaseMsgAttachments 删除已经物理删除的消息绑定的附件
+func (svc *ChatServiceImpl) releaseMsgAttachments(ctx context.Context, convType int8, msgIDs []int64) error {
+	as, err := svc.fileRepo.FindMsgAttachments(ctx, convType, msgIDs)
+	if err != nil {
+		return err
+	}
+	return svc.fileRepo.DeleteAttachments(ctx, as)
+}
+
+func (svc *ChatServiceImpl) pushDelete(ctx context.Context, uid int64, evt chat_domain.DeleteEvent) {
+	svc.push.Push(ctx, []int64{uid}, push_service.Event{
+		Type: push_service.EventDelete,
+		Data: evt,
+	})
+}
@@ -0,0 +1,180 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/pkg/logger"
+	"time"
+)
+
+// editTimeout 发送者可以编辑消息的时间
+const editTimeout = 15 * time.Minute
+
+var (
+	ErrEditNotSupported = errors.New("只能编辑文本消息")
+	ErrEditExpire       = errors.New("消息发送超过 15 分钟，不能编辑")
+	ErrMsgChanged       = errors.New("消息已被修改，请刷新后重试")
+)
+
+// Edit 编辑自己发送的文本消息，旧版本保存到编辑历史
+func (svc *ChatServiceImpl) Edit(ctx context.Context, req chat_domain.EditRequest) error {
+	if req.Content == "" {
+		return chat_domain.ErrMsgContentEmpty
+	}
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		return svc.editChat(ctx, req)
+	case chat_domain.ConvTypeGroup:
+		return svc.editGroupMsg(ctx, req)
+	default:
+		return ErrConvType
+	}
+}
+
+func (svc *ChatServiceImpl) editChat(ctx context.Context, req chat_domain.EditRequest) error {
+	old, err := svc.repo.FindChatByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err = checkEdit(req.UserID, old.SendUserID, old.MsgType, old.CreateTime); err != nil {
+		return err
+	}
+
+	c := old
+	c.Msg = edited(old.Msg, req.Content)
+	c.MsgPreview = c.Msg.Preview()
+	if err = svc.repo.EditChat(ctx, old, c); err != nil {
+		if errors.Is(err, chat_repo.ErrMsgChanged) {
+			return ErrMsgChanged
+		}
+		return err
+	}
+	c.Edited = true
+	c.UpdateTime = time.Now()
+	svc.reindex(ctx, chat_domain.ConvTypeChat, c.ID, c.Msg)
+
+	svc.push.Push(ctx, []int64{c.SendUserID, c.RevUserID}, push_service.Event{
+		Type: push_service.EventEdit,
+		Data: chat_domain.MsgEvent{
+			ConvType: chat_domain.ConvTypeChat,
+			Chat:     &c,
+		},
+	})
+	return nil
+}
+
+func (svc *ChatServiceImpl) editGroupMsg(ctx context.Context, req chat_domain.EditRequest) error {
+	old, err := svc.repo.FindGroupMsgByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err = checkEdit(req.UserID, old.SendUserID, old.MsgType, old.CreateTime); err != nil {
+		return err
+	}
+	// 已经退群或者被禁言的成员不能再编辑
+	if _, err = svc.checkGroupSend(ctx, old.GroupID, req.UserID); err != nil {
+		return err
+	}
+
+	m := old
+	m.Msg = edited(old.Msg, req.Content)
+	m.MsgPreview = m.Msg.Preview()
+	if err = svc.repo.EditGroupMsg(ctx, old, m); err != nil {
+		if errors.Is(err, chat_repo.ErrMsgChanged) {
+			return ErrMsgChanged
+		}
+		return err
+	}
+	m.Edited = true
+	m.UpdateTime = time.Now()
+	svc.reindex(ctx, chat_domain.ConvTypeGroup, m.ID, m.Msg)
+
+	members, err := svc.groupRepo.FindMembers(ctx, m.GroupID)
+	if err != nil {
+		return err
+	}
+	svc.push.Push(ctx, memberIDs(members), push_service.Event{
+		Type: push_service.EventEdit,
+		Data: chat_domain.MsgEvent{
+			ConvType: chat_domain.ConvTypeGroup,
+			GroupMsg: &m,
+		},
+	})
+	return nil
+}
+
+// EditHistory 查询消息的编辑历史，只有能看到这条消息的人可以查询，撤回后只有发送者可以查询
+func (svc *ChatServiceImpl) EditHistory(ctx context.Context, uid int64, convType int8, msgID int64) ([]chat_domain.MsgEdit, error) {
+	var (
+		sendUserID int64
+		msgType    int8
+	)
+	switch convType {
+	case chat_domain.ConvTypeChat:
+		c, err := svc.repo.FindChatByID(ctx, msgID)
+		if errors.Is(err, chat_repo.ErrRecordNotFound) || (err == nil && c.SendUserID != uid && c.RevUserID != uid) {
+			return nil, ErrMsgNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		sendUserID, msgType = c.SendUserID, c.MsgType
+	case chat_domain.ConvTypeGroup:
+		m, err := svc.repo.FindGroupMsgByID(ctx, msgID)
+		if errors.Is(err, chat_repo.ErrRecordNotFound) {
+			return nil, ErrMsgNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err = svc.findMember(ctx, m.GroupID, uid); err != nil {
+			return nil, err
+		}
+		sendUserID, msgType = m.SendUserID, m.MsgType
+	default:
+		return nil, ErrConvType
+	}
+	if msgType == chat_domain.MsgTypeWithdraw && sendUserID != uid {
+		return nil, ErrWithdrawn
+	}
+	return svc.repo.FindMsgEdits(ctx, convType, msgID)
+}
+
+// checkEdit 只有发送者可以在限定时间内编辑文本消息
+func checkEdit(uid, sendUserID int64, msgType int8, createTime time.Time) error {
+	if sendUserID != uid {
+		return ErrNoPermission
+	}
+	if msgType == chat_domain.MsgTypeWithdraw {
+		return ErrWithdrawn
+	}
+	if msgType != chat_domain.MsgTypeText {
+		return ErrEditNotSupported
+	}
+	if time.Since(createTime) > editTimeout {
+		return ErrEditExpire
+	}
+	return nil
+}
+
+// edited 替换文本内容，保留转发等标记
+func edited(msg chat_domain.Msg, content string) chat_domain.Msg {
+	msg.Content = &content
+	return msg
+}
+
+// reindex 消息编辑后更新全文索引
+func (svc *ChatServiceImpl) reindex(ctx context.Context, convType int8, msgID int64, msg chat_domain.Msg) {
+	if err := svc.searchRepo.UpdateText(ctx, convType, msgID, msg.SearchText()); err != nil {
+		svc.l.Error("更新消息索引失败", logger.Int64("msgID", msgID), logger.Error("err", err))
+	}
+}
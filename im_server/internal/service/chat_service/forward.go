@@ -0,0 +1,201 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"sort"
+	"time"
+)
+
+// forwardSource 被转发的一条消息
+type forwardSource struct {
+	GroupID    int64 // 来自群聊时为群ID，私聊为 0
+	SendUserID int64
+	SendTime   time.Time
+	Msg        chat_domain.Msg
+}
+
+// Forward 转发消息，转发者必须能看到每一条被转发的消息，并且能在每个目标会话中发言
+func (svc *ChatServiceImpl) Forward(ctx context.Context, req chat_domain.ForwardRequest) ([]chat_domain.ForwardedMsg, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	sources, title, err := svc.loadForwardSources(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []chat_domain.Msg
+	if req.Merge {
+		items, err := svc.mergeItems(ctx, sources)
+		if err != nil {
+			return nil, err
+		}
+		msgs = []chat_domain.Msg{chat_domain.NewMergeMsg(title, items)}
+	} else {
+		for _, src := range sources {
+			msg, err := src.Msg.ForwardCopy()
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+
+	// 先校验全部目标会话，避免只转发成功一部分
+	groupMembers := make(map[int64][]group_domain.GroupMember)
+	for _, t := range req.Targets {
+		switch t.ConvType {
+		case chat_domain.ConvTypeChat:
+			// 转发只能发给好友，不能发起临时会话
+			if _, err = svc.checkChatSend(ctx, req.UserID, t.ConvID, 0); err != nil {
+				return nil, err
+			}
+		case chat_domain.ConvTypeGroup:
+			if _, err = svc.checkGroupSend(ctx, t.ConvID, req.UserID); err != nil {
+				return nil, err
+			}
+			if groupMembers[t.ConvID], err = svc.groupRepo.FindMembers(ctx, t.ConvID); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, ErrConvType
+		}
+	}
+
+	res := make([]chat_domain.ForwardedMsg, 0, len(req.Targets)*len(msgs))
+	for _, t := range req.Targets {
+		for _, msg := range msgs {
+			msg, as, err := svc.copyAttachments(ctx, t.ConvType, msg)
+			if err != nil {
+				return res, err
+			}
+			fwd := chat_domain.ForwardedMsg{ConvType: t.ConvType, ConvID: t.ConvID}
+			if t.ConvType == chat_domain.ConvTypeChat {
+				c, err := svc.createChat(ctx, req.UserID, t.ConvID, 0, msg, func(msgID int64, err error) {
+					svc.settleAttachments(ctx, as, msgID, err)
+				})
+				if err != nil {
+					return res, err
+				}
+				fwd.Chat = &c
+			} else {
+				m, err := svc.createGroupMsg(ctx, req.UserID, t.ConvID, msg, groupMembers[t.ConvID], nil, func(msgID int64, err error) {
+					svc.settleAttachments(ctx, as, msgID, err)
+				})
+				if err != nil {
+					return res, err
+				}
+				fwd.GroupMsg = &m
+			}
+			res = append(res, fwd)
+		}
+	}
+	return res, nil
+}
+
+// loadForwardSources 按发送时间顺序加载被转发的消息，并返回合并转发的标题
+// 不存在、不属于该会话、自己删除或清空了、已经撤回的消息都视为不存在
+// 使用数据库中保存的消息，不经过 viewChats，签名地址有有效期，不能保存到新消息中
+func (svc *ChatServiceImpl) loadForwardSources(ctx context.Context, req chat_domain.ForwardRequest) ([]forwardSource, string, error) {
+	ids := dedup(req.MsgIDs)
+	var (
+		sources []forwardSource
+		title   string
+	)
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		chats, err := svc.repo.FindVisibleChats(ctx, req.UserID, ids)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, c := range chats {
+			if !inChat(c, req.UserID, req.ConvID) || c.MsgType == chat_domain.MsgTypeWithdraw {
+				continue
+			}
+			sources = append(sources, forwardSource{SendUserID: c.SendUserID, SendTime: c.CreateTime, Msg: c.Msg})
+		}
+		if err = svc.withdrawnChatRefs(ctx, sourceMsgs(sources)); err != nil {
+			return nil, "", err
+		}
+		names, err := svc.nicknames(ctx, []int64{req.UserID, req.ConvID})
+		if err != nil {
+			return nil, "", err
+		}
+		title = names[req.UserID] + "和" + names[req.ConvID] + "的聊天记录"
+	case chat_domain.ConvTypeGroup:
+		if _, err := svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+			return nil, "", err
+		}
+		msgs, err := svc.repo.FindVisibleGroupMsgs(ctx, req.UserID, ids)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, m := range msgs {
+			if m.GroupID != req.ConvID || m.MsgType == chat_domain.MsgTypeWithdraw {
+				continue
+			}
+			sources = append(sources, forwardSource{GroupID: m.GroupID, SendUserID: m.SendUserID, SendTime: m.CreateTime, Msg: m.Msg})
+		}
+		if err = svc.withdrawnGroupRefs(ctx, sourceMsgs(sources)); err != nil {
+			return nil, "", err
+		}
+		group, err := svc.groupRepo.FindByID(ctx, req.ConvID)
+		if err != nil {
+			return nil, "", err
+		}
+		title = group.Title + "的聊天记录"
+	default:
+		return nil, "", ErrConvType
+	}
+	if len(sources) != len(ids) {
+		return nil, "", ErrMsgNotFound
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].SendTime.Before(sources[j].SendTime)
+	})
+	return sources, title, nil
+}
+
+// mergeItems 为被转发的消息填充发送者昵称，群消息优先使用群昵称
+func (svc *ChatServiceImpl) mergeItems(ctx context.Context, sources []forwardSource) ([]chat_domain.MergeItem, error) {
+	keys := make([]memberKey, 0, len(sources))
+	for _, src := range sources {
+		keys = append(keys, memberKey{src.GroupID, src.SendUserID})
+	}
+	names, err := svc.groupNicknames(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]chat_domain.MergeItem, 0, len(sources))
+	for _, src := range sources {
+		items = append(items, chat_domain.MergeItem{
+			SendUserID:   src.SendUserID,
+			SendNickname: names[memberKey{src.GroupID, src.SendUserID}],
+			SendTime:     src.SendTime,
+			Msg:          src.Msg,
+		})
+	}
+	return items, nil
+}
+
+func sourceMsgs(sources []forwardSource) []*chat_domain.Msg {
+	res := make([]*chat_domain.Msg, 0, len(sources))
+	for i := range sources {
+		res = append(res, &sources[i].Msg)
+	}
+	return res
+}
+
+func dedup(ids []int64) []int64 {
+	res := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
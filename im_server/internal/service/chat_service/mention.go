@@ -0,0 +1,90 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+)
+
+// resolveAt 校验@消息并返回需要提醒的用户，发送者自己不会收到提醒
+// 1. @所有人只有群主和管理员可以使用，展开为除发送者以外的全部成员
+// 2. 指定的用户必须都是群成员，UserIDs 归一化为去重后的结果
+func resolveAt(msg *chat_domain.Msg, sender group_domain.GroupMember,
+	members []group_domain.GroupMember) ([]int64, error) {
+	if msg.Type != chat_domain.MsgTypeAt {
+		return nil, nil
+	}
+	if msg.AtMsg.IsAll && !sender.IsAdmin() {
+		return nil, ErrAtAllNoPermission
+	}
+	isMember := make(map[int64]bool, len(members))
+	for _, m := range members {
+		isMember[m.UserID] = true
+	}
+	targets := msg.AtMsg.Targets()
+	for _, uid := range targets {
+		if !isMember[uid] {
+			return nil, ErrAtNotMember
+		}
+	}
+	msg.AtMsg.UserIDs = targets
+	msg.AtMsg.Msg = nil
+
+	if msg.AtMsg.IsAll {
+		targets = memberIDs(members)
+	}
+	res := make([]int64, 0, len(targets))
+	for _, uid := range targets {
+		if uid != sender.UserID {
+			res = append(res, uid)
+		}
+	}
+	return res, nil
+}
+
+// Mentions 我被@的列表，已经退出的群、自己清空和删除的消息不再返回
+func (svc *ChatServiceImpl) Mentions(ctx context.Context, req chat_domain.MentionRequest) ([]chat_domain.Mention, error) {
+	mentions, err := svc.repo.FindMentions(ctx, req.UserID, req.GroupID, req.LastID, req.PageLimit())
+	if err != nil {
+		return nil, err
+	}
+	if len(mentions) == 0 {
+		return mentions, nil
+	}
+
+	msgIDs := make([]int64, 0, len(mentions))
+	groupIDs := make([]int64, 0, len(mentions))
+	seen := make(map[int64]bool, len(mentions))
+	for _, m := range mentions {
+		msgIDs = append(msgIDs, m.MsgID)
+		if !seen[m.GroupID] {
+			seen[m.GroupID] = true
+			groupIDs = append(groupIDs, m.GroupID)
+		}
+	}
+	msgs, err := svc.repo.FindVisibleGroupMsgs(ctx, req.UserID, msgIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err = svc.viewGroupMsgs(ctx, req.UserID, msgs); err != nil {
+		return nil, err
+	}
+	convs, err := svc.repo.FindUserConversations(ctx, req.UserID, chat_domain.ConvTypeGroup, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	msgMap := make(map[int64]*chat_domain.GroupMsg, len(msgs))
+	for i := range msgs {
+		msgMap[msgs[i].ID] = &msgs[i]
+	}
+	cursors := make(map[int64]int64, len(convs))
+	for _, c := range convs {
+		cursors[c.ConvID] = c.ReadMsgID
+	}
+	for i := range mentions {
+		mentions[i].GroupMsg = msgMap[mentions[i].MsgID]
+		mentions[i].IsRead = mentions[i].MsgID <= cursors[mentions[i].GroupID]
+	}
+	return mentions, nil
+}
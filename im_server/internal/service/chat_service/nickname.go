@@ -0,0 +1,93 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// memberKey 某个用户在某个群里
+type memberKey struct {
+	groupID int64
+	uid     int64
+}
+
+// nicknames 批量查询用户昵称
+func (svc *ChatServiceImpl) nicknames(ctx context.Context, uids []int64) (map[int64]string, error) {
+	users, err := svc.userRepo.FindByIDs(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]string, len(users))
+	for _, u := range users {
+		res[u.ID] = u.Nickname
+	}
+	return res, nil
+}
+
+// groupNicknames 批量查询用户在群里展示的名字，设置了群昵称时使用群昵称，否则（包括已经退群）使用用户昵称
+func (svc *ChatServiceImpl) groupNicknames(ctx context.Context, keys []memberKey) (map[memberKey]string, error) {
+	res := make(map[memberKey]string, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+	groupIDs := make([]int64, 0, len(keys))
+	uids := make([]int64, 0, len(keys))
+	for _, k := range keys {
+		groupIDs = append(groupIDs, k.groupID)
+		uids = append(uids, k.uid)
+	}
+	uids = dedup(uids)
+	members, err := svc.groupRepo.FindMembersByUsers(ctx, dedup(groupIDs), uids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if m.MemberNickname != "" {
+			res[memberKey{m.GroupID, m.UserID}] = m.MemberNickname
+		}
+	}
+	names, err := svc.nicknames(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if _, ok := res[k]; !ok {
+			res[k] = names[k.uid]
+		}
+	}
+	return res, nil
+}
+
+// groupMsgNicknames 批量填充群消息发送者在群里展示的名字
+func (svc *ChatServiceImpl) groupMsgNicknames(ctx context.Context, msgs []chat_domain.GroupMsg) error {
+	keys := make([]memberKey, 0, len(msgs))
+	for _, m := range msgs {
+		keys = append(keys, memberKey{m.GroupID, m.SendUserID})
+	}
+	names, err := svc.groupNicknames(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].SendNickname = names[memberKey{msgs[i].GroupID, msgs[i].SendUserID}]
+	}
+	return nil
+}
+
+// senderNickname 发送群消息时，从已经查出的群成员中取发送者的群昵称，没有设置时查询用户昵称
+// 消息已经写入，查询失败只记录日志，推送的消息不带昵称
+func (svc *ChatServiceImpl) senderNickname(ctx context.Context, uid int64, members []group_domain.GroupMember) string {
+	for _, m := range members {
+		if m.UserID == uid && m.MemberNickname != "" {
+			return m.MemberNickname
+		}
+	}
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		svc.l.Error("查询用户昵称失败", logger.Int64("uid", uid), logger.Error("err", err))
+		return ""
+	}
+	return u.Nickname
+}
@@ -0,0 +1,155 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+var ErrPinNotAdmin = errors.New("只有群主和管理员可以置顶消息")
+
+// PinMsg 置顶消息，群聊只有群主和管理员可以置顶，私聊双方都可以置顶
+func (svc *ChatServiceImpl) PinMsg(ctx context.Context, req chat_domain.PinRequest) error {
+	return svc.pin(ctx, req, false)
+}
+
+// UnpinMsg 取消置顶消息，权限同 PinMsg
+func (svc *ChatServiceImpl) UnpinMsg(ctx context.Context, req chat_domain.PinRequest) error {
+	return svc.pin(ctx, req, true)
+}
+
+func (svc *ChatServiceImpl) pin(ctx context.Context, req chat_domain.PinRequest, unpin bool) error {
+	aud, err := svc.findMsgAudience(ctx, req.UserID, req.ConvType, req.MsgID)
+	if err != nil {
+		return err
+	}
+	var convID, peerID int64
+	if req.ConvType == chat_domain.ConvTypeChat {
+		convID, peerID = chatPinKey(aud.SendUserID, aud.RevUserID)
+	} else {
+		if !aud.Member.IsAdmin() {
+			return ErrPinNotAdmin
+		}
+		convID = aud.GroupID
+	}
+
+	var changed bool
+	if unpin {
+		changed, err = svc.repo.UnpinMsg(ctx, req.ConvType, convID, peerID, req.MsgID)
+		if err != nil {
+			return err
+		}
+	} else {
+		if aud.Withdrawn {
+			return ErrWithdrawn
+		}
+		err = svc.repo.PinMsg(ctx, req.ConvType, convID, peerID, req.MsgID, req.UserID, chat_domain.MaxPinnedMsgs)
+		if errors.Is(err, chat_repo.ErrPinLimit) {
+			return chat_domain.ErrPinnedMsgsTooMany
+		}
+		if err != nil {
+			return err
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	for uid, cid := range aud.ConvIDs {
+		svc.push.Push(ctx, []int64{uid}, push_service.Event{
+			Type: push_service.EventPin,
+			Data: chat_domain.PinEvent{
+				ConvType: req.ConvType,
+				ConvID:   cid,
+				MsgID:    req.MsgID,
+				UserID:   req.UserID,
+				Unpinned: unpin,
+			},
+		})
+	}
+	return nil
+}
+
+// PinnedMsgs 查询会话中的置顶消息，最近置顶的在前
+func (svc *ChatServiceImpl) PinnedMsgs(ctx context.Context, req chat_domain.PinnedListRequest) ([]chat_domain.PinnedMsg, error) {
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		convID, peerID := chatPinKey(req.UserID, req.ConvID)
+		pins, err := svc.repo.FindPinnedMsgs(ctx, req.ConvType, convID, peerID)
+		if err != nil || len(pins) == 0 {
+			return []chat_domain.PinnedMsg{}, err
+		}
+		chats, err := svc.repo.FindChatsByIDs(ctx, pinMsgIDs(pins))
+		if err != nil {
+			return nil, err
+		}
+		if err = svc.viewChats(ctx, req.UserID, chats); err != nil {
+			return nil, err
+		}
+		byID := make(map[int64]*chat_domain.Chat, len(chats))
+		for i := range chats {
+			byID[chats[i].ID] = &chats[i]
+		}
+		res := make([]chat_domain.PinnedMsg, 0, len(pins))
+		for _, p := range pins {
+			if c, ok := byID[p.MsgID]; ok {
+				res = append(res, chat_domain.PinnedMsg{PinUserID: p.PinUserID, PinTime: p.PinTime, Chat: c})
+			}
+		}
+		return res, nil
+	case chat_domain.ConvTypeGroup:
+		if _, err := svc.findMember(ctx, req.ConvID, req.UserID); err != nil {
+			return nil, err
+		}
+		pins, err := svc.repo.FindPinnedMsgs(ctx, req.ConvType, req.ConvID, 0)
+		if err != nil || len(pins) == 0 {
+			return []chat_domain.PinnedMsg{}, err
+		}
+		msgs, err := svc.repo.FindGroupMsgsByIDs(ctx, pinMsgIDs(pins))
+		if err != nil {
+			return nil, err
+		}
+		if err = svc.viewGroupMsgs(ctx, req.UserID, msgs); err != nil {
+			return nil, err
+		}
+		byID := make(map[int64]*chat_domain.GroupMsg, len(msgs))
+		for i := range msgs {
+			byID[msgs[i].ID] = &msgs[i]
+		}
+		res := make([]chat_domain.PinnedMsg, 0, len(pins))
+		for _, p := range pins {
+			if m, ok := byID[p.MsgID]; ok {
+				res = append(res, chat_domain.PinnedMsg{PinUserID: p.PinUserID, PinTime: p.PinTime, GroupMsg: m})
+			}
+		}
+		return res, nil
+	default:
+		return nil, ErrConvType
+	}
+}
+
+// unpinWithdrawn 消息撤回后取消它的置顶，撤回事件已经推送，客户端据此移除置顶
+func (svc *ChatServiceImpl) unpinWithdrawn(ctx context.Context, convType int8, msgID int64) {
+	if err := svc.repo.UnpinMsgByMsgID(ctx, convType, msgID); err != nil {
+		svc.l.Error("取消撤回消息的置顶失败", logger.Int64("msgID", msgID), logger.Error("err", err))
+	}
+}
+
+// chatPinKey 私聊置顶按双方用户ID排序存储，双方看到的是同一份置顶
+func chatPinKey(a, b int64) (int64, int64) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+func pinMsgIDs(pins []chat_domain.PinRecord) []int64 {
+	ids := make([]int64, 0, len(pins))
+	for _, p := range pins {
+		ids = append(ids, p.MsgID)
+	}
+	return ids
+}
@@ -0,0 +1,88 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/service/push_service"
+)
+
+// AddReaction 给消息添加表情回应
+func (svc *ChatServiceImpl) AddReaction(ctx context.Context, req chat_domain.ReactionRequest) error {
+	return svc.react(ctx, req, false)
+}
+
+// RemoveReaction 取消自己的表情回应
+func (svc *ChatServiceImpl) RemoveReaction(ctx context.Context, req chat_domain.ReactionRequest) error {
+	return svc.react(ctx, req, true)
+}
+
+func (svc *ChatServiceImpl) react(ctx context.Context, req chat_domain.ReactionRequest, remove bool) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	aud, err := svc.findMsgAudience(ctx, req.UserID, req.ConvType, req.MsgID)
+	if err != nil {
+		return err
+	}
+	if aud.Withdrawn {
+		return ErrWithdrawn
+	}
+
+	var changed bool
+	if remove {
+		changed, err = svc.repo.RemoveReaction(ctx, req.ConvType, req.MsgID, req.UserID, req.Emoji)
+	} else {
+		changed, err = svc.repo.AddReaction(ctx, req.ConvType, req.MsgID, req.UserID, req.Emoji)
+	}
+	if err != nil || !changed {
+		return err
+	}
+	for uid, convID := range aud.ConvIDs {
+		svc.push.Push(ctx, []int64{uid}, push_service.Event{
+			Type: push_service.EventReaction,
+			Data: chat_domain.ReactionEvent{
+				ConvType: req.ConvType,
+				ConvID:   convID,
+				MsgID:    req.MsgID,
+				UserID:   req.UserID,
+				Emoji:    req.Emoji,
+				Removed:  remove,
+			},
+		})
+	}
+	return nil
+}
+
+// chatReactions 批量加载私聊消息的表情回应
+func (svc *ChatServiceImpl) chatReactions(ctx context.Context, uid int64, chats []chat_domain.Chat) error {
+	ids := make([]int64, 0, len(chats))
+	for _, c := range chats {
+		ids = append(ids, c.ID)
+	}
+	records, err := svc.repo.FindReactions(ctx, chat_domain.ConvTypeChat, ids)
+	if err != nil {
+		return err
+	}
+	reactions := chat_domain.AggregateReactions(records, uid)
+	for i := range chats {
+		chats[i].Reactions = reactions[chats[i].ID]
+	}
+	return nil
+}
+
+// groupMsgReactions 批量加载群消息的表情回应
+func (svc *ChatServiceImpl) groupMsgReactions(ctx context.Context, uid int64, msgs []chat_domain.GroupMsg) error {
+	ids := make([]int64, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	records, err := svc.repo.FindReactions(ctx, chat_domain.ConvTypeGroup, ids)
+	if err != nil {
+		return err
+	}
+	reactions := chat_domain.AggregateReactions(records, uid)
+	for i := range msgs {
+		msgs[i].Reactions = reactions[msgs[i].ID]
+	}
+	return nil
+}
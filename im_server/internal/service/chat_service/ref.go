@@ -0,0 +1,167 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+)
+
+// resolveChatRef 校验私聊中回复、引用的消息，并由服务端填充被引用消息的快照
+// 被引用的消息必须属于同一个私聊会话，不信任客户端传上来的快照
+func (svc *ChatServiceImpl) resolveChatRef(ctx context.Context, msg *chat_domain.Msg, uid, peerID int64) error {
+	refID := msg.RefMsgID()
+	if refID == 0 {
+		return nil
+	}
+	ref, err := svc.repo.FindChatByID(ctx, refID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrRefMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !inChat(ref, uid, peerID) {
+		return ErrRefMsgNotFound
+	}
+	if ref.MsgType == chat_domain.MsgTypeWithdraw {
+		return ErrRefWithdrawn
+	}
+	msg.SetRefMsg(ref.Msg.Snapshot())
+	return nil
+}
+
+// resolveGroupRef 校验群聊中回复、引用的消息，并由服务端填充被引用消息的快照
+func (svc *ChatServiceImpl) resolveGroupRef(ctx context.Context, msg *chat_domain.Msg, groupID int64) error {
+	refID := msg.RefMsgID()
+	if refID == 0 {
+		return nil
+	}
+	ref, err := svc.repo.FindGroupMsgByID(ctx, refID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrRefMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ref.GroupID != groupID {
+		return ErrRefMsgNotFound
+	}
+	if ref.MsgType == chat_domain.MsgTypeWithdraw {
+		return ErrRefWithdrawn
+	}
+	msg.SetRefMsg(ref.Msg.Snapshot())
+	return nil
+}
+
+// viewChats 按查看者处理返回的私聊消息
+// 1. 撤回消息的原内容只有发送者能看到
+// 2. 被引用的消息之后被撤回了，快照替换为撤回提示
+// 3. 批量填充表情回应
+// 4. 为绑定到消息上的附件生成签名下载地址
+func (svc *ChatServiceImpl) viewChats(ctx context.Context, uid int64, chats []chat_domain.Chat) error {
+	if err := svc.chatReactions(ctx, uid, chats); err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(chats))
+	msgs := make([]*chat_domain.Msg, 0, len(chats))
+	for i := range chats {
+		if chats[i].SendUserID != uid {
+			chats[i].Msg.HideWithdrawOrigin()
+		}
+		ids = append(ids, chats[i].ID)
+		msgs = append(msgs, &chats[i].Msg)
+	}
+	if err := svc.withdrawnChatRefs(ctx, msgs); err != nil {
+		return err
+	}
+	return svc.signFiles(ctx, chat_domain.ConvTypeChat, ids, msgs)
+}
+
+// viewGroupMsgs 按查看者处理返回的群消息，规则同 viewChats，另外填充发送者的群昵称
+func (svc *ChatServiceImpl) viewGroupMsgs(ctx context.Context, uid int64, msgs []chat_domain.GroupMsg) error {
+	if err := svc.groupMsgReactions(ctx, uid, msgs); err != nil {
+		return err
+	}
+	if err := svc.groupMsgNicknames(ctx, msgs); err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(msgs))
+	ms := make([]*chat_domain.Msg, 0, len(msgs))
+	for i := range msgs {
+		if msgs[i].SendUserID != uid {
+			msgs[i].Msg.HideWithdrawOrigin()
+		}
+		ids = append(ids, msgs[i].ID)
+		ms = append(ms, &msgs[i].Msg)
+	}
+	if err := svc.withdrawnGroupRefs(ctx, ms); err != nil {
+		return err
+	}
+	return svc.signFiles(ctx, chat_domain.ConvTypeGroup, ids, ms)
+}
+
+// withdrawnChatRefs 被引用的私聊消息之后被撤回了，快照替换为撤回提示
+func (svc *ChatServiceImpl) withdrawnChatRefs(ctx context.Context, msgs []*chat_domain.Msg) error {
+	refIDs := refMsgIDs(msgs)
+	if len(refIDs) == 0 {
+		return nil
+	}
+	refs, err := svc.repo.FindChatsByIDs(ctx, refIDs)
+	if err != nil {
+		return err
+	}
+	withdrawn := make(map[int64]chat_domain.Msg, len(refs))
+	for _, ref := range refs {
+		if ref.MsgType == chat_domain.MsgTypeWithdraw {
+			withdrawn[ref.ID] = ref.Msg
+		}
+	}
+	replaceWithdrawnRefs(msgs, withdrawn)
+	return nil
+}
+
+// withdrawnGroupRefs 被引用的群消息之后被撤回了，快照替换为撤回提示
+func (svc *ChatServiceImpl) withdrawnGroupRefs(ctx context.Context, msgs []*chat_domain.Msg) error {
+	refIDs := refMsgIDs(msgs)
+	if len(refIDs) == 0 {
+		return nil
+	}
+	refs, err := svc.repo.FindGroupMsgsByIDs(ctx, refIDs)
+	if err != nil {
+		return err
+	}
+	withdrawn := make(map[int64]chat_domain.Msg, len(refs))
+	for _, ref := range refs {
+		if ref.MsgType == chat_domain.MsgTypeWithdraw {
+			withdrawn[ref.ID] = ref.Msg
+		}
+	}
+	replaceWithdrawnRefs(msgs, withdrawn)
+	return nil
+}
+
+func refMsgIDs(msgs []*chat_domain.Msg) []int64 {
+	var ids []int64
+	for _, msg := range msgs {
+		if id := msg.RefMsgID(); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func replaceWithdrawnRefs(msgs []*chat_domain.Msg, withdrawn map[int64]chat_domain.Msg) {
+	for _, msg := range msgs {
+		if ref, ok := withdrawn[msg.RefMsgID()]; ok {
+			ref.HideWithdrawOrigin()
+			msg.SetRefMsg(&ref)
+		}
+	}
+}
+
+// inChat 消息是否属于 uid 和 peerID 之间的私聊
+func inChat(c chat_domain.Chat, uid, peerID int64) bool {
+	return (c.SendUserID == uid && c.RevUserID == peerID) ||
+		(c.SendUserID == peerID && c.RevUserID == uid)
+}
@@ -0,0 +1,153 @@
+package chat_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/search_domain"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// Search 全文搜索自己可见的消息
+// 私聊的删除、清空直接删除了自己的索引；群聊的索引由全体成员共享，删除、清空的消息在这里过滤
+func (svc *ChatServiceImpl) Search(ctx context.Context, req search_domain.SearchRequest) (search_domain.SearchResult, error) {
+	if err := req.Validate(); err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	var groupIDs []int64
+	if req.ConvType != chat_domain.ConvTypeChat {
+		var err error
+		groupIDs, err = svc.groupRepo.FindGroupIDsByUser(ctx, req.UserID)
+		if err != nil {
+			return search_domain.SearchResult{}, err
+		}
+	}
+	docs, err := svc.searchRepo.Search(ctx, req, groupIDs)
+	if err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	res := search_domain.SearchResult{
+		Hits:    []search_domain.SearchHit{},
+		HasMore: len(docs) == req.PageLimit(),
+	}
+	if len(docs) == 0 {
+		return res, nil
+	}
+	res.LastID = docs[len(docs)-1].ID
+
+	var chatIDs, groupMsgIDs, hitGroupIDs []int64
+	for _, d := range docs {
+		if d.ConvType == chat_domain.ConvTypeGroup {
+			groupMsgIDs = append(groupMsgIDs, d.MsgID)
+			hitGroupIDs = append(hitGroupIDs, d.ConvID)
+		} else {
+			chatIDs = append(chatIDs, d.MsgID)
+		}
+	}
+	chats, err := svc.repo.FindChatsByIDs(ctx, chatIDs)
+	if err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	groupMsgs, err := svc.repo.FindGroupMsgsByIDs(ctx, groupMsgIDs)
+	if err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	hidden, err := svc.repo.FindHiddenMsgIDs(ctx, req.UserID, groupMsgIDs)
+	if err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	convs, err := svc.repo.FindUserConversations(ctx, req.UserID, chat_domain.ConvTypeGroup, hitGroupIDs)
+	if err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	if err = svc.viewChats(ctx, req.UserID, chats); err != nil {
+		return search_domain.SearchResult{}, err
+	}
+	if err = svc.viewGroupMsgs(ctx, req.UserID, groupMsgs); err != nil {
+		return search_domain.SearchResult{}, err
+	}
+
+	chatMap := make(map[int64]*chat_domain.Chat, len(chats))
+	for i := range chats {
+		chatMap[chats[i].ID] = &chats[i]
+	}
+	groupMsgMap := make(map[int64]*chat_domain.GroupMsg, len(groupMsgs))
+	for i := range groupMsgs {
+		groupMsgMap[groupMsgs[i].ID] = &groupMsgs[i]
+	}
+	hiddenSet := make(map[int64]bool, len(hidden))
+	for _, id := range hidden {
+		hiddenSet[id] = true
+	}
+	clearCursors := make(map[int64]int64, len(convs))
+	for _, c := range convs {
+		clearCursors[c.ConvID] = c.ClearMsgID
+	}
+	for _, d := range docs {
+		hit := search_domain.SearchHit{
+			ConvType: d.ConvType,
+			ConvID:   d.ConvID,
+		}
+		if d.ConvType == chat_domain.ConvTypeGroup {
+			if hiddenSet[d.MsgID] || d.MsgID <= clearCursors[d.ConvID] {
+				continue
+			}
+			hit.GroupMsg = groupMsgMap[d.MsgID]
+		} else {
+			hit.Chat = chatMap[d.MsgID]
+		}
+		if hit.Chat == nil && hit.GroupMsg == nil {
+			continue
+		}
+		res.Hits = append(res.Hits, hit)
+	}
+	return res, nil
+}
+
+// indexChat 为私聊消息的收发双方各建一条索引，索引失败不影响消息发送
+func (svc *ChatServiceImpl) indexChat(ctx context.Context, c chat_domain.Chat) {
+	text := c.Msg.SearchText()
+	if text == "" {
+		return
+	}
+	doc := search_domain.MsgDoc{
+		ConvType:   chat_domain.ConvTypeChat,
+		MsgID:      c.ID,
+		SendUserID: c.SendUserID,
+		MsgType:    c.MsgType,
+		MsgTime:    c.CreateTime,
+		Text:       text,
+	}
+	sent, received := doc, doc
+	sent.OwnerID, sent.ConvID = c.SendUserID, c.RevUserID
+	received.OwnerID, received.ConvID = c.RevUserID, c.SendUserID
+	if err := svc.searchRepo.Index(ctx, []search_domain.MsgDoc{sent, received}); err != nil {
+		svc.l.Error("私聊消息建立索引失败", logger.Int64("msgID", c.ID), logger.Error("err", err))
+	}
+}
+
+// indexGroupMsg 群消息只建一条索引，由全体成员共享
+func (svc *ChatServiceImpl) indexGroupMsg(ctx context.Context, m chat_domain.GroupMsg) {
+	text := m.Msg.SearchText()
+	if text == "" {
+		return
+	}
+	err := svc.searchRepo.Index(ctx, []search_domain.MsgDoc{{
+		ConvType:   chat_domain.ConvTypeGroup,
+		ConvID:     m.GroupID,
+		MsgID:      m.ID,
+		SendUserID: m.SendUserID,
+		MsgType:    m.MsgType,
+		MsgTime:    m.CreateTime,
+		Text:       text,
+	}})
+	if err != nil {
+		svc.l.Error("群消息建立索引失败", logger.Int64("msgID", m.ID), logger.Error("err", err))
+	}
+}
+
+// unindex 删除消息对所有人的索引，用于撤回
+func (svc *ChatServiceImpl) unindex(ctx context.Context, convType int8, msgID int64) {
+	if err := svc.searchRepo.Remove(ctx, convType, []int64{msgID}); err != nil {
+		svc.l.Error("删除消息索引失败", logger.Int64("msgID", msgID), logger.Error("err", err))
+	}
+}
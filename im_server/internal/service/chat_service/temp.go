@@ -0,0 +1,50 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"time"
+)
+
+// tempSessionLimit 临时会话中每个用户每分钟最多给对方发送的消息数
+const tempSessionLimit = 10
+
+var (
+	ErrTempSessionClosed = errors.New("该群未开启临时会话")
+	ErrTempSessionLeft   = errors.New("对方已不在该群中，临时会话已关闭")
+	ErrTempSessionLimit  = errors.New("临时会话发送过于频繁，请稍后再试")
+)
+
+// checkTempSession 校验 uid 能否通过 groupID 给 peerID 发送临时会话消息
+// 群关闭临时会话或者任意一方退群后，临时会话随之关闭
+func (svc *ChatServiceImpl) checkTempSession(ctx context.Context, uid, peerID, groupID int64) error {
+	g, err := svc.groupRepo.FindByID(ctx, groupID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return ErrTempSessionClosed
+	}
+	if err != nil {
+		return err
+	}
+	if !g.IsTemporarySession {
+		return ErrTempSessionClosed
+	}
+	if _, err = svc.findMember(ctx, groupID, uid); err != nil {
+		return err
+	}
+	_, err = svc.groupRepo.FindMember(ctx, groupID, peerID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return ErrTempSessionLeft
+	}
+	if err != nil {
+		return err
+	}
+	cnt, err := svc.repo.CountTempChats(ctx, uid, peerID, time.Now().Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+	if cnt >= tempSessionLimit {
+		return ErrTempSessionLimit
+	}
+	return nil
+}
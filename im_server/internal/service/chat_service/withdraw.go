@@ -0,0 +1,139 @@
+package chat_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"time"
+)
+
+// withdrawTimeout 发送者可以撤回消息的时间
+const withdrawTimeout = 2 * time.Minute
+
+// adminRecallMessage 管理员撤回其他成员消息时的提示内容
+const adminRecallMessage = "管理员撤回了一条成员消息"
+
+func (svc *ChatServiceImpl) Withdraw(ctx context.Context, req chat_domain.WithdrawRequest) error {
+	switch req.ConvType {
+	case chat_domain.ConvTypeChat:
+		return svc.withdrawChat(ctx, req)
+	case chat_domain.ConvTypeGroup:
+		return svc.withdrawGroupMsg(ctx, req)
+	default:
+		return ErrConvType
+	}
+}
+
+// withdrawChat 撤回私聊消息，只有发送者可以撤回
+func (svc *ChatServiceImpl) withdrawChat(ctx context.Context, req chat_domain.WithdrawRequest) error {
+	c, err := svc.repo.FindChatByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if c.SendUserID != req.UserID {
+		return ErrNoPermission
+	}
+	if c.MsgType == chat_domain.MsgTypeWithdraw {
+		return ErrWithdrawn
+	}
+	if time.Since(c.CreateTime) > withdrawTimeout {
+		return ErrWithdrawExpire
+	}
+
+	content, err := svc.recallMessage(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	c.Msg = c.Msg.Withdrawn(content)
+	c.MsgType = chat_domain.MsgTypeWithdraw
+	c.MsgPreview = c.Msg.Preview()
+	if err = svc.repo.UpdateChatMsg(ctx, c); err != nil {
+		return err
+	}
+	svc.unindex(ctx, chat_domain.ConvTypeChat, c.ID)
+	svc.unpinWithdrawn(ctx, chat_domain.ConvTypeChat, c.ID)
+
+	c.Msg.HideWithdrawOrigin()
+	svc.push.Push(ctx, []int64{c.SendUserID, c.RevUserID}, push_service.Event{
+		Type: push_service.EventWithdraw,
+		Data: chat_domain.MsgEvent{
+			ConvType: chat_domain.ConvTypeChat,
+			Chat:     &c,
+		},
+	})
+	return nil
+}
+
+// withdrawGroupMsg 撤回群消息，发送者在限定时间内可以撤回，群主和管理员可以随时撤回成员的消息
+func (svc *ChatServiceImpl) withdrawGroupMsg(ctx context.Context, req chat_domain.WithdrawRequest) error {
+	m, err := svc.repo.FindGroupMsgByID(ctx, req.MsgID)
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return ErrMsgNotFound
+	}
+	if err != nil {
+		return err
+	}
+	member, err := svc.findMember(ctx, m.GroupID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if m.MsgType == chat_domain.MsgTypeWithdraw {
+		return ErrWithdrawn
+	}
+
+	var content string
+	if m.SendUserID == req.UserID {
+		if time.Since(m.CreateTime) > withdrawTimeout {
+			return ErrWithdrawExpire
+		}
+		content, err = svc.recallMessage(ctx, req.UserID)
+		if err != nil {
+			return err
+		}
+	} else {
+		if !member.IsAdmin() {
+			return ErrNoPermission
+		}
+		content = adminRecallMessage
+	}
+
+	m.Msg = m.Msg.Withdrawn(content)
+	m.MsgType = chat_domain.MsgTypeWithdraw
+	m.MsgPreview = m.Msg.Preview()
+	if err = svc.repo.UpdateGroupMsg(ctx, m); err != nil {
+		return err
+	}
+	svc.unindex(ctx, chat_domain.ConvTypeGroup, m.ID)
+	svc.unpinWithdrawn(ctx, chat_domain.ConvTypeGroup, m.ID)
+
+	members, err := svc.groupRepo.FindMembers(ctx, m.GroupID)
+	if err != nil {
+		return err
+	}
+	m.Msg.HideWithdrawOrigin()
+	svc.push.Push(ctx, memberIDs(members), push_service.Event{
+		Type: push_service.EventWithdraw,
+		Data: chat_domain.MsgEvent{
+			ConvType: chat_domain.ConvTypeGroup,
+			GroupMsg: &m,
+		},
+	})
+	return nil
+}
+
+// recallMessage 用户配置的撤回提示内容
+func (svc *ChatServiceImpl) recallMessage(ctx context.Context, uid int64) (string, error) {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	if u.UserConf.RecallMessage != nil && *u.UserConf.RecallMessage != "" {
+		return *u.UserConf.RecallMessage, nil
+	}
+	return chat_domain.DefaultRecallMessage, nil
+}
@@ -0,0 +1,210 @@
+package file_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/domain/user_domain"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/pkg/imaging"
+	"github.com/ink-yht/im/pkg/storage"
+	"image"
+	"io"
+	"mime/multipart"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// generatedDir 生成的默认头像保存的目录，在公开的 avatar 目录下，不写文件记录
+	generatedDir = "avatar/generated"
+	// gridMembers 群头像最多拼接的成员数
+	gridMembers = 9
+)
+
+var (
+	ErrGroupNotFound = errors.New("群不存在")
+	ErrNotGroupAdmin = errors.New("只有群主和管理员可以修改群头像")
+)
+
+// GroupAvatar 上传群头像，只有群主和管理员可以修改，处理方式和用户头像相同
+// 上传过的群头像不再自动生成，之前上传的群头像由后台任务清理
+func (svc FileServiceImpl) GroupAvatar(ctx context.Context, req file_domain.GroupAvatarRequest, image *multipart.FileHeader) (file_domain.Avatar, error) {
+	g, err := svc.groupRepo.FindByID(ctx, req.GroupID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return file_domain.Avatar{}, ErrGroupNotFound
+	}
+	if err != nil {
+		return file_domain.Avatar{}, err
+	}
+	member, err := svc.groupRepo.FindMember(ctx, g.ID, req.UserID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) || (err == nil && !member.IsAdmin()) {
+		return file_domain.Avatar{}, ErrNotGroupAdmin
+	}
+	if err != nil {
+		return file_domain.Avatar{}, err
+	}
+
+	c := loadUploadConfig()
+	if image.Size > int64(c.Size)*1024*1024 {
+		return file_domain.Avatar{}, ErrImageSize
+	}
+	if err = svc.checkQuota(ctx, c, req.UserID, image.Size); err != nil {
+		return file_domain.Avatar{}, err
+	}
+	f, err := svc.store(ctx, c, storeRequest{
+		UserID:  req.UserID,
+		GroupID: g.ID,
+		Kind:    file_domain.KindGroupAvatar,
+		File:    image,
+		Mimes:   avatarMimes,
+	})
+	if errors.Is(err, ErrAttachmentMime) {
+		return file_domain.Avatar{}, ErrImageType
+	}
+	if err != nil {
+		return file_domain.Avatar{}, err
+	}
+
+	res := svc.avatarURLs(c, f)
+	if err = svc.repo.GroupAvatar(ctx, g.ID, res.URL); err != nil {
+		return file_domain.Avatar{}, err
+	}
+	if err = svc.repo.ReleaseGroupAvatars(ctx, g.ID, f.ID); err != nil {
+		return file_domain.Avatar{}, err
+	}
+	svc.removeGenerated(ctx, groupAvatarDir(g.ID), g.Avatar)
+	return res, nil
+}
+
+// GenerateUserAvatar 根据用户ID生成色块头像并设置为用户头像
+// 同一个用户ID总是生成同样的图片，保存在固定的路径下，已经生成过时直接使用
+func (svc FileServiceImpl) GenerateUserAvatar(ctx context.Context, uid int64) error {
+	key := fmt.Sprintf("%s/user/%d.png", generatedDir, uid)
+	err := svc.putGenerated(ctx, key, func() image.Image {
+		return imaging.Identicon([]byte(strconv.FormatInt(uid, 10)), loadUploadConfig().Images.avatarSizes()[0])
+	})
+	if err != nil {
+		return err
+	}
+	return svc.repo.Avatar(ctx, user_domain.User{
+		ID:     uid,
+		Avatar: svc.storage.URL(key),
+	})
+}
+
+// RefreshGroupAvatar 群成员变化后重新生成群头像，上传过群头像的群不处理
+// 群头像由前 9 个成员（群主、管理员在前）的头像拼成九宫格，没有上传头像或读取失败的成员使用色块头像
+// 对象路径由成员和头像地址计算得到，成员和头像都没有变化时直接使用之前生成的图片，替换后删除之前生成的图片
+func (svc FileServiceImpl) RefreshGroupAvatar(ctx context.Context, groupID int64) error {
+	g, err := svc.groupRepo.FindByID(ctx, groupID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return ErrGroupNotFound
+	}
+	if err != nil {
+		return err
+	}
+	dir := groupAvatarDir(g.ID)
+	if g.Avatar != "" && !svc.isGenerated(dir, g.Avatar) {
+		return nil
+	}
+
+	members, _, err := svc.groupRepo.FindMemberPage(ctx, g.ID, "", 0, gridMembers)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	for _, m := range members {
+		_, _ = fmt.Fprintf(h, "%d:%s\n", m.UserID, m.Avatar)
+	}
+	key := dir + "/" + hex.EncodeToString(h.Sum(nil)) + ".png"
+	url := svc.storage.URL(key)
+	if url == g.Avatar {
+		return nil
+	}
+
+	err = svc.putGenerated(ctx, key, func() image.Image {
+		size := loadUploadConfig().Images.avatarSizes()[0]
+		imgs := make([]image.Image, 0, len(members))
+		for _, m := range members {
+			img, err := svc.avatarImage(ctx, m.Avatar)
+			if err != nil {
+				img = imaging.Identicon([]byte(strconv.FormatInt(m.UserID, 10)), size)
+			}
+			imgs = append(imgs, img)
+		}
+		return imaging.Grid(imgs, size)
+	})
+	if err != nil {
+		return err
+	}
+	if err = svc.repo.GroupAvatar(ctx, g.ID, url); err != nil {
+		return err
+	}
+	svc.removeGenerated(ctx, dir, g.Avatar)
+	return nil
+}
+
+// putGenerated 保存生成的头像，对象已经存在时不再生成
+func (svc FileServiceImpl) putGenerated(ctx context.Context, key string, gen func() image.Image) error {
+	_, err := svc.storage.Stat(ctx, key)
+	if err == nil || !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+	var buf bytes.Buffer
+	if err = imaging.Encode(&buf, gen(), imaging.FormatPNG, 0); err != nil {
+		return err
+	}
+	return svc.storage.Put(ctx, key, &buf, int64(buf.Len()), "image/png")
+}
+
+// avatarImage 读取保存在存储后端中的头像，不是本服务保存的头像地址返回 ErrFileNotFound
+// 用户资料中的头像地址可以由用户修改，转换得到的对象路径必须仍然在 avatar 目录下，避免读取私有附件
+func (svc FileServiceImpl) avatarImage(ctx context.Context, url string) (image.Image, error) {
+	prefix := svc.storage.URL("avatar") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return nil, ErrFileNotFound
+	}
+	key := path.Clean("avatar/" + strings.TrimPrefix(url, prefix))
+	if !strings.HasPrefix(key, "avatar/") {
+		return nil, ErrFileNotFound
+	}
+	obj, err := svc.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := imaging.Decode(data)
+	return img, err
+}
+
+// isGenerated 头像地址是否为 dir 目录下生成的头像
+func (svc FileServiceImpl) isGenerated(dir, url string) bool {
+	return strings.HasPrefix(url, svc.storage.URL(dir)+"/")
+}
+
+// removeGenerated 删除 dir 目录下之前生成的头像，删除失败只会留下不再使用的对象，不影响结果
+func (svc FileServiceImpl) removeGenerated(ctx context.Context, dir, url string) {
+	if !svc.isGenerated(dir, url) {
+		return
+	}
+	key := path.Clean(dir + "/" + strings.TrimPrefix(url, svc.storage.URL(dir)+"/"))
+	if !strings.HasPrefix(key, dir+"/") {
+		return
+	}
+	_ = svc.storage.Delete(ctx, key)
+}
+
+// groupAvatarDir 群头像生成的目录
+func groupAvatarDir(groupID int64) string {
+	return fmt.Sprintf("%s/group/%d", generatedDir, groupID)
+}
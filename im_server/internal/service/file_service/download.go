@@ -0,0 +1,62 @@
+package file_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/pkg/storage"
+)
+
+var (
+	ErrDownloadSign = errors.New("下载地址无效或已过期")
+	ErrFileNotFound = errors.New("文件不存在")
+)
+
+// Download 校验签名后打开附件，调用方负责关闭 Content
+func (svc FileServiceImpl) Download(ctx context.Context, req file_domain.DownloadRequest) (file_domain.Download, error) {
+	if !svc.signer.Verify(req.FileID, req.Expires, req.Thumb, req.Sign) {
+		return file_domain.Download{}, ErrDownloadSign
+	}
+	as, err := svc.repo.FindAttachmentsByIDs(ctx, []int64{req.FileID})
+	if err != nil {
+		return file_domain.Download{}, err
+	}
+	if len(as) == 0 {
+		return file_domain.Download{}, ErrFileNotFound
+	}
+	f, err := svc.repo.FindFileByID(ctx, as[0].FileID)
+	if errors.Is(err, file_repo.ErrRecordNotFound) {
+		return file_domain.Download{}, ErrFileNotFound
+	}
+	if err != nil {
+		return file_domain.Download{}, err
+	}
+	// 等待扫描的文件可以下载，已被隔离的文件包括缩略图都不能下载
+	if f.ScanStatus == file_domain.ScanBlocked {
+		return file_domain.Download{}, ErrFileBlocked
+	}
+	// 尺寸本身就很小的图片没有缩略图，下载原图；没有封面的视频不能用原文件代替
+	a, key := as[0], f.Path
+	if req.Thumb {
+		switch {
+		case f.Thumb != "":
+			key = f.Thumb
+			a.MimeType = thumbMime(f.MimeType)
+		case f.Kind != file_domain.KindImage:
+			return file_domain.Download{}, ErrFileNotFound
+		}
+	}
+	obj, err := svc.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) {
+		return file_domain.Download{}, ErrFileNotFound
+	}
+	if err != nil {
+		return file_domain.Download{}, err
+	}
+	return file_domain.Download{
+		Attachment: a,
+		ModTime:    obj.Info().ModTime,
+		Content:    obj,
+	}, nil
+}
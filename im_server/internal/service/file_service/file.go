@@ -3,96 +3,126 @@ package file_service
 import (
 	"context"
 	"errors"
-	"fmt"
+	"github.com/ink-yht/im/internal/domain/file_domain"
 	"github.com/ink-yht/im/internal/domain/user_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
 	"github.com/ink-yht/im/internal/repository/file_repo"
-	"github.com/spf13/viper"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/pkg/scanner"
+	"github.com/ink-yht/im/pkg/storage"
 	"io"
-	"log"
 	"mime/multipart"
-	"os"
-	"path/filepath"
 )
 
 var (
 	ErrSavePicture = errors.New("保存图片失败")
 	ErrImageSize   = errors.New("图片大小超过设定大小，当设定大小为: 2Mb ")
+	ErrImageType   = errors.New("不支持的图片格式")
 )
 
+// avatarMimes 头像允许的图片格式
+var avatarMimes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
 // FileService 定义了用户服务的接口
 type FileService interface {
-	Avatar(ctx context.Context, id int64, imageType string, file *multipart.FileHeader) error
+	Avatar(ctx context.Context, id int64, imageType string, file *multipart.FileHeader) (file_domain.Avatar, error)
+	GroupAvatar(ctx context.Context, req file_domain.GroupAvatarRequest, file *multipart.FileHeader) (file_domain.Avatar, error)
+	GenerateUserAvatar(ctx context.Context, uid int64) error
+	RefreshGroupAvatar(ctx context.Context, groupID int64) error
+	Upload(ctx context.Context, req file_domain.UploadRequest, file *multipart.FileHeader) (file_domain.Attachment, error)
+	Download(ctx context.Context, req file_domain.DownloadRequest) (file_domain.Download, error)
+
+	InitUpload(ctx context.Context, req file_domain.UploadInitRequest) (file_domain.UploadSession, error)
+	UploadChunk(ctx context.Context, req file_domain.UploadChunkRequest, body io.Reader) (file_domain.UploadSession, error)
+	UploadStatus(ctx context.Context, uid int64, uploadID string) (file_domain.UploadSession, error)
+	CompleteUpload(ctx context.Context, uid int64, uploadID string) (file_domain.Attachment, error)
+	PurgeExpiredUploads(ctx context.Context) (int, error)
+
+	Usage(ctx context.Context, uid int64) (file_domain.Usage, error)
+	CollectFiles(ctx context.Context) (int, error)
+	ScanFiles(ctx context.Context) (int, error)
 }
 
 // FileServiceImpl 实现了 UserService 接口
+// scanner 为 nil 表示不扫描上传的文件
 type FileServiceImpl struct {
-	repo file_repo.FileRepository
+	repo      file_repo.FileRepository
+	groupRepo group_repo.GroupRepository
+	chatRepo  chat_repo.ChatRepository
+	storage   storage.Storage
+	signer    URLSigner
+	scanner   scanner.Scanner
 }
 
-func NewFileService(repo file_repo.FileRepository) FileService {
+func NewFileService(repo file_repo.FileRepository, groupRepo group_repo.GroupRepository, chatRepo chat_repo.ChatRepository,
+	storage storage.Storage, signer URLSigner, scanner scanner.Scanner) FileService {
 	return &FileServiceImpl{
-		repo: repo,
+		repo:      repo,
+		groupRepo: groupRepo,
+		chatRepo:  chatRepo,
+		storage:   storage,
+		signer:    signer,
+		scanner:   scanner,
 	}
 }
 
-func (svc FileServiceImpl) Avatar(ctx context.Context, id int64, imageType string, image *multipart.FileHeader) error {
-	type Config struct {
-		Size int    `yaml:"size"`
-		Path string `yaml:"path"`
-	}
-	var c Config
-	err := viper.UnmarshalKey("uploads", &c)
-	if err != nil {
-		panic(fmt.Errorf("初始化配置失败: %s \n", err))
-	}
-
-	// 判断图片路径是否存在，没有就创建
-	basePath := c.Path + imageType
-	_, err = os.ReadDir(basePath)
-	if err != nil {
-		err = os.MkdirAll(basePath, os.ModePerm)
-		if err != nil {
-			return errors.New("图片路径创建失败")
-		}
-	}
+func (svc FileServiceImpl) Avatar(ctx context.Context, id int64, imageType string, image *multipart.FileHeader) (file_domain.Avatar, error) {
+	c := loadUploadConfig()
 
 	// 判断大小
 	size := float64(image.Size) / float64(1024*1024)
 	if size >= float64(c.Size) {
-		return ErrImageSize
+		return file_domain.Avatar{}, ErrImageSize
 	}
-
-	// 保存文件
-	filePath := filepath.Join(basePath, image.Filename)
-	out, err := os.Create(filePath)
-	if err != nil {
-		log.Printf("创建文件失败: %v", err)
-		return errors.New("创建文件失败")
+	if err := svc.checkQuota(ctx, c, id, image.Size); err != nil {
+		return file_domain.Avatar{}, err
 	}
-	defer out.Close()
 
-	in, err := image.Open()
-	if err != nil {
-		log.Printf("打开文件失败: %v", err)
-		return errors.New("打开文件失败")
+	// 保存文件，保存路径由文件内容生成，不使用客户端上传的文件名
+	f, err := svc.store(ctx, c, storeRequest{
+		UserID: id,
+		Kind:   imageType,
+		File:   image,
+		Mimes:  avatarMimes,
+	})
+	if errors.Is(err, ErrAttachmentMime) {
+		return file_domain.Avatar{}, ErrImageType
 	}
-	defer in.Close()
-
-	_, err = io.Copy(out, in)
 	if err != nil {
-		log.Printf("保存图片失败: %v", err)
-		return ErrSavePicture
+		return file_domain.Avatar{}, err
 	}
 
+	res := svc.avatarURLs(c, f)
+
 	// 更新用户头像
-	url := "/" + filepath.ToSlash(filePath)
 	data := user_domain.User{
 		ID:     id,
-		Avatar: url,
+		Avatar: res.URL,
 	}
 	err = svc.repo.Avatar(ctx, data)
 	if err != nil {
-		return err
+		return file_domain.Avatar{}, err
+	}
+	// 之前的头像不再被引用，由后台任务清理
+	err = svc.repo.ReleaseAvatars(ctx, id, f.ID)
+	if err != nil {
+		return file_domain.Avatar{}, err
+	}
+	return res, nil
+}
+
+// avatarURLs 头像各个尺寸的公开地址，其他尺寸保存在默认头像的路径加上边长后缀的位置
+func (svc FileServiceImpl) avatarURLs(c uploadConfig, f file_domain.File) file_domain.Avatar {
+	res := file_domain.Avatar{
+		URL:   svc.storage.URL(f.Path),
+		Sizes: make(map[int]string),
+	}
+	for i, s := range c.Images.avatarSizes() {
+		if i == 0 {
+			res.Sizes[s] = res.URL
+			continue
+		}
+		res.Sizes[s] = svc.storage.URL(f.Path + avatarSuffix(s))
 	}
-	return nil
+	return res
 }
@@ -0,0 +1,204 @@
+package file_service
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/pkg/imaging"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultThumbSize    = 360
+	defaultImageQuality = 85
+	defaultMaxPixels    = 4000 // 万像素
+
+	// thumbSuffix 聊天图片缩略图的对象路径后缀
+	thumbSuffix = "_thumb"
+)
+
+var defaultAvatarSizes = []int{512, 160, 64}
+
+var (
+	ErrImageDecode = errors.New("图片已损坏或格式不支持")
+	ErrImagePixels = errors.New("图片尺寸过大")
+)
+
+// imageConfig 图片处理的配置
+type imageConfig struct {
+	ThumbSize   int   `yaml:"thumbSize"`   // 缩略图长边（像素）
+	AvatarSizes []int `yaml:"avatarSizes"` // 头像边长（像素），第一个为默认头像
+	Quality     int   `yaml:"quality"`     // 重新编码 JPEG 的质量
+	MaxPixels   int   `yaml:"maxPixels"`   // 单张图片最多的像素数（万），避免解码超大图片耗尽内存
+}
+
+func (c imageConfig) thumbSize() int {
+	if c.ThumbSize <= 0 {
+		return defaultThumbSize
+	}
+	return c.ThumbSize
+}
+
+func (c imageConfig) avatarSizes() []int {
+	if len(c.AvatarSizes) == 0 {
+		return defaultAvatarSizes
+	}
+	return c.AvatarSizes
+}
+
+func (c imageConfig) quality() int {
+	if c.Quality <= 0 || c.Quality > 100 {
+		return defaultImageQuality
+	}
+	return c.Quality
+}
+
+func (c imageConfig) maxPixels() int {
+	if c.MaxPixels <= 0 {
+		return defaultMaxPixels * 10000
+	}
+	return c.MaxPixels * 10000
+}
+
+// imageVariant 由原图生成的其他尺寸，保存在原图的对象路径加上 Suffix 的位置
+type imageVariant struct {
+	Suffix   string
+	Data     []byte
+	MimeType string
+}
+
+// avatarSuffix 头像其他尺寸的对象路径后缀
+func avatarSuffix(size int) string {
+	return "_" + strconv.Itoa(size)
+}
+
+// isAvatar 用户头像和群头像的处理方式相同
+func isAvatar(kind string) bool {
+	return kind == file_domain.KindAvatar || kind == file_domain.KindGroupAvatar
+}
+
+// thumbMime 缩略图的格式，JPEG 图片的缩略图和视频封面为 JPEG，其他图片格式可能带有透明通道，缩略图为 PNG
+func thumbMime(mimeType string) string {
+	if mimeType == "image/jpeg" || !strings.HasPrefix(mimeType, "image/") {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+func mimeFormat(mimeType string) string {
+	if mimeType == "image/jpeg" {
+		return imaging.FormatJPEG
+	}
+	return imaging.FormatPNG
+}
+
+// processImage 处理上传的头像和聊天图片
+// 按 EXIF 中的方向旋转后重新编码，去掉 EXIF（包括拍摄位置）等元数据；头像从中间裁剪为正方形并生成多个尺寸，聊天图片生成缩略图
+// 处理后的内容写入新的临时文件，返回更新了哈希、大小、格式和尺寸的文件记录，调用方负责删除新的临时文件
+func processImage(c imageConfig, f file_domain.File, tmpPath string) (file_domain.File, string, []imageVariant, error) {
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return file_domain.File{}, "", nil, err
+	}
+	// 先只读取尺寸，拒绝解码后会占用大量内存的图片
+	cfg, format, err := imaging.Config(data)
+	if err != nil {
+		return file_domain.File{}, "", nil, ErrImageDecode
+	}
+	if cfg.Width*cfg.Height > c.maxPixels() {
+		return file_domain.File{}, "", nil, ErrImagePixels
+	}
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return file_domain.File{}, "", nil, ErrImageDecode
+	}
+
+	var variants []imageVariant
+	if isAvatar(f.Kind) {
+		data, variants, err = avatarImages(c, &f, img)
+	} else {
+		data, variants, err = chatImages(c, &f, img, format, data)
+	}
+	if err != nil {
+		return file_domain.File{}, "", nil, err
+	}
+
+	tmp, hash, err := writeTemp(tmpPath, data)
+	if err != nil {
+		return file_domain.File{}, "", nil, err
+	}
+	f.Hash = hash
+	f.Size = int64(len(data))
+	return f, tmp, variants, nil
+}
+
+// avatarImages 头像裁剪为正方形，第一个尺寸作为默认头像，其他尺寸作为附加的对象保存
+// 带透明通道的格式统一转为 PNG，动图只保留第一帧
+func avatarImages(c imageConfig, f *file_domain.File, img image.Image) ([]byte, []imageVariant, error) {
+	f.MimeType = thumbMime(f.MimeType)
+	format := mimeFormat(f.MimeType)
+	sizes := c.avatarSizes()
+
+	var main []byte
+	var variants []imageVariant
+	for i, size := range sizes {
+		sq := imaging.Square(img, size)
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, sq, format, c.quality()); err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			main = buf.Bytes()
+			f.Width, f.Height = sq.Bounds().Dx(), sq.Bounds().Dy()
+			continue
+		}
+		variants = append(variants, imageVariant{
+			Suffix:   avatarSuffix(size),
+			Data:     buf.Bytes(),
+			MimeType: f.MimeType,
+		})
+	}
+	return main, variants, nil
+}
+
+// chatImages 聊天图片保持原来的格式，JPEG 和 PNG 重新编码，WebP 去掉元数据块，GIF 本身不带 EXIF，原样保存以保留动画
+// 长边超过缩略图尺寸时生成缩略图
+func chatImages(c imageConfig, f *file_domain.File, img image.Image, format string, data []byte) ([]byte, []imageVariant, error) {
+	b := img.Bounds()
+	f.Width, f.Height = b.Dx(), b.Dy()
+
+	var out []byte
+	switch format {
+	case imaging.FormatJPEG, imaging.FormatPNG:
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, img, format, c.quality()); err != nil {
+			return nil, nil, err
+		}
+		out = buf.Bytes()
+	case imaging.FormatWebP:
+		var err error
+		if out, err = imaging.StripWebP(data); err != nil {
+			return nil, nil, ErrImageDecode
+		}
+	default:
+		out = data
+	}
+
+	size := c.thumbSize()
+	if f.Width <= size && f.Height <= size {
+		return out, nil, nil
+	}
+	mimeType := thumbMime(f.MimeType)
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, imaging.Fit(img, size), mimeFormat(mimeType), c.quality()); err != nil {
+		return nil, nil, err
+	}
+	return out, []imageVariant{{
+		Suffix:   thumbSuffix,
+		Data:     buf.Bytes(),
+		MimeType: mimeType,
+	}}, nil
+}
@@ -0,0 +1,130 @@
+package file_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"time"
+)
+
+const (
+	defaultGraceHours = 24
+	collectBatch      = 100
+)
+
+var ErrQuotaExceeded = errors.New("存储空间不足，请清理后再上传")
+
+// lifecycleConfig 文件清理的配置
+type lifecycleConfig struct {
+	GraceHours    int `yaml:"graceHours"`    // 上传后多久还没有被引用就删除（小时）
+	RetentionDays int `yaml:"retentionDays"` // 消息附件的保留天数，为 0 表示永久保留
+}
+
+func (c lifecycleConfig) grace() time.Duration {
+	if c.GraceHours <= 0 {
+		return defaultGraceHours * time.Hour
+	}
+	return time.Duration(c.GraceHours) * time.Hour
+}
+
+func (c uploadConfig) quota() int64 {
+	return int64(c.Quota) * 1024 * 1024
+}
+
+// Usage 查询用户已经使用的存储空间和配额
+func (svc FileServiceImpl) Usage(ctx context.Context, uid int64) (file_domain.Usage, error) {
+	u, err := svc.repo.FileUsage(ctx, uid)
+	if err != nil {
+		return file_domain.Usage{}, err
+	}
+	u.Quota = loadUploadConfig().quota()
+	return u, nil
+}
+
+// checkQuota 上传 size 字节之后是否超过用户的存储配额
+func (svc FileServiceImpl) checkQuota(ctx context.Context, c uploadConfig, uid, size int64) error {
+	if c.quota() <= 0 {
+		return nil
+	}
+	u, err := svc.repo.FileUsage(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Used+size > c.quota() {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// CollectFiles 清理不再需要的文件，返回删除的文件记录数
+// 先删除绑定到消息超过保留期限的附件，释放它们对文件记录的引用；
+// 再删除超过宽限期还没有被引用的文件记录，包括上传后没有发送的附件和被替换的头像，
+// 没有其他记录使用同样的内容时删除存储后端中的文件和缩略图等其他尺寸
+func (svc FileServiceImpl) CollectFiles(ctx context.Context) (int, error) {
+	c := loadUploadConfig()
+	if c.Lifecycle.RetentionDays > 0 {
+		before := time.Now().AddDate(0, 0, -c.Lifecycle.RetentionDays)
+		for {
+			as, err := svc.repo.FindExpiredAttachments(ctx, before, collectBatch)
+			if err != nil {
+				return 0, err
+			}
+			if err = svc.repo.DeleteAttachments(ctx, as); err != nil {
+				return 0, err
+			}
+			if len(as) < collectBatch {
+				break
+			}
+		}
+	}
+
+	total := 0
+	before := time.Now().Add(-c.Lifecycle.grace())
+	var lastID int64
+	for {
+		fs, err := svc.repo.FindUnreferencedFiles(ctx, before, lastID, collectBatch)
+		if err != nil {
+			return total, err
+		}
+		for _, f := range fs {
+			deleted, err := svc.deleteFile(ctx, c, f)
+			if err != nil {
+				return total, err
+			}
+			if deleted {
+				total++
+			}
+		}
+		// 正在发送的附件不会被删除，按ID向后翻页，避免重复查到
+		if len(fs) < collectBatch {
+			return total, nil
+		}
+		lastID = fs[len(fs)-1].ID
+	}
+}
+
+// deleteFile 删除文件记录，最后一条使用同样内容的记录删除时一起删除存储后端中的所有对象
+func (svc FileServiceImpl) deleteFile(ctx context.Context, c uploadConfig, f file_domain.File) (bool, error) {
+	return svc.repo.DeleteFile(ctx, f, func() error {
+		for _, key := range objectKeys(c, f) {
+			if err := svc.storage.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// objectKeys 文件在存储后端中的所有对象，包括缩略图、视频封面和头像的其他尺寸
+func objectKeys(c uploadConfig, f file_domain.File) []string {
+	keys := []string{f.Path}
+	if f.Thumb != "" {
+		keys = append(keys, f.Thumb)
+	}
+	if isAvatar(f.Kind) {
+		for _, size := range c.Images.avatarSizes()[1:] {
+			keys = append(keys, f.Path+avatarSuffix(size))
+		}
+	}
+	return keys
+}
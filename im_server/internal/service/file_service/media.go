@@ -0,0 +1,97 @@
+package file_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/pkg/media"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// defaultPosterTimeout 生成视频封面默认的超时时间
+const defaultPosterTimeout = 10 * time.Second
+
+var (
+	ErrMediaDecode   = errors.New("无法识别音视频文件的时长")
+	ErrMediaDuration = errors.New("音视频时长超过限制")
+)
+
+// mediaConfig 音视频处理的配置
+type mediaConfig struct {
+	FFmpeg        string `yaml:"ffmpeg"`        // ffmpeg 可执行文件路径，为空时不生成视频封面
+	PosterTimeout int    `yaml:"posterTimeout"` // 生成封面的超时时间（秒）
+}
+
+func (c mediaConfig) posterTimeout() time.Duration {
+	if c.PosterTimeout <= 0 {
+		return defaultPosterTimeout
+	}
+	return time.Duration(c.PosterTimeout) * time.Second
+}
+
+// processMedia 解析上传的音视频文件，时长和画面尺寸以服务端读取的为准，时长超过附件类型的限制时拒绝上传
+// 视频在配置了 ffmpeg 时截取一帧作为封面，和图片缩略图一样保存在文件的对象路径加上 thumbSuffix 的位置
+func processMedia(ctx context.Context, c uploadConfig, f file_domain.File, tmpPath string) (file_domain.File, []imageVariant, error) {
+	in, err := os.Open(tmpPath)
+	if err != nil {
+		return file_domain.File{}, nil, err
+	}
+	info, err := media.Probe(in, f.Size, f.MimeType)
+	_ = in.Close()
+	if err != nil {
+		return file_domain.File{}, nil, ErrMediaDecode
+	}
+
+	f.Duration = int(math.Ceil(info.Duration.Seconds()))
+	if limit := c.Attachments[f.Kind].Duration; limit > 0 && f.Duration > limit {
+		return file_domain.File{}, nil, ErrMediaDuration
+	}
+	if f.Kind != file_domain.KindVideo {
+		return f, nil, nil
+	}
+
+	f.Width, f.Height = info.Width, info.Height
+	poster := c.Media.poster(ctx, tmpPath, c.Images.thumbSize(), info.Duration)
+	if len(poster) == 0 {
+		return f, nil, nil
+	}
+	return f, []imageVariant{{
+		Suffix:   thumbSuffix,
+		Data:     poster,
+		MimeType: "image/jpeg",
+	}}, nil
+}
+
+// poster 使用 ffmpeg 截取视频的一帧作为 JPEG 封面，长边缩小到 size
+// 封面只是为了展示，ffmpeg 不可用或者无法解码时返回 nil，不影响上传
+func (c mediaConfig) poster(ctx context.Context, name string, size int, d time.Duration) []byte {
+	if c.FFmpeg == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.posterTimeout())
+	defer cancel()
+
+	// 第一帧经常是黑屏，足够长的视频从第 1 秒截取
+	ss := "0"
+	if d >= 2*time.Second {
+		ss = "1"
+	}
+	cmd := exec.CommandContext(ctx, c.FFmpeg,
+		"-v", "error",
+		"-ss", ss,
+		"-i", name,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=w='min(iw,%d)':h='min(ih,%d)':force_original_aspect_ratio=decrease", size, size),
+		"-f", "image2pipe",
+		"-c:v", "mjpeg",
+		"pipe:1")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,348 @@
+package file_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/file_repo"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultChunkSize   = 4 // MB
+	defaultUploadHours = 24
+	purgeUploadBatch   = 100
+)
+
+var (
+	ErrUploadNotFound     = errors.New("上传任务不存在或已过期")
+	ErrUploadOffset       = errors.New("分片位置和已上传的大小不一致")
+	ErrUploadChunkSize    = errors.New("分片大小超过限制")
+	ErrUploadIncomplete   = errors.New("文件还没有上传完")
+	ErrUploadHashMismatch = errors.New("文件哈希校验失败，请重新上传")
+	ErrUploadCompleting   = errors.New("上传任务正在完成，请稍后再试")
+)
+
+func (c resumableConfig) chunkSize() int64 {
+	if c.ChunkSize <= 0 {
+		return defaultChunkSize * 1024 * 1024
+	}
+	return int64(c.ChunkSize) * 1024 * 1024
+}
+
+func (c resumableConfig) ttl() time.Duration {
+	if c.ExpireHours <= 0 {
+		return defaultUploadHours * time.Hour
+	}
+	return time.Duration(c.ExpireHours) * time.Hour
+}
+
+// chunkPath 断点续传任务已上传内容的临时文件
+func chunkPath(c uploadConfig, uploadID string) string {
+	return filepath.Join(c.Path, "tmp", "uploads", uploadID)
+}
+
+// InitUpload 创建断点续传任务，大小和类型限制与普通上传一致
+func (svc FileServiceImpl) InitUpload(ctx context.Context, req file_domain.UploadInitRequest) (file_domain.UploadSession, error) {
+	if err := req.Validate(); err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	c := loadUploadConfig()
+	kc, ok := c.Attachments[req.Kind]
+	if !ok {
+		return file_domain.UploadSession{}, file_domain.ErrKindNotSupported
+	}
+	if req.Size > int64(kc.Size)*1024*1024 {
+		return file_domain.UploadSession{}, ErrAttachmentSize
+	}
+	if err := svc.checkQuota(ctx, c, req.UserID, req.Size); err != nil {
+		return file_domain.UploadSession{}, err
+	}
+
+	uploadID, err := newUploadID()
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	p := chunkPath(c, uploadID)
+	if err = os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	_ = f.Close()
+
+	s := file_domain.UploadSession{
+		UploadID:   uploadID,
+		UserID:     req.UserID,
+		Kind:       req.Kind,
+		Name:       safeTitle(req.Name),
+		Size:       req.Size,
+		Hash:       strings.ToLower(req.Hash),
+		ChunkSize:  c.Resumable.chunkSize(),
+		ExpireTime: time.Now().Add(c.Resumable.ttl()),
+	}
+	if err = svc.repo.CreateUploadSession(ctx, s); err != nil {
+		_ = os.Remove(p)
+		return file_domain.UploadSession{}, err
+	}
+	return s, nil
+}
+
+// UploadChunk 在已上传内容之后追加一个分片
+// 分片的起始位置必须等于已上传的大小，不一致时返回 ErrUploadOffset 和当前进度，客户端据此从断点继续
+// 分片先写入这个请求自己的临时文件，锁住任务之后再追加到已上传的内容后面，同一个位置的并发请求不会互相覆盖
+func (svc FileServiceImpl) UploadChunk(ctx context.Context, req file_domain.UploadChunkRequest, body io.Reader) (file_domain.UploadSession, error) {
+	c := loadUploadConfig()
+	s, err := svc.findUploadSession(ctx, c, req.UserID, req.UploadID)
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	if s.Completing {
+		return file_domain.UploadSession{}, ErrUploadCompleting
+	}
+	if req.Offset != s.Offset {
+		return s, ErrUploadOffset
+	}
+
+	p := chunkPath(c, s.UploadID)
+	tmp, n, err := receiveChunk(p, body, min(s.ChunkSize, s.Size-s.Offset))
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	defer os.Remove(tmp)
+	if n == 0 {
+		return s, nil
+	}
+
+	expireTime := time.Now().Add(c.Resumable.ttl())
+	ok, err := svc.repo.AppendUpload(ctx, s.UploadID, s.Offset, n, expireTime, func() error {
+		return appendChunk(p, tmp, s.Offset)
+	})
+	if errors.Is(err, file_repo.ErrRecordNotFound) {
+		return file_domain.UploadSession{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	if !ok {
+		// 同一个位置的分片被其他请求抢先写入，或者任务已经开始完成，返回最新进度
+		s, err = svc.findUploadSession(ctx, c, req.UserID, req.UploadID)
+		if err != nil {
+			return file_domain.UploadSession{}, err
+		}
+		if s.Completing {
+			return file_domain.UploadSession{}, ErrUploadCompleting
+		}
+		return s, ErrUploadOffset
+	}
+	s.Offset += n
+	s.ExpireTime = expireTime
+	return s, nil
+}
+
+// receiveChunk 把分片写入已上传内容所在目录下的新临时文件，返回临时文件路径和分片大小，超过 limit 时返回 ErrUploadChunkSize
+func receiveChunk(part string, body io.Reader, limit int64) (string, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(part), filepath.Base(part)+"-*")
+	if err != nil {
+		return "", 0, err
+	}
+	// 多读一个字节，用来判断分片是否超过限制
+	n, err := io.Copy(tmp, io.LimitReader(body, limit+1))
+	if err == nil && n > limit {
+		err = ErrUploadChunkSize
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", 0, err
+	}
+	return tmp.Name(), n, nil
+}
+
+// appendChunk 把临时文件中的分片写入已上传内容的 offset 位置，调用方需要持有任务的锁
+// 写入失败时截断到 offset，offset 之前是已经提交的内容，不受影响
+func appendChunk(part, tmp string, offset int64) error {
+	src, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	f, err := os.OpenFile(part, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := io.Copy(io.NewOffsetWriter(f, offset), src)
+	if err == nil {
+		// 去掉之前失败的写入留下的多余内容
+		err = f.Truncate(offset + n)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		_ = f.Truncate(offset)
+	}
+	return err
+}
+
+// UploadStatus 查询断点续传任务的进度
+func (svc FileServiceImpl) UploadStatus(ctx context.Context, uid int64, uploadID string) (file_domain.UploadSession, error) {
+	return svc.findUploadSession(ctx, loadUploadConfig(), uid, uploadID)
+}
+
+// CompleteUpload 校验整个文件的哈希和格式，保存为聊天附件，并删除断点续传任务
+// 先把任务标记为正在完成，避免同一个任务被重复完成；保存失败时恢复任务，已上传的内容保留，客户端可以重新完成
+func (svc FileServiceImpl) CompleteUpload(ctx context.Context, uid int64, uploadID string) (file_domain.Attachment, error) {
+	c := loadUploadConfig()
+	s, err := svc.findUploadSession(ctx, c, uid, uploadID)
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	if !s.Done() {
+		return file_domain.Attachment{}, ErrUploadIncomplete
+	}
+	ok, err := svc.repo.ClaimUploadSession(ctx, s.UploadID, time.Now().Add(c.Resumable.ttl()))
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	if !ok {
+		return file_domain.Attachment{}, ErrUploadCompleting
+	}
+
+	a, err := svc.completeUpload(ctx, c, s)
+	switch {
+	case errors.Is(err, ErrUploadHashMismatch) || errors.Is(err, ErrAttachmentMime):
+		// 内容有问题时重新上传也只能得到同样的结果，直接删除任务
+		_ = svc.removeUpload(ctx, c, s.UploadID)
+		return file_domain.Attachment{}, err
+	case err != nil:
+		// 请求被取消时同样需要恢复任务
+		_ = svc.repo.ReleaseUploadSession(context.WithoutCancel(ctx), s.UploadID)
+		return file_domain.Attachment{}, err
+	}
+	// 文件已经保存，删除失败时任务保持正在完成的状态，过期后由清理任务删除
+	_ = svc.removeUpload(ctx, c, s.UploadID)
+	return a, nil
+}
+
+// completeUpload 校验已经标记为正在完成的任务并保存文件，不删除任务和已上传的内容
+func (svc FileServiceImpl) completeUpload(ctx context.Context, c uploadConfig, s file_domain.UploadSession) (file_domain.Attachment, error) {
+	// 上传过程中可能有其他上传占用了配额，任务保留到过期，清理空间后可以重新完成
+	if err := svc.checkQuota(ctx, c, s.UserID, s.Size); err != nil {
+		return file_domain.Attachment{}, err
+	}
+
+	p := chunkPath(c, s.UploadID)
+	hash, head, err := hashFile(p)
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	mimeType := detectMime(head)
+	if hash != s.Hash {
+		return file_domain.Attachment{}, ErrUploadHashMismatch
+	}
+	if !allowMime(c.Attachments[s.Kind].Mimes, mimeType) {
+		return file_domain.Attachment{}, ErrAttachmentMime
+	}
+
+	f, err := svc.commit(ctx, c, file_domain.File{
+		UserID:   s.UserID,
+		Kind:     s.Kind,
+		Name:     s.Name,
+		Hash:     hash,
+		Size:     s.Size,
+		MimeType: mimeType,
+	}, p)
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	return svc.createAttachment(ctx, f)
+}
+
+// PurgeExpiredUploads 删除过期的断点续传任务和已上传的内容，返回删除的任务数
+func (svc FileServiceImpl) PurgeExpiredUploads(ctx context.Context) (int, error) {
+	c := loadUploadConfig()
+	total := 0
+	for {
+		ss, err := svc.repo.FindExpiredUploadSessions(ctx, purgeUploadBatch)
+		if err != nil {
+			return total, err
+		}
+		for _, s := range ss {
+			if err = svc.removeUpload(ctx, c, s.UploadID); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(ss) < purgeUploadBatch {
+			return total, nil
+		}
+	}
+}
+
+// findUploadSession 查询当前用户未过期的断点续传任务
+func (svc FileServiceImpl) findUploadSession(ctx context.Context, c uploadConfig, uid int64, uploadID string) (file_domain.UploadSession, error) {
+	s, err := svc.repo.FindUploadSession(ctx, uploadID)
+	if errors.Is(err, file_repo.ErrRecordNotFound) {
+		return file_domain.UploadSession{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return file_domain.UploadSession{}, err
+	}
+	if s.UserID != uid || time.Now().After(s.ExpireTime) {
+		return file_domain.UploadSession{}, ErrUploadNotFound
+	}
+	s.ChunkSize = c.Resumable.chunkSize()
+	return s, nil
+}
+
+func (svc FileServiceImpl) removeUpload(ctx context.Context, c uploadConfig, uploadID string) error {
+	if err := os.Remove(chunkPath(c, uploadID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	_, err := svc.repo.DeleteUploadSession(ctx, uploadID)
+	return err
+}
+
+// hashFile 计算文件的 SHA-256，同时返回文件头用于识别格式
+func hashFile(name string) (string, []byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), head[:n], nil
+}
+
+// newUploadID 生成 32 位十六进制任务ID
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,154 @@
+package file_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/pkg/scanner"
+	"github.com/ink-yht/im/pkg/storage"
+	"unicode/utf8"
+)
+
+// scanBatch 每次查询等待扫描的文件记录数
+const scanBatch = 100
+
+// maxReasonLen 隔离原因最多保留的字符数，和表结构 ScanReason size:128 对应
+const maxReasonLen = 128
+
+var ErrFileBlocked = errors.New("文件没有通过安全检查")
+
+// scanStatus 新上传文件的扫描状态，在 commit 中保存文件之前调用
+// 没有配置扫描器时不扫描；头像重新编码过，只保留了像素数据，也不扫描
+// 同样的内容已经扫描过时直接使用之前的结果，已被隔离的内容不再保存，其他情况等待后台任务扫描
+func (svc FileServiceImpl) scanStatus(ctx context.Context, f file_domain.File) (int8, error) {
+	if svc.scanner == nil || isAvatar(f.Kind) {
+		return file_domain.ScanClean, nil
+	}
+	prev, err := svc.repo.FindFileByHash(ctx, f.Hash)
+	if errors.Is(err, file_repo.ErrRecordNotFound) {
+		return file_domain.ScanPending, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if prev.ScanStatus == file_domain.ScanBlocked {
+		return 0, ErrFileBlocked
+	}
+	return prev.ScanStatus, nil
+}
+
+// ScanFiles 扫描等待扫描的文件，返回隔离的文件数
+// 同样内容的文件记录共用一个扫描结果；没有通过扫描时隔离文件记录和附件，并标记引用附件的消息，
+// 消息返回给客户端时不再带附件地址。单个文件扫描失败时跳过，保持等待扫描的状态，下次任务重试，最后返回第一个错误
+func (svc FileServiceImpl) ScanFiles(ctx context.Context) (int, error) {
+	if svc.scanner == nil {
+		return 0, nil
+	}
+	var (
+		total   int
+		lastID  int64
+		scanErr error
+	)
+	for {
+		fs, err := svc.repo.FindPendingScanFiles(ctx, lastID, scanBatch)
+		if err != nil {
+			return total, err
+		}
+		for _, f := range fs {
+			res, err := svc.scanFile(ctx, f)
+			if ctx.Err() != nil {
+				return total, ctx.Err()
+			}
+			if err != nil {
+				if scanErr == nil {
+					scanErr = err
+				}
+				continue
+			}
+			if !res.Blocked {
+				if err = svc.repo.MarkFilesClean(ctx, f.Hash); err != nil {
+					return total, err
+				}
+				continue
+			}
+			if err = svc.quarantine(ctx, f, res.Reason); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(fs) < scanBatch {
+			return total, scanErr
+		}
+		lastID = fs[len(fs)-1].ID
+	}
+}
+
+// scanFile 从存储后端读取文件交给扫描器，文件已经被清理时视为通过
+func (svc FileServiceImpl) scanFile(ctx context.Context, f file_domain.File) (scanner.Result, error) {
+	obj, err := svc.storage.Get(ctx, f.Path)
+	if errors.Is(err, storage.ErrNotExist) {
+		return scanner.Result{}, nil
+	}
+	if err != nil {
+		return scanner.Result{}, err
+	}
+	defer obj.Close()
+	return svc.scanner.Scan(ctx, scanner.Object{
+		Name:     f.Name,
+		MimeType: f.MimeType,
+		Size:     f.Size,
+		Content:  obj,
+	})
+}
+
+// quarantine 隔离同样内容的文件，文件保留在存储后端中但不能再下载，之后由清理任务删除
+func (svc FileServiceImpl) quarantine(ctx context.Context, f file_domain.File, reason string) error {
+	if utf8.RuneCountInString(reason) > maxReasonLen {
+		reason = string([]rune(reason)[:maxReasonLen])
+	}
+	as, err := svc.repo.QuarantineFiles(ctx, f.Hash, reason)
+	if err != nil {
+		return err
+	}
+	for _, a := range as {
+		if err = svc.blockMsg(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockMsg 标记引用附件的消息，消息已经被删除时忽略
+func (svc FileServiceImpl) blockMsg(ctx context.Context, a file_domain.Attachment) error {
+	switch a.ConvType {
+	case chat_domain.ConvTypeChat:
+		c, err := svc.chatRepo.FindChatByID(ctx, a.MsgID)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		if !c.Msg.BlockFile(a.ID) {
+			return nil
+		}
+		return svc.chatRepo.UpdateChatMsg(ctx, c)
+	case chat_domain.ConvTypeGroup:
+		m, err := svc.chatRepo.FindGroupMsgByID(ctx, a.MsgID)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		if !m.Msg.BlockFile(a.ID) {
+			return nil
+		}
+		return svc.chatRepo.UpdateGroupMsg(ctx, m)
+	}
+	return nil
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, chat_repo.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
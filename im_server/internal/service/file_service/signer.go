@@ -0,0 +1,81 @@
+package file_service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/spf13/viper"
+	"strconv"
+	"time"
+)
+
+// downloadPath 私有附件的下载地址，不需要登录，通过签名鉴权
+const downloadPath = "/files/download"
+
+// defaultDownloadTTL 签名地址默认的有效期
+const defaultDownloadTTL = time.Hour
+
+// URLSigner 生成和校验私有附件的短期下载地址
+// 只有能看到引用附件的消息的用户才会拿到签名地址，下载时只校验签名和有效期
+type URLSigner interface {
+	Sign(fileID int64) string
+	// SignThumb 生成附件预览图的下载地址，附件没有预览图时下载原文件
+	SignThumb(fileID int64) string
+	Verify(fileID, expires int64, thumb bool, sign string) bool
+}
+
+// HMACSigner 使用 HMAC-SHA256 对附件ID、过期时间和是否下载预览图签名
+type HMACSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewURLSigner() URLSigner {
+	type Config struct {
+		Secret string `yaml:"secret"`
+		TTL    int    `yaml:"ttl"` // 有效期（分钟）
+	}
+	var c Config
+	err := viper.UnmarshalKey("download", &c)
+	if err != nil {
+		panic(fmt.Errorf("初始化配置失败: %s \n", err))
+	}
+	if c.Secret == "" {
+		panic(fmt.Errorf("初始化配置失败: 缺少 download.secret \n"))
+	}
+	ttl := time.Duration(c.TTL) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultDownloadTTL
+	}
+	return &HMACSigner{
+		secret: []byte(c.Secret),
+		ttl:    ttl,
+	}
+}
+
+func (s *HMACSigner) Sign(fileID int64) string {
+	expires := time.Now().Add(s.ttl).Unix()
+	return fmt.Sprintf("%s?id=%d&expires=%d&sign=%s", downloadPath, fileID, expires, s.mac(fileID, expires, false))
+}
+
+func (s *HMACSigner) SignThumb(fileID int64) string {
+	expires := time.Now().Add(s.ttl).Unix()
+	return fmt.Sprintf("%s?id=%d&expires=%d&thumb=true&sign=%s", downloadPath, fileID, expires, s.mac(fileID, expires, true))
+}
+
+func (s *HMACSigner) Verify(fileID, expires int64, thumb bool, sign string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(sign), []byte(s.mac(fileID, expires, thumb)))
+}
+
+func (s *HMACSigner) mac(fileID, expires int64, thumb bool) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(strconv.FormatInt(fileID, 10) + ":" + strconv.FormatInt(expires, 10)))
+	if thumb {
+		h.Write([]byte(":thumb"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,133 @@
+package file_service
+
+import (
+	"github.com/spf13/viper"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseSigned 解析签名地址中的参数
+func parseSigned(t *testing.T, raw string) (int64, int64, bool, string) {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("解析地址 %q 失败: %v", raw, err)
+	}
+	if u.Path != downloadPath {
+		t.Fatalf("下载路径 = %q, 期望 %q", u.Path, downloadPath)
+	}
+	q := u.Query()
+	id, err := strconv.ParseInt(q.Get("id"), 10, 64)
+	if err != nil {
+		t.Fatalf("解析 id 失败: %v", err)
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatalf("解析 expires 失败: %v", err)
+	}
+	return id, expires, q.Get("thumb") == "true", q.Get("sign")
+}
+
+func TestHMACSignerSign(t *testing.T) {
+	s := &HMACSigner{secret: []byte("secret"), ttl: time.Hour}
+	testCases := []struct {
+		name      string
+		sign      func(fileID int64) string
+		fileID    int64
+		wantThumb bool
+	}{
+		{name: "原文件", sign: s.Sign, fileID: 42},
+		{name: "预览图", sign: s.SignThumb, fileID: 42, wantThumb: true},
+		{name: "大ID", sign: s.Sign, fileID: 1<<62 + 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := time.Now().Add(time.Hour).Unix()
+			id, expires, thumb, sign := parseSigned(t, tc.sign(tc.fileID))
+			after := time.Now().Add(time.Hour).Unix()
+			if id != tc.fileID {
+				t.Errorf("id = %d, 期望 %d", id, tc.fileID)
+			}
+			if expires < before || expires > after {
+				t.Errorf("expires = %d, 期望在 [%d, %d] 之间", expires, before, after)
+			}
+			if thumb != tc.wantThumb {
+				t.Errorf("thumb = %v, 期望 %v", thumb, tc.wantThumb)
+			}
+			if !s.Verify(id, expires, thumb, sign) {
+				t.Error("签名地址没有通过校验")
+			}
+		})
+	}
+}
+
+func TestHMACSignerVerify(t *testing.T) {
+	s := &HMACSigner{secret: []byte("secret"), ttl: time.Hour}
+	expires := time.Now().Add(time.Hour).Unix()
+	sign := s.mac(42, expires, false)
+	thumbSign := s.mac(42, expires, true)
+	past := time.Now().Add(-time.Minute).Unix()
+
+	testCases := []struct {
+		name    string
+		signer  *HMACSigner
+		fileID  int64
+		expires int64
+		thumb   bool
+		sign    string
+		want    bool
+	}{
+		{name: "正确的签名", signer: s, fileID: 42, expires: expires, sign: sign, want: true},
+		{name: "正确的预览图签名", signer: s, fileID: 42, expires: expires, thumb: true, sign: thumbSign, want: true},
+		{name: "修改附件ID", signer: s, fileID: 43, expires: expires, sign: sign},
+		{name: "延长有效期", signer: s, fileID: 42, expires: expires + 1, sign: sign},
+		{name: "原文件签名下载预览图", signer: s, fileID: 42, expires: expires, thumb: true, sign: sign},
+		{name: "预览图签名下载原文件", signer: s, fileID: 42, expires: expires, sign: thumbSign},
+		{name: "已经过期", signer: s, fileID: 42, expires: past, sign: s.mac(42, past, false)},
+		{name: "大写签名", signer: s, fileID: 42, expires: expires, sign: strings.ToUpper(sign)},
+		{name: "空签名", signer: s, fileID: 42, expires: expires},
+		{name: "其他密钥", signer: &HMACSigner{secret: []byte("other"), ttl: time.Hour}, fileID: 42, expires: expires, sign: sign},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.signer.Verify(tc.fileID, tc.expires, tc.thumb, tc.sign)
+			if got != tc.want {
+				t.Errorf("Verify = %v, 期望 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewURLSigner(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    map[string]any
+		wantPanic bool
+		wantTTL   time.Duration
+	}{
+		{name: "默认有效期", config: map[string]any{"secret": "secret"}, wantTTL: defaultDownloadTTL},
+		{name: "配置有效期", config: map[string]any{"secret": "secret", "ttl": 10}, wantTTL: 10 * time.Minute},
+		{name: "有效期小于 0", config: map[string]any{"secret": "secret", "ttl": -1}, wantTTL: defaultDownloadTTL},
+		{name: "缺少密钥", config: map[string]any{"ttl": 10}, wantPanic: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("download", tc.config)
+			defer func() {
+				r := recover()
+				if (r != nil) != tc.wantPanic {
+					t.Errorf("panic = %v, 期望 panic %v", r, tc.wantPanic)
+				}
+			}()
+			s := NewURLSigner().(*HMACSigner)
+			if s.ttl != tc.wantTTL {
+				t.Errorf("ttl = %v, 期望 %v", s.ttl, tc.wantTTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package file_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/pkg/storage"
+	"io"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// storeRequest 保存一个上传的文件，Mimes 为空表示不限制格式
+type storeRequest struct {
+	UserID  int64
+	GroupID int64 // 上传群头像时为群ID
+	Kind    string
+	File    *multipart.FileHeader
+	Mimes   []string
+}
+
+// store 按内容的 SHA-256 保存文件并写入文件记录
+// 文件先写入本地临时文件，边写边计算哈希，再交给 commit 保存到存储后端
+func (svc FileServiceImpl) store(ctx context.Context, c uploadConfig, req storeRequest) (file_domain.File, error) {
+	in, err := req.File.Open()
+	if err != nil {
+		return file_domain.File{}, err
+	}
+	defer in.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(in, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return file_domain.File{}, err
+	}
+	mimeType := detectMime(head[:n])
+	if !allowMime(req.Mimes, mimeType) {
+		return file_domain.File{}, ErrAttachmentMime
+	}
+
+	tmpDir := filepath.Join(c.Path, "tmp")
+	if err = os.MkdirAll(tmpDir, os.ModePerm); err != nil {
+		return file_domain.File{}, err
+	}
+	tmp, err := os.CreateTemp(tmpDir, "upload-*")
+	if err != nil {
+		return file_domain.File{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), io.MultiReader(bytes.NewReader(head[:n]), in))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return file_domain.File{}, err
+	}
+
+	return svc.commit(ctx, c, file_domain.File{
+		UserID:   req.UserID,
+		GroupID:  req.GroupID,
+		Kind:     req.Kind,
+		Name:     safeTitle(req.File.Filename),
+		Hash:     hex.EncodeToString(h.Sum(nil)),
+		Size:     size,
+		MimeType: mimeType,
+	}, tmp.Name())
+}
+
+// commit 把已经计算好哈希的临时文件保存到存储后端并写入文件记录，同样内容的文件已经存在时直接复用
+// 头像和聊天图片先经过 processImage 处理，保存的是处理后的内容；音视频经过 processMedia 读取时长
+// 配置了扫描器时聊天附件先正常保存，由后台任务 ScanFiles 扫描，已知有问题的内容直接拒绝
+func (svc FileServiceImpl) commit(ctx context.Context, c uploadConfig, f file_domain.File, tmpPath string) (file_domain.File, error) {
+	var (
+		variants []imageVariant
+		err      error
+	)
+	switch f.Kind {
+	case file_domain.KindAvatar, file_domain.KindGroupAvatar, file_domain.KindImage:
+		f, tmpPath, variants, err = processImage(c.Images, f, tmpPath)
+		if err != nil {
+			return file_domain.File{}, err
+		}
+		defer os.Remove(tmpPath)
+	case file_domain.KindVideo, file_domain.KindVoice:
+		f, variants, err = processMedia(ctx, c, f, tmpPath)
+		if err != nil {
+			return file_domain.File{}, err
+		}
+	}
+
+	f.Path = objectKey(f.Kind, f.Hash)
+	for _, v := range variants {
+		if v.Suffix == thumbSuffix {
+			f.Thumb = f.Path + thumbSuffix
+		}
+	}
+	// 头像上传后立即被用户资料或群资料引用，附件在发送消息时才增加引用
+	if isAvatar(f.Kind) {
+		f.Refs = 1
+	}
+	if f.ScanStatus, err = svc.scanStatus(ctx, f); err != nil {
+		return file_domain.File{}, err
+	}
+	// 先写入文件记录再保存内容：清理任务删除最后一条使用同样内容的记录时，在同一个事务中删除存储后端中的文件，
+	// 记录写入之后同样的内容不会再被删除，写入之前已经被删除的对象在这里重新保存
+	if f, err = svc.repo.CreateFile(ctx, f); err != nil {
+		return file_domain.File{}, err
+	}
+	if err = svc.putObjects(ctx, f, tmpPath, variants); err != nil {
+		// 释放刚写入的记录，由 deleteFile 删除记录和已经保存的部分内容
+		dctx := context.WithoutCancel(ctx)
+		if rerr := svc.repo.ReleaseFile(dctx, f.ID); rerr == nil {
+			_, _ = svc.deleteFile(dctx, c, f)
+		}
+		return file_domain.File{}, err
+	}
+	return f, nil
+}
+
+// putObjects 把文件和其他尺寸保存到存储后端，已经存在的对象不重复保存
+func (svc FileServiceImpl) putObjects(ctx context.Context, f file_domain.File, tmpPath string, variants []imageVariant) error {
+	for _, v := range variants {
+		err := svc.putMissing(ctx, f.Path+v.Suffix, func() error {
+			return svc.storage.Put(ctx, f.Path+v.Suffix, bytes.NewReader(v.Data), int64(len(v.Data)), v.MimeType)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return svc.putMissing(ctx, f.Path, func() error {
+		return svc.putTemp(ctx, f.Path, tmpPath, f.Size, f.MimeType)
+	})
+}
+
+// putMissing 对象不存在时调用 put 保存
+func (svc FileServiceImpl) putMissing(ctx context.Context, key string, put func() error) error {
+	_, err := svc.storage.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) {
+		return put()
+	}
+	return err
+}
+
+// putTemp 把临时文件上传到存储后端
+func (svc FileServiceImpl) putTemp(ctx context.Context, key, name string, size int64, mimeType string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return svc.storage.Put(ctx, key, f, size, mimeType)
+}
+
+// writeTemp 把处理后的内容写入 name 所在目录下的新临时文件，返回临时文件路径和内容的 SHA-256
+func writeTemp(name string, data []byte) (string, string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "processed-*")
+	if err != nil {
+		return "", "", err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", "", err
+	}
+	h := sha256.Sum256(data)
+	return tmp.Name(), hex.EncodeToString(h[:]), nil
+}
+
+// objectKey 内容寻址的对象路径，取哈希的前两级作为目录，避免单个目录下文件过多
+// 头像保存在公开的 avatar 目录下，聊天附件保存在只能通过签名地址下载的 objects 目录下
+func objectKey(kind, hash string) string {
+	dir := "objects"
+	if isAvatar(kind) {
+		dir = "avatar"
+	}
+	return path.Join(dir, hash[:2], hash[2:4], hash)
+}
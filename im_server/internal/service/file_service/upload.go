@@ -0,0 +1,177 @@
+package file_service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/spf13/viper"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTitleLen 原始文件名最多保留的字符数，和表结构 Title size:128 对应
+const maxTitleLen = 128
+
+var (
+	ErrAttachmentSize = errors.New("附件大小超过限制")
+	ErrAttachmentMime = errors.New("不支持的附件格式")
+	ErrAttachmentNone = errors.New("未找到上传文件")
+)
+
+// kindConfig 单种附件的限制，Mimes 为空表示不限制格式
+type kindConfig struct {
+	Size     int      `yaml:"size"`     // 单个文件大小上限（MB）
+	Duration int      `yaml:"duration"` // 音视频时长上限（秒），为 0 表示不限制
+	Mimes    []string `yaml:"mimes"`
+}
+
+// resumableConfig 断点续传的配置
+type resumableConfig struct {
+	ChunkSize   int `yaml:"chunkSize"`   // 单个分片大小上限（MB）
+	ExpireHours int `yaml:"expireHours"` // 最后一次上传分片之后多久没有继续上传就过期（小时）
+}
+
+type uploadConfig struct {
+	Size        int                   `yaml:"size"`
+	Path        string                `yaml:"path"`
+	Attachments map[string]kindConfig `yaml:"attachments"`
+	Resumable   resumableConfig       `yaml:"resumable"`
+	Images      imageConfig           `yaml:"images"`
+	Media       mediaConfig           `yaml:"media"`
+	Quota       int                   `yaml:"quota"` // 每个用户的存储配额（MB），为 0 表示不限制
+	Lifecycle   lifecycleConfig       `yaml:"lifecycle"`
+}
+
+func loadUploadConfig() uploadConfig {
+	var c uploadConfig
+	if err := viper.UnmarshalKey("uploads", &c); err != nil {
+		panic(fmt.Errorf("初始化配置失败: %s \n", err))
+	}
+	return c
+}
+
+// Upload 上传聊天附件，按附件类型限制大小和格式，保存后归上传者所有
+func (svc FileServiceImpl) Upload(ctx context.Context, req file_domain.UploadRequest, fh *multipart.FileHeader) (file_domain.Attachment, error) {
+	if err := req.Validate(); err != nil {
+		return file_domain.Attachment{}, err
+	}
+	c := loadUploadConfig()
+	kc, ok := c.Attachments[req.Kind]
+	if !ok {
+		return file_domain.Attachment{}, file_domain.ErrKindNotSupported
+	}
+	if fh.Size <= 0 {
+		return file_domain.Attachment{}, ErrAttachmentNone
+	}
+	if fh.Size > int64(kc.Size)*1024*1024 {
+		return file_domain.Attachment{}, ErrAttachmentSize
+	}
+	if err := svc.checkQuota(ctx, c, req.UserID, fh.Size); err != nil {
+		return file_domain.Attachment{}, err
+	}
+
+	f, err := svc.store(ctx, c, storeRequest{
+		UserID: req.UserID,
+		Kind:   req.Kind,
+		File:   fh,
+		Mimes:  kc.Mimes,
+	})
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+
+	return svc.createAttachment(ctx, f)
+}
+
+// createAttachment 为保存好的文件创建聊天附件，返回带签名下载地址的附件
+func (svc FileServiceImpl) createAttachment(ctx context.Context, f file_domain.File) (file_domain.Attachment, error) {
+	a := file_domain.Attachment{
+		UserID:   f.UserID,
+		Kind:     f.Kind,
+		Title:    f.Name,
+		Size:     f.Size,
+		MimeType: f.MimeType,
+		Type:     strings.TrimPrefix(safeExt(f.Name), "."),
+		Duration: f.Duration,
+		Width:    f.Width,
+		Height:   f.Height,
+		HasThumb: f.Thumb != "",
+		FileID:   f.ID,
+	}
+	a, err := svc.repo.CreateAttachment(ctx, a)
+	if err != nil {
+		return file_domain.Attachment{}, err
+	}
+	a.Src = svc.signer.Sign(a.ID)
+	// 没有缩略图的图片下载原图，视频没有封面时不返回
+	if a.Kind == file_domain.KindImage || a.HasThumb {
+		a.Thumb = svc.signer.SignThumb(a.ID)
+	}
+	return a, nil
+}
+
+// detectMime 根据文件头识别类型，补充标准库不认识的几种音视频格式
+func detectMime(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("#!AMR")):
+		return "audio/amr"
+	case len(head) >= 2 && head[0] == 0xFF && (head[1]&0xF6) == 0xF0:
+		return "audio/aac"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		switch string(head[8:12]) {
+		case "M4A ", "M4B ":
+			return "audio/mp4"
+		case "qt  ":
+			return "video/quicktime"
+		}
+	}
+	t, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return t
+}
+
+func allowMime(mimes []string, t string) bool {
+	if len(mimes) == 0 {
+		return true
+	}
+	for _, m := range mimes {
+		if m == t {
+			return true
+		}
+	}
+	return false
+}
+
+// safeExt 只保留由字母和数字组成的短扩展名，其他情况返回空字符串
+func safeExt(filename string) string {
+	ext := strings.ToLower(filepath.Ext(safeTitle(filename)))
+	if len(ext) < 2 || len(ext) > 10 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// safeTitle 去掉客户端文件名中的路径部分，过长时截断
+func safeTitle(filename string) string {
+	title := filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if title == "." || title == "/" {
+		return ""
+	}
+	if utf8.RuneCountInString(title) > maxTitleLen {
+		title = string([]rune(title)[:maxTitleLen])
+	}
+	return title
+}
@@ -0,0 +1,103 @@
+package group_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/repository/user_repo"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+var (
+	ErrGroupNotFound  = errors.New("群不存在")
+	ErrNotGroupMember = errors.New("你不是该群成员")
+	ErrNotGroupAdmin  = errors.New("只有群主和管理员可以操作")
+)
+
+// GroupService 定义了群服务的接口
+type GroupService interface {
+	CreateNotice(ctx context.Context, req group_domain.NoticeRequest) (group_domain.Notice, error)
+	UpdateNotice(ctx context.Context, req group_domain.NoticeRequest) error
+	DeleteNotice(ctx context.Context, req group_domain.NoticeIDRequest) error
+	PinNotice(ctx context.Context, req group_domain.NoticePinRequest) error
+	Notices(ctx context.Context, uid, groupID int64) ([]group_domain.Notice, error)
+	ConfirmNotice(ctx context.Context, req group_domain.NoticeIDRequest) error
+	NoticeConfirms(ctx context.Context, uid, noticeID int64) (group_domain.NoticeConfirms, error)
+	Invite(ctx context.Context, req group_domain.InviteRequest) (group_domain.InviteResult, error)
+	CreateInviteLink(ctx context.Context, req group_domain.InviteLinkRequest) (group_domain.InviteLink, error)
+	InviteLinks(ctx context.Context, uid, groupID int64) ([]group_domain.InviteLink, error)
+	RevokeInviteLink(ctx context.Context, req group_domain.InviteLinkIDRequest) error
+	JoinByLink(ctx context.Context, req group_domain.JoinByLinkRequest) (group_domain.JoinResult, error)
+	Verifies(ctx context.Context, uid, groupID int64) ([]group_domain.Verify, error)
+	HandleVerify(ctx context.Context, req group_domain.VerifyHandleRequest) error
+	SearchGroups(ctx context.Context, req group_domain.GroupSearchRequest) ([]group_domain.GroupSearchItem, error)
+	Members(ctx context.Context, req group_domain.MemberListRequest) (group_domain.MemberList, error)
+	SetMemberNickname(ctx context.Context, req group_domain.MemberNicknameRequest) error
+}
+
+// GroupServiceImpl 实现了 GroupService 接口
+type GroupServiceImpl struct {
+	repo       group_repo.GroupRepository
+	friendRepo user_repo.FriendRepository
+	push       push_service.PushService
+	files      file_service.FileService
+	l          logger.Logger
+}
+
+func NewGroupService(repo group_repo.GroupRepository, friendRepo user_repo.FriendRepository,
+	push push_service.PushService, files file_service.FileService, l logger.Logger) GroupService {
+	return &GroupServiceImpl{
+		repo:       repo,
+		friendRepo: friendRepo,
+		push:       push,
+		files:      files,
+		l:          l,
+	}
+}
+
+// findGroup 查询群信息，群不存在时返回 ErrGroupNotFound
+func (svc *GroupServiceImpl) findGroup(ctx context.Context, groupID int64) (group_domain.Group, error) {
+	g, err := svc.repo.FindByID(ctx, groupID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return group_domain.Group{}, ErrGroupNotFound
+	}
+	return g, err
+}
+
+// findMember 查询 uid 在群里的成员信息，不是群成员时返回 ErrNotGroupMember
+func (svc *GroupServiceImpl) findMember(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error) {
+	member, err := svc.repo.FindMember(ctx, groupID, uid)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return group_domain.GroupMember{}, ErrNotGroupMember
+	}
+	return member, err
+}
+
+// findAdmin 查询 uid 在群里的成员信息，不是群主或管理员时返回 ErrNotGroupAdmin
+func (svc *GroupServiceImpl) findAdmin(ctx context.Context, groupID, uid int64) (group_domain.GroupMember, error) {
+	member, err := svc.findMember(ctx, groupID, uid)
+	if err != nil {
+		return group_domain.GroupMember{}, err
+	}
+	if !member.IsAdmin() {
+		return group_domain.GroupMember{}, ErrNotGroupAdmin
+	}
+	return member, nil
+}
+
+// pushMembers 推送事件给全部群成员
+func (svc *GroupServiceImpl) pushMembers(ctx context.Context, groupID int64, evt push_service.Event) {
+	members, err := svc.repo.FindMembers(ctx, groupID)
+	if err != nil {
+		svc.l.Error("查询群成员失败", logger.Int64("groupID", groupID), logger.Error("err", err))
+		return
+	}
+	uids := make([]int64, 0, len(members))
+	for _, m := range members {
+		uids = append(uids, m.UserID)
+	}
+	svc.push.Push(ctx, uids, evt)
+}
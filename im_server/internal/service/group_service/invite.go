@@ -0,0 +1,312 @@
+package group_service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/pkg/logger"
+	"time"
+)
+
+var (
+	ErrInviteDisabled     = errors.New("该群不允许普通成员邀请好友")
+	ErrInviteNotFriend    = errors.New("只能邀请自己的好友")
+	ErrInviteLinkInvalid  = errors.New("邀请链接已失效")
+	ErrInviteLinkNotFound = errors.New("邀请链接不存在")
+	ErrJoinForbidden      = errors.New("该群不允许加入")
+	ErrAlreadyMember      = errors.New("你已经是该群成员")
+	ErrGroupFull          = errors.New("群成员已满")
+	ErrVerifyNotFound     = errors.New("验证请求不存在")
+	ErrVerifyHandled      = errors.New("验证请求已处理")
+)
+
+// Invite 邀请好友入群，群开启了 IsInvite 时成员都可以邀请，否则只有群主和管理员可以邀请
+// 群主和管理员邀请的好友直接入群，普通成员邀请的好友按群验证规则处理
+func (svc *GroupServiceImpl) Invite(ctx context.Context, req group_domain.InviteRequest) (group_domain.InviteResult, error) {
+	if err := req.Validate(); err != nil {
+		return group_domain.InviteResult{}, err
+	}
+	g, err := svc.findGroup(ctx, req.GroupID)
+	if err != nil {
+		return group_domain.InviteResult{}, err
+	}
+	inviter, err := svc.findMember(ctx, req.GroupID, req.UserID)
+	if err != nil {
+		return group_domain.InviteResult{}, err
+	}
+	if !g.IsInvite && !inviter.IsAdmin() {
+		return group_domain.InviteResult{}, ErrInviteDisabled
+	}
+
+	uids := make([]int64, 0, len(req.UserIDs))
+	seen := make(map[int64]struct{}, len(req.UserIDs))
+	for _, uid := range req.UserIDs {
+		if _, ok := seen[uid]; ok || uid == req.UserID {
+			continue
+		}
+		seen[uid] = struct{}{}
+		ok, err := svc.friendRepo.IsFriend(ctx, req.UserID, uid)
+		if err != nil {
+			return group_domain.InviteResult{}, err
+		}
+		if !ok {
+			return group_domain.InviteResult{}, ErrInviteNotFriend
+		}
+		uids = append(uids, uid)
+	}
+
+	res := group_domain.InviteResult{Joined: []int64{}, Pending: []int64{}}
+	for _, uid := range uids {
+		joined, err := svc.join(ctx, g, group_domain.Verify{
+			GroupID:   g.ID,
+			UserID:    uid,
+			InviterID: req.UserID,
+			Type:      group_domain.VerifyTypeInvite,
+		}, inviter.IsAdmin())
+		switch {
+		case errors.Is(err, ErrAlreadyMember):
+			continue
+		case err != nil:
+			return res, err
+		case joined:
+			res.Joined = append(res.Joined, uid)
+		default:
+			res.Pending = append(res.Pending, uid)
+		}
+	}
+	return res, nil
+}
+
+// CreateInviteLink 创建邀请链接，只有群主和管理员可以创建
+func (svc *GroupServiceImpl) CreateInviteLink(ctx context.Context, req group_domain.InviteLinkRequest) (group_domain.InviteLink, error) {
+	if err := req.Validate(); err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	if _, err := svc.findAdmin(ctx, req.GroupID, req.UserID); err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	token, err := newInviteToken()
+	if err != nil {
+		return group_domain.InviteLink{}, err
+	}
+	return svc.repo.CreateInviteLink(ctx, group_domain.InviteLink{
+		GroupID:    req.GroupID,
+		Token:      token,
+		CreatorID:  req.UserID,
+		ExpireTime: time.Now().Add(req.TTL()),
+		MaxUses:    req.MaxUses,
+	})
+}
+
+// InviteLinks 查询群里没有撤销的邀请链接，只有群主和管理员可以查看
+func (svc *GroupServiceImpl) InviteLinks(ctx context.Context, uid, groupID int64) ([]group_domain.InviteLink, error) {
+	if _, err := svc.findAdmin(ctx, groupID, uid); err != nil {
+		return nil, err
+	}
+	return svc.repo.FindInviteLinks(ctx, groupID)
+}
+
+// RevokeInviteLink 撤销邀请链接，已经通过链接提交的验证请求不受影响
+func (svc *GroupServiceImpl) RevokeInviteLink(ctx context.Context, req group_domain.InviteLinkIDRequest) error {
+	link, err := svc.repo.FindInviteLinkByID(ctx, req.LinkID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return ErrInviteLinkNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findAdmin(ctx, link.GroupID, req.UserID); err != nil {
+		return err
+	}
+	return svc.repo.RevokeInviteLink(ctx, link.ID)
+}
+
+// JoinByLink 通过邀请链接入群，群需要审核时提交验证请求
+func (svc *GroupServiceImpl) JoinByLink(ctx context.Context, req group_domain.JoinByLinkRequest) (group_domain.JoinResult, error) {
+	if err := req.Validate(); err != nil {
+		return group_domain.JoinResult{}, err
+	}
+	link, err := svc.repo.FindInviteLinkByToken(ctx, req.Token)
+	if errors.Is(err, group_repo.ErrRecordNotFound) || (err == nil && !link.Usable()) {
+		return group_domain.JoinResult{}, ErrInviteLinkInvalid
+	}
+	if err != nil {
+		return group_domain.JoinResult{}, err
+	}
+	g, err := svc.findGroup(ctx, link.GroupID)
+	if err != nil {
+		return group_domain.JoinResult{}, err
+	}
+	res := group_domain.JoinResult{GroupID: g.ID}
+
+	_, err = svc.repo.FindMember(ctx, g.ID, req.UserID)
+	if err == nil {
+		return res, ErrAlreadyMember
+	}
+	if !errors.Is(err, group_repo.ErrRecordNotFound) {
+		return res, err
+	}
+	// 已经提交过验证请求时不重复占用链接的使用次数
+	_, err = svc.repo.FindPendingVerify(ctx, g.ID, req.UserID)
+	if err == nil {
+		return res, nil
+	}
+	if !errors.Is(err, group_repo.ErrRecordNotFound) {
+		return res, err
+	}
+	// 链接的使用次数在入群或者提交验证请求的事务中占用，失败时不占用
+	res.Joined, err = svc.join(ctx, g, group_domain.Verify{
+		GroupID:            g.ID,
+		UserID:             req.UserID,
+		InviterID:          link.CreatorID,
+		InviteLinkID:       link.ID,
+		Type:               group_domain.VerifyTypeLink,
+		AdditionalMessages: req.Message,
+	}, false)
+	return res, err
+}
+
+// Verifies 查询群里还没有处理的入群验证请求，只有群主和管理员可以查看
+func (svc *GroupServiceImpl) Verifies(ctx context.Context, uid, groupID int64) ([]group_domain.Verify, error) {
+	if _, err := svc.findAdmin(ctx, groupID, uid); err != nil {
+		return nil, err
+	}
+	return svc.repo.FindPendingVerifies(ctx, groupID)
+}
+
+// HandleVerify 同意或拒绝入群验证请求
+func (svc *GroupServiceImpl) HandleVerify(ctx context.Context, req group_domain.VerifyHandleRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	v, err := svc.repo.FindVerifyByID(ctx, req.VerifyID)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return ErrVerifyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findAdmin(ctx, v.GroupID, req.UserID); err != nil {
+		return err
+	}
+	if v.Status != group_domain.VerifyStatusPending {
+		return ErrVerifyHandled
+	}
+	if req.Status == group_domain.VerifyStatusAgree {
+		g, err := svc.findGroup(ctx, v.GroupID)
+		if err != nil {
+			return err
+		}
+		// 先入群再修改状态，群已满时请求保持未操作；邀请链接在提交请求时已经占用过
+		if err = svc.addMember(ctx, g, v.UserID, v.InviterID, 0); err != nil && !errors.Is(err, ErrAlreadyMember) {
+			return err
+		}
+	}
+	err = svc.repo.UpdateVerifyStatus(ctx, v.ID, req.Status)
+	if errors.Is(err, group_repo.ErrVerifyHandled) {
+		return ErrVerifyHandled
+	}
+	return err
+}
+
+// join 用户入群，direct 为 true 或群允许任何人加入时直接入群，否则提交验证请求
+// 通过邀请链接加入时，入群或提交验证请求成功才占用一次链接
+// 返回 true 表示已经入群，false 表示在等待审核
+func (svc *GroupServiceImpl) join(ctx context.Context, g group_domain.Group, v group_domain.Verify, direct bool) (bool, error) {
+	if direct || g.Verification == group_domain.VerificationAllowAll {
+		if err := svc.addMember(ctx, g, v.UserID, v.InviterID, v.InviteLinkID); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if g.Verification == group_domain.VerificationForbid {
+		return false, ErrJoinForbidden
+	}
+
+	_, err := svc.repo.FindPendingVerify(ctx, g.ID, v.UserID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, group_repo.ErrRecordNotFound) {
+		return false, err
+	}
+	_, err = svc.repo.FindMember(ctx, g.ID, v.UserID)
+	if err == nil {
+		return false, ErrAlreadyMember
+	}
+	if !errors.Is(err, group_repo.ErrRecordNotFound) {
+		return false, err
+	}
+	v.Status = group_domain.VerifyStatusPending
+	v, err = svc.repo.CreateVerify(ctx, v)
+	if errors.Is(err, group_repo.ErrLinkUnusable) {
+		return false, ErrInviteLinkInvalid
+	}
+	if err != nil {
+		return false, err
+	}
+	svc.pushAdmins(ctx, g.ID, push_service.Event{
+		Type: push_service.EventVerify,
+		Data: group_domain.VerifyEvent{Verify: v},
+	})
+	return false, nil
+}
+
+// addMember 以普通成员身份入群，并通知全部群成员
+// 群规模在入群的事务中检查，linkID 不为 0 时同时占用一次邀请链接
+func (svc *GroupServiceImpl) addMember(ctx context.Context, g group_domain.Group, uid, inviterID, linkID int64) error {
+	err := svc.repo.AddMember(ctx, g.ID, uid, group_domain.RoleMember, linkID)
+	switch {
+	case errors.Is(err, group_repo.ErrMemberExists):
+		return ErrAlreadyMember
+	case errors.Is(err, group_repo.ErrGroupFull):
+		return ErrGroupFull
+	case errors.Is(err, group_repo.ErrLinkUnusable):
+		return ErrInviteLinkInvalid
+	case err != nil:
+		return err
+	}
+	svc.pushMembers(ctx, g.ID, push_service.Event{
+		Type: push_service.EventMember,
+		Data: group_domain.MemberEvent{
+			GroupID:   g.ID,
+			UserID:    uid,
+			InviterID: inviterID,
+			Action:    group_domain.MemberActionJoin,
+		},
+	})
+	// 群头像由前几个成员的头像生成，成员变化后重新生成，失败时保留原来的头像
+	if err = svc.files.RefreshGroupAvatar(ctx, g.ID); err != nil {
+		svc.l.Error("生成群头像失败", logger.Int64("groupID", g.ID), logger.Error("err", err))
+	}
+	return nil
+}
+
+// pushAdmins 推送事件给群主和管理员
+func (svc *GroupServiceImpl) pushAdmins(ctx context.Context, groupID int64, evt push_service.Event) {
+	members, err := svc.repo.FindMembers(ctx, groupID)
+	if err != nil {
+		svc.l.Error("查询群成员失败", logger.Int64("groupID", groupID), logger.Error("err", err))
+		return
+	}
+	var uids []int64
+	for _, m := range members {
+		if m.IsAdmin() {
+			uids = append(uids, m.UserID)
+		}
+	}
+	svc.push.Push(ctx, uids, evt)
+}
+
+// newInviteToken 生成 32 位十六进制邀请码
+func newInviteToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
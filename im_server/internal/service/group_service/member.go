@@ -0,0 +1,47 @@
+package group_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"strings"
+)
+
+// Members 分页查询群成员，只有群成员可以查看
+func (svc *GroupServiceImpl) Members(ctx context.Context, req group_domain.MemberListRequest) (group_domain.MemberList, error) {
+	if _, err := svc.findMember(ctx, req.GroupID, req.UserID); err != nil {
+		return group_domain.MemberList{}, err
+	}
+	members, total, err := svc.repo.FindMemberPage(ctx, req.GroupID, strings.TrimSpace(req.Keyword), req.Offset(), req.PageLimit())
+	if err != nil {
+		return group_domain.MemberList{}, err
+	}
+	return group_domain.MemberList{Total: total, Members: members}, nil
+}
+
+// SetMemberNickname 修改自己的群昵称，并通知全部群成员
+func (svc *GroupServiceImpl) SetMemberNickname(ctx context.Context, req group_domain.MemberNicknameRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	member, err := svc.findMember(ctx, req.GroupID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if member.MemberNickname == req.MemberNickname {
+		return nil
+	}
+	if err = svc.repo.UpdateMemberNickname(ctx, req.GroupID, req.UserID, req.MemberNickname); err != nil {
+		return err
+	}
+	svc.pushMembers(ctx, req.GroupID, push_service.Event{
+		Type: push_service.EventMember,
+		Data: group_domain.MemberEvent{
+			GroupID:        req.GroupID,
+			UserID:         req.UserID,
+			Action:         group_domain.MemberActionNickname,
+			MemberNickname: req.MemberNickname,
+		},
+	})
+	return nil
+}
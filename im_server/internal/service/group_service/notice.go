@@ -0,0 +1,217 @@
+package group_service
+
+import (
+	"context"
+	"errors"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/service/push_service"
+)
+
+var (
+	ErrNoticeNotFound  = errors.New("公告不存在")
+	ErrNoticeNoConfirm = errors.New("该公告不需要确认")
+)
+
+// CreateNotice 发布群公告，只有群主和管理员可以发布，发布后通知全部群成员
+func (svc *GroupServiceImpl) CreateNotice(ctx context.Context, req group_domain.NoticeRequest) (group_domain.Notice, error) {
+	if err := req.Validate(); err != nil {
+		return group_domain.Notice{}, err
+	}
+	if _, err := svc.findAdmin(ctx, req.GroupID, req.UserID); err != nil {
+		return group_domain.Notice{}, err
+	}
+	n, err := svc.repo.CreateNotice(ctx, group_domain.Notice{
+		GroupID:     req.GroupID,
+		Title:       req.Title,
+		Content:     req.Content,
+		NeedConfirm: req.NeedConfirm,
+		IsPinned:    req.IsPinned,
+		UserID:      req.UserID,
+	})
+	if err != nil {
+		return group_domain.Notice{}, err
+	}
+	svc.pushNotice(ctx, n, group_domain.NoticeActionCreate)
+	return n, nil
+}
+
+// UpdateNotice 编辑群公告，需要确认的公告修改了标题或内容后，成员需要重新确认
+func (svc *GroupServiceImpl) UpdateNotice(ctx context.Context, req group_domain.NoticeRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	n, err := svc.findNotice(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findAdmin(ctx, n.GroupID, req.UserID); err != nil {
+		return err
+	}
+	resetConfirm := req.NeedConfirm && (n.Title != req.Title || n.Content != req.Content)
+	n.Title = req.Title
+	n.Content = req.Content
+	n.NeedConfirm = req.NeedConfirm
+	n.EditUserID = req.UserID
+	if err = svc.repo.UpdateNotice(ctx, n, resetConfirm); err != nil {
+		return err
+	}
+	svc.pushNotice(ctx, n, group_domain.NoticeActionUpdate)
+	return nil
+}
+
+// DeleteNotice 删除群公告
+func (svc *GroupServiceImpl) DeleteNotice(ctx context.Context, req group_domain.NoticeIDRequest) error {
+	n, err := svc.findNotice(ctx, req.NoticeID)
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findAdmin(ctx, n.GroupID, req.UserID); err != nil {
+		return err
+	}
+	if err = svc.repo.DeleteNotice(ctx, n.ID); err != nil {
+		return err
+	}
+	svc.pushMembers(ctx, n.GroupID, push_service.Event{
+		Type: push_service.EventNotice,
+		Data: group_domain.NoticeEvent{
+			GroupID:  n.GroupID,
+			NoticeID: n.ID,
+			Action:   group_domain.NoticeActionDelete,
+		},
+	})
+	return nil
+}
+
+// PinNotice 置顶或取消置顶群公告
+func (svc *GroupServiceImpl) PinNotice(ctx context.Context, req group_domain.NoticePinRequest) error {
+	n, err := svc.findNotice(ctx, req.NoticeID)
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findAdmin(ctx, n.GroupID, req.UserID); err != nil {
+		return err
+	}
+	if n.IsPinned == req.Pinned {
+		return nil
+	}
+	if err = svc.repo.UpdateNoticePinned(ctx, n.ID, req.Pinned); err != nil {
+		return err
+	}
+	n.IsPinned = req.Pinned
+	action := group_domain.NoticeActionPin
+	if !req.Pinned {
+		action = group_domain.NoticeActionUnpin
+	}
+	svc.pushNotice(ctx, n, action)
+	return nil
+}
+
+// Notices 查询群公告列表，置顶的在前，并标记当前用户是否已确认
+func (svc *GroupServiceImpl) Notices(ctx context.Context, uid, groupID int64) ([]group_domain.Notice, error) {
+	if _, err := svc.findMember(ctx, groupID, uid); err != nil {
+		return nil, err
+	}
+	notices, err := svc.repo.FindNotices(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, n := range notices {
+		if n.NeedConfirm {
+			ids = append(ids, n.ID)
+		}
+	}
+	confirmed, err := svc.repo.FindConfirmedNoticeIDs(ctx, uid, ids)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[int64]struct{}, len(confirmed))
+	for _, id := range confirmed {
+		set[id] = struct{}{}
+	}
+	for i := range notices {
+		_, notices[i].Confirmed = set[notices[i].ID]
+	}
+	return notices, nil
+}
+
+// ConfirmNotice 群成员确认已读需要确认的公告
+func (svc *GroupServiceImpl) ConfirmNotice(ctx context.Context, req group_domain.NoticeIDRequest) error {
+	n, err := svc.findNotice(ctx, req.NoticeID)
+	if err != nil {
+		return err
+	}
+	if _, err = svc.findMember(ctx, n.GroupID, req.UserID); err != nil {
+		return err
+	}
+	if !n.NeedConfirm {
+		return ErrNoticeNoConfirm
+	}
+	return svc.repo.ConfirmNotice(ctx, n.ID, req.UserID)
+}
+
+// NoticeConfirms 查询群公告的确认情况，只有群主和管理员可以查看，已经退群的成员不再统计
+func (svc *GroupServiceImpl) NoticeConfirms(ctx context.Context, uid, noticeID int64) (group_domain.NoticeConfirms, error) {
+	n, err := svc.findNotice(ctx, noticeID)
+	if err != nil {
+		return group_domain.NoticeConfirms{}, err
+	}
+	if _, err = svc.findAdmin(ctx, n.GroupID, uid); err != nil {
+		return group_domain.NoticeConfirms{}, err
+	}
+	if !n.NeedConfirm {
+		return group_domain.NoticeConfirms{}, ErrNoticeNoConfirm
+	}
+	members, err := svc.repo.FindMembers(ctx, n.GroupID)
+	if err != nil {
+		return group_domain.NoticeConfirms{}, err
+	}
+	records, err := svc.repo.FindNoticeConfirms(ctx, n.ID)
+	if err != nil {
+		return group_domain.NoticeConfirms{}, err
+	}
+	confirmed := make(map[int64]group_domain.NoticeConfirmRecord, len(records))
+	for _, r := range records {
+		confirmed[r.UserID] = r
+	}
+	res := group_domain.NoticeConfirms{
+		NoticeID:    n.ID,
+		Confirmed:   []group_domain.NoticeConfirmer{},
+		Unconfirmed: []group_domain.NoticeConfirmer{},
+	}
+	for _, m := range members {
+		c := group_domain.NoticeConfirmer{
+			UserID:         m.UserID,
+			MemberNickname: m.MemberNickname,
+		}
+		if r, ok := confirmed[m.UserID]; ok {
+			c.ConfirmTime = r.ConfirmTime
+			res.Confirmed = append(res.Confirmed, c)
+		} else {
+			res.Unconfirmed = append(res.Unconfirmed, c)
+		}
+	}
+	return res, nil
+}
+
+func (svc *GroupServiceImpl) findNotice(ctx context.Context, id int64) (group_domain.Notice, error) {
+	n, err := svc.repo.FindNoticeByID(ctx, id)
+	if errors.Is(err, group_repo.ErrRecordNotFound) {
+		return group_domain.Notice{}, ErrNoticeNotFound
+	}
+	return n, err
+}
+
+// pushNotice 公告发布、编辑、置顶后通知全部群成员
+func (svc *GroupServiceImpl) pushNotice(ctx context.Context, n group_domain.Notice, action string) {
+	svc.pushMembers(ctx, n.GroupID, push_service.Event{
+		Type: push_service.EventNotice,
+		Data: group_domain.NoticeEvent{
+			GroupID:  n.GroupID,
+			NoticeID: n.ID,
+			Action:   action,
+			Notice:   &n,
+		},
+	})
+}
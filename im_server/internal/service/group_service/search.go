@@ -0,0 +1,63 @@
+package group_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+)
+
+// SearchGroups 按群号或群名称搜索群，只返回允许被搜索的群
+func (svc *GroupServiceImpl) SearchGroups(ctx context.Context, req group_domain.GroupSearchRequest) ([]group_domain.GroupSearchItem, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	groups, err := svc.repo.SearchGroups(ctx, req.Keyword, req.GroupID(), req.LastID, req.PageLimit())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]group_domain.GroupSearchItem, 0, len(groups))
+	if len(groups) == 0 {
+		return res, nil
+	}
+	ids := make([]int64, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	counts, err := svc.repo.CountMembersByGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	joined, err := svc.repo.FindGroupIDsByUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := svc.repo.FindPendingVerifyGroupIDs(ctx, req.UserID, ids)
+	if err != nil {
+		return nil, err
+	}
+	joinedSet := idSet(joined)
+	pendingSet := idSet(pending)
+	for _, g := range groups {
+		_, isMember := joinedSet[g.ID]
+		_, isPending := pendingSet[g.ID]
+		res = append(res, group_domain.GroupSearchItem{
+			ID:           g.ID,
+			Title:        g.Title,
+			Abstract:     g.Abstract,
+			Avatar:       g.Avatar,
+			Verification: g.Verification,
+			Size:         g.Size,
+			MemberCount:  counts[g.ID],
+			IsMember:     isMember,
+			IsPending:    isPending,
+		})
+	}
+	return res, nil
+}
+
+func idSet(ids []int64) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
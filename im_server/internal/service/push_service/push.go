@@ -0,0 +1,99 @@
+package push_service
+
+import (
+	"context"
+	"github.com/ink-yht/im/pkg/logger"
+	"sync"
+)
+
+// 事件类型
+const (
+	EventMsg      = "msg"      // 新消息
+	EventRead     = "read"     // 已读回执
+	EventAt       = "at"       // @提醒
+	EventWithdraw = "withdraw" // 消息撤回
+	EventDelete   = "delete"   // 删除消息、清空会话，只推送给自己的其他端
+	EventEdit     = "edit"     // 消息编辑
+	EventReaction = "reaction" // 表情回应
+	EventPin      = "pin"      // 置顶、取消置顶消息
+	EventNotice   = "notice"   // 群公告变化
+	EventMember   = "member"   // 群成员变化
+	EventVerify   = "verify"   // 新的入群验证请求，只推送给群主和管理员
+)
+
+// Event 实时推送给客户端的事件
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// Conn 一条客户端长连接，同一个用户可以有多条（多端登录）
+type Conn interface {
+	WriteJSON(v any) error
+}
+
+// PushService 维护在线连接并推送实时事件
+type PushService interface {
+	Online(uid int64, conn Conn)
+	Offline(uid int64, conn Conn)
+	Push(ctx context.Context, uids []int64, evt Event)
+}
+
+// PushServiceImpl 单机内存版本，连接只保存在当前进程中
+type PushServiceImpl struct {
+	mu    sync.RWMutex
+	conns map[int64]map[Conn]struct{}
+	l     logger.Logger
+}
+
+func NewPushService(l logger.Logger) PushService {
+	return &PushServiceImpl{
+		conns: make(map[int64]map[Conn]struct{}),
+		l:     l,
+	}
+}
+
+func (svc *PushServiceImpl) Online(uid int64, conn Conn) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	cs, ok := svc.conns[uid]
+	if !ok {
+		cs = make(map[Conn]struct{})
+		svc.conns[uid] = cs
+	}
+	cs[conn] = struct{}{}
+}
+
+func (svc *PushServiceImpl) Offline(uid int64, conn Conn) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	cs, ok := svc.conns[uid]
+	if !ok {
+		return
+	}
+	delete(cs, conn)
+	if len(cs) == 0 {
+		delete(svc.conns, uid)
+	}
+}
+
+// Push 推送事件，不在线的用户直接忽略，写失败只记录日志
+func (svc *PushServiceImpl) Push(ctx context.Context, uids []int64, evt Event) {
+	for _, uid := range uids {
+		svc.mu.RLock()
+		cs := make([]Conn, 0, len(svc.conns[uid]))
+		for c := range svc.conns[uid] {
+			cs = append(cs, c)
+		}
+		svc.mu.RUnlock()
+
+		for _, c := range cs {
+			if err := c.WriteJSON(evt); err != nil {
+				svc.l.Warn("推送事件失败",
+					logger.Int64("uid", uid),
+					logger.String("type", evt.Type),
+					logger.Error("err", err))
+			}
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/ink-yht/im/internal/domain/user_domain"
 	"github.com/ink-yht/im/internal/repository/user_repo"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 	"time"
 )
@@ -24,12 +26,16 @@ type UserService interface {
 
 // UserServiceImpl 实现了 UserService 接口
 type UserServiceImpl struct {
-	repo user_repo.UserRepository
+	repo  user_repo.UserRepository
+	files file_service.FileService
+	l     logger.Logger
 }
 
-func NewUserService(repo user_repo.UserRepository) UserService {
+func NewUserService(repo user_repo.UserRepository, files file_service.FileService, l logger.Logger) UserService {
 	return &UserServiceImpl{
-		repo: repo,
+		repo:  repo,
+		files: files,
+		l:     l,
 	}
 }
 
@@ -130,6 +136,14 @@ func (svc *UserServiceImpl) Signup(ctx context.Context, req user_domain.EmailReg
 		return err
 	}
 
+	// 根据用户ID生成默认头像，失败时保留统一的默认头像，不影响注册
+	u, err := svc.repo.FindByEmail(ctx, user.Email)
+	if err == nil {
+		err = svc.files.GenerateUserAvatar(ctx, u.ID)
+	}
+	if err != nil {
+		svc.l.Warn("生成默认头像失败", logger.String("email", user.Email), logger.Error("err", err))
+	}
 	return nil
 }
 
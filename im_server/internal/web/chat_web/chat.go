@@ -0,0 +1,855 @@
+package chat_web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/chat_domain"
+	"github.com/ink-yht/im/internal/domain/search_domain"
+	"github.com/ink-yht/im/internal/service/chat_service"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+	"strconv"
+)
+
+// bizErrs 业务错误，错误信息可以直接返回给前端
+var bizErrs = []error{
+	chat_domain.ErrMsgTypeNotSupported,
+	chat_domain.ErrMsgContentEmpty,
+	chat_service.ErrNotFriend,
+	chat_service.ErrNotGroupMember,
+	chat_service.ErrProhibition,
+	chat_service.ErrMsgNotFound,
+	chat_service.ErrNoPermission,
+	chat_service.ErrConvType,
+	chat_domain.ErrRefMsgEmpty,
+	chat_service.ErrRefMsgNotFound,
+	chat_service.ErrRefWithdrawn,
+	chat_service.ErrAtInChat,
+	chat_service.ErrAtNotMember,
+	chat_domain.ErrAtEmpty,
+	chat_domain.ErrAtTooMany,
+	chat_domain.ErrFileMisplaced,
+	chat_domain.ErrMsgServerOnly,
+	chat_service.ErrAtAllNoPermission,
+	chat_domain.ErrDeleteEmpty,
+	chat_domain.ErrDeleteTooMany,
+	search_domain.ErrKeywordEmpty,
+	search_domain.ErrKeywordTooLong,
+	search_domain.ErrConvTypeEmpty,
+	chat_domain.ErrForwardEmpty,
+	chat_domain.ErrForwardTooMany,
+	chat_domain.ErrForwardTargetEmpty,
+	chat_domain.ErrForwardTargetTooMany,
+	chat_domain.ErrForwardNotSupported,
+	chat_service.ErrEditNotSupported,
+	chat_service.ErrEditExpire,
+	chat_service.ErrMsgChanged,
+	chat_domain.ErrEmojiInvalid,
+	chat_service.ErrWithdrawn,
+	chat_service.ErrWithdrawExpire,
+	chat_service.ErrPinNotAdmin,
+	chat_service.ErrUserNotFound,
+	chat_domain.ErrPinnedMsgsTooMany,
+	chat_domain.ErrPinnedConvTooMany,
+	chat_service.ErrTempSessionClosed,
+	chat_service.ErrTempSessionLeft,
+	chat_service.ErrTempSessionLimit,
+	chat_service.ErrAttachmentNotFound,
+	chat_service.ErrAttachmentUsed,
+	chat_service.ErrAttachmentKind,
+	chat_service.ErrAttachmentBlocked,
+}
+
+func isBizErr(err error) bool {
+	for _, e := range bizErrs {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+type ChatHandler struct {
+	svc chat_service.ChatService
+	l   logger.Logger
+}
+
+func NewChatHandler(svc chat_service.ChatService, l logger.Logger) *ChatHandler {
+	return &ChatHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+// RegisterRoutes 路由注册
+func (c *ChatHandler) RegisterRoutes(server *gin.Engine) {
+	mg := server.Group("/messages")
+	mg.POST("/chat/send", c.SendChat)             // 发送私聊消息
+	mg.GET("/chat/history", c.ChatHistory)        // 私聊历史消息
+	mg.POST("/group/send", c.SendGroupMsg)        // 发送群消息
+	mg.GET("/group/history", c.GroupHistory)      // 群聊历史消息
+	mg.GET("/group/readers", c.GroupMsgReaders)   // 群消息已读详情
+	mg.POST("/read", c.Read)                      // 上报已读
+	mg.GET("/sync", c.Sync)                       // 离线消息同步
+	mg.POST("/ack", c.Ack)                        // 确认收到消息
+	mg.POST("/withdraw", c.Withdraw)              // 撤回消息
+	mg.GET("/mentions", c.Mentions)               // 我被@的列表
+	mg.POST("/delete", c.Delete)                  // 删除消息（仅自己）
+	mg.POST("/clear", c.Clear)                    // 清空会话（仅自己）
+	mg.GET("/search", c.Search)                   // 搜索消息
+	mg.POST("/forward", c.Forward)                // 转发消息（逐条、合并）
+	mg.POST("/edit", c.Edit)                      // 编辑消息
+	mg.GET("/edits", c.EditHistory)               // 消息编辑历史
+	mg.POST("/reaction/add", c.AddReaction)       // 添加表情回应
+	mg.POST("/reaction/remove", c.RemoveReaction) // 取消表情回应
+	mg.POST("/pin", c.PinMsg)                     // 置顶消息
+	mg.POST("/unpin", c.UnpinMsg)                 // 取消置顶消息
+	mg.GET("/pinned", c.PinnedMsgs)               // 会话的置顶消息列表
+
+	cg := server.Group("/conversations")
+	cg.GET("", c.Conversations)        // 会话列表
+	cg.POST("/pin", c.PinConversation) // 置顶、取消置顶会话
+}
+
+func (c *ChatHandler) SendChat(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.SendChatRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.SendUserID = userClaims.Id
+
+	chat, err := c.svc.SendChat(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("发送私聊消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "发送成功",
+		Data: chat,
+	})
+}
+
+func (c *ChatHandler) SendGroupMsg(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.SendGroupMsgRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.SendUserID = userClaims.Id
+
+	msg, err := c.svc.SendGroupMsg(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("发送群消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "发送成功",
+		Data: msg,
+	})
+}
+
+func (c *ChatHandler) ChatHistory(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.HistoryRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	chats, err := c.svc.ChatHistory(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取私聊历史消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取历史消息成功",
+		Data: chats,
+	})
+}
+
+func (c *ChatHandler) GroupHistory(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.HistoryRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	msgs, err := c.svc.GroupHistory(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取群聊历史消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取历史消息成功",
+		Data: msgs,
+	})
+}
+
+func (c *ChatHandler) Read(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.ReadRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Read(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("上报已读失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "上报已读成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) GroupMsgReaders(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	msgID, err := strconv.ParseInt(ctx.Query("msgID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "参数错误",
+			Data: nil,
+		})
+		return
+	}
+
+	readers, err := c.svc.GroupMsgReaders(ctx, userClaims.Id, msgID)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取群消息已读详情失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取已读详情成功",
+		Data: readers,
+	})
+}
+
+func (c *ChatHandler) Sync(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.SyncRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := c.svc.Sync(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("离线消息同步失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "同步成功",
+		Data: res,
+	})
+}
+
+func (c *ChatHandler) Ack(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.AckRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Ack(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("确认收到消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "确认成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) Withdraw(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.WithdrawRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Withdraw(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("撤回消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "撤回成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) Mentions(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.MentionRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	mentions, err := c.svc.Mentions(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取@我的消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取@我的消息成功",
+		Data: mentions,
+	})
+}
+
+func (c *ChatHandler) Delete(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.DeleteRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Delete(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("删除消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "删除成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) Clear(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.ClearRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Clear(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("清空会话失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "清空成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) Search(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req search_domain.SearchRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := c.svc.Search(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("搜索消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "搜索成功",
+		Data: res,
+	})
+}
+
+func (c *ChatHandler) Forward(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.ForwardRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	msgs, err := c.svc.Forward(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("转发消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "转发成功",
+		Data: msgs,
+	})
+}
+
+func (c *ChatHandler) Edit(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.EditRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.Edit(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("编辑消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "编辑成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) EditHistory(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	convType, err1 := strconv.ParseInt(ctx.Query("convType"), 10, 8)
+	msgID, err2 := strconv.ParseInt(ctx.Query("msgID"), 10, 64)
+	if err1 != nil || err2 != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "参数错误",
+			Data: nil,
+		})
+		return
+	}
+
+	edits, err := c.svc.EditHistory(ctx, userClaims.Id, int8(convType), msgID)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取消息编辑历史失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取编辑历史成功",
+		Data: edits,
+	})
+}
+
+func (c *ChatHandler) AddReaction(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.ReactionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.AddReaction(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("添加表情回应失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "添加成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) RemoveReaction(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.ReactionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.RemoveReaction(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("取消表情回应失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "取消成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) PinMsg(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.PinRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.PinMsg(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("置顶消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "置顶成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) UnpinMsg(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.PinRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.UnpinMsg(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("取消置顶消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "取消置顶成功",
+		Data: nil,
+	})
+}
+
+func (c *ChatHandler) PinnedMsgs(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.PinnedListRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	pins, err := c.svc.PinnedMsgs(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取置顶消息失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取置顶消息成功",
+		Data: pins,
+	})
+}
+
+func (c *ChatHandler) Conversations(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+
+	items, err := c.svc.Conversations(ctx, userClaims.Id)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("获取会话列表失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取会话列表成功",
+		Data: items,
+	})
+}
+
+func (c *ChatHandler) PinConversation(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req chat_domain.PinConversationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := c.svc.PinConversation(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		c.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		c.l.Error("置顶会话失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "操作成功",
+		Data: nil,
+	})
+}
@@ -0,0 +1,51 @@
+package file_web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/pkg/logger"
+	"mime"
+	"net/http"
+)
+
+// Download 通过签名地址下载私有附件，不需要登录，支持 Range 请求，视频可以边下边播
+func (f *FileHandler) Download(ctx *gin.Context) {
+	var req file_domain.DownloadRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+
+	d, err := f.svc.Download(ctx, req)
+	if errors.Is(err, file_service.ErrDownloadSign) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, file_service.ErrFileBlocked) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+		f.l.Warn("下载已隔离的附件", logger.Int64("fileID", req.FileID))
+		return
+	}
+	if errors.Is(err, file_service.ErrFileNotFound) {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		f.l.Error("下载附件失败", logger.Int64("fileID", req.FileID), logger.Error("err", err))
+		return
+	}
+	defer d.Content.Close()
+
+	// 文件消息作为下载处理，图片、视频、语音直接在页面中展示和播放
+	disposition := "inline"
+	if d.Kind == file_domain.KindFile {
+		disposition = "attachment"
+	}
+	ctx.Header("Content-Type", d.MimeType)
+	ctx.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": d.Title}))
+	ctx.Header("Cache-Control", "private, max-age=3600")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(ctx.Writer, ctx.Request, d.Title, d.ModTime, d.Content)
+}
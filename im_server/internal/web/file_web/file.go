@@ -3,6 +3,7 @@ package file_web
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/file_domain"
 	"github.com/ink-yht/im/internal/service/file_service"
 	"github.com/ink-yht/im/internal/service/user_service"
 	"github.com/ink-yht/im/internal/web"
@@ -26,6 +27,15 @@ func NewFileHandler(svc file_service.FileService, l logger.Logger) *FileHandler
 func (f *FileHandler) RegisterRoutes(server *gin.Engine) {
 	fg := server.Group("/files")
 	fg.POST("/avatar", f.Avatar)
+	fg.POST("/group/avatar", f.GroupAvatar) // 上传群头像，只有群主和管理员可以修改
+	fg.POST("/upload/:kind", f.Upload)      // 上传聊天附件 image video file voice
+	fg.GET("/download", f.Download)         // 通过签名地址下载聊天附件
+
+	fg.POST("/uploads/init", f.InitUpload)             // 创建断点续传任务
+	fg.PUT("/uploads/:id", f.UploadChunk)              // 上传分片
+	fg.GET("/uploads/:id", f.UploadStatus)             // 查询上传进度
+	fg.POST("/uploads/:id/complete", f.CompleteUpload) // 完成上传
+	fg.GET("/usage", f.Usage)                          // 查询存储空间使用情况
 }
 
 func (f *FileHandler) Avatar(ctx *gin.Context) {
@@ -41,14 +51,19 @@ func (f *FileHandler) Avatar(ctx *gin.Context) {
 
 	// 获取单个文件，这里假设表单中文件字段名为"image"
 	file, ok := form.File["image"]
-	if !ok {
-		errors.New("上传图片错误，未找到上传文件")
+	if !ok || len(file) == 0 {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "上传图片错误，未找到上传文件",
+			Data: nil,
+		})
+		f.l.Warn("上传图片错误，未找到上传文件")
 		return
 	}
 
 	// 取到单个文件对象
 	image := file[0]
-	err = f.svc.Avatar(ctx, userClaims.Id, imageType, image)
+	avatar, err := f.svc.Avatar(ctx, userClaims.Id, imageType, image)
 	if errors.Is(err, file_service.ErrSavePicture) {
 		ctx.JSON(http.StatusOK, web.Result{
 			Code: 1,
@@ -67,6 +82,24 @@ func (f *FileHandler) Avatar(ctx *gin.Context) {
 		f.l.Warn("图片大小超过设定大小，当设定大小为: 2Mb ")
 		return
 	}
+	if errors.Is(err, file_service.ErrImageType) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "不支持的图片格式",
+			Data: nil,
+		})
+		f.l.Warn("不支持的图片格式")
+		return
+	}
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, web.Result{
 			Code: 2,
@@ -79,7 +112,72 @@ func (f *FileHandler) Avatar(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, web.Result{
 		Code: 0,
 		Msg:  "上传头像成功",
-		Data: nil,
+		Data: avatar,
 	})
 	f.l.Info("上传头像成功")
 }
+
+// GroupAvatar 上传群头像，表单中 groupID 为群ID，image 为图片
+func (f *FileHandler) GroupAvatar(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req file_domain.GroupAvatarRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	image, err := ctx.FormFile("image")
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "上传图片错误，未找到上传文件",
+			Data: nil,
+		})
+		return
+	}
+
+	avatar, err := f.svc.GroupAvatar(ctx, req, image)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id), logger.Int64("groupID", req.GroupID))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		f.l.Error("上传群头像失败", logger.Int64("groupID", req.GroupID), logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "上传群头像成功",
+		Data: avatar,
+	})
+}
+
+// Usage 查询当前用户已经使用的存储空间和配额
+func (f *FileHandler) Usage(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	u, err := f.svc.Usage(ctx, userClaims.Id)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		f.l.Error("查询存储空间失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "查询成功",
+		Data: u,
+	})
+}
@@ -0,0 +1,111 @@
+package file_web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+)
+
+// InitUpload 创建断点续传任务
+func (f *FileHandler) InitUpload(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req file_domain.UploadInitRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	s, err := f.svc.InitUpload(ctx, req)
+	f.uploadResult(ctx, userClaims.Id, s, err, "创建上传任务失败", "创建成功")
+}
+
+// UploadChunk 上传一个分片，请求体为分片内容，offset 为分片在文件中的起始位置
+func (f *FileHandler) UploadChunk(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req file_domain.UploadChunkRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+	req.UploadID = ctx.Param("id")
+
+	s, err := f.svc.UploadChunk(ctx, req, ctx.Request.Body)
+	f.uploadResult(ctx, userClaims.Id, s, err, "上传分片失败", "上传成功")
+}
+
+// UploadStatus 查询上传进度，连接断开后从返回的 offset 继续上传
+func (f *FileHandler) UploadStatus(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	s, err := f.svc.UploadStatus(ctx, userClaims.Id, ctx.Param("id"))
+	f.uploadResult(ctx, userClaims.Id, s, err, "查询上传进度失败", "查询成功")
+}
+
+// CompleteUpload 全部分片上传完成后校验哈希，返回发送消息需要的附件信息
+func (f *FileHandler) CompleteUpload(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	a, err := f.svc.CompleteUpload(ctx, userClaims.Id, ctx.Param("id"))
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		f.l.Error("完成上传失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "上传成功",
+		Data: a,
+	})
+}
+
+// uploadResult 返回断点续传任务的进度，分片位置不一致时同样返回当前进度
+func (f *FileHandler) uploadResult(ctx *gin.Context, uid int64, s file_domain.UploadSession, err error, errMsg, okMsg string) {
+	if errors.Is(err, file_service.ErrUploadOffset) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: s,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", uid))
+		return
+	}
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", uid))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		f.l.Error(errMsg, logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  okMsg,
+		Data: s,
+	})
+}
@@ -0,0 +1,99 @@
+package file_web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/file_domain"
+	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+)
+
+// bizErrs 业务错误，错误信息可以直接返回给前端
+var bizErrs = []error{
+	file_domain.ErrKindNotSupported,
+	file_service.ErrAttachmentSize,
+	file_service.ErrAttachmentMime,
+	file_service.ErrAttachmentNone,
+	file_domain.ErrUploadSize,
+	file_domain.ErrUploadHash,
+	file_domain.ErrUploadName,
+	file_service.ErrUploadNotFound,
+	file_service.ErrUploadChunkSize,
+	file_service.ErrUploadIncomplete,
+	file_service.ErrUploadHashMismatch,
+	file_service.ErrUploadCompleting,
+	file_service.ErrImageDecode,
+	file_service.ErrImagePixels,
+	file_service.ErrMediaDecode,
+	file_service.ErrMediaDuration,
+	file_service.ErrQuotaExceeded,
+	file_service.ErrImageSize,
+	file_service.ErrImageType,
+	file_service.ErrGroupNotFound,
+	file_service.ErrNotGroupAdmin,
+	file_service.ErrFileBlocked,
+}
+
+func isBizErr(err error) bool {
+	for _, e := range bizErrs {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload 上传聊天附件，返回发送图片、视频、文件、语音消息需要的信息
+func (f *FileHandler) Upload(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req file_domain.UploadRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "参数错误",
+			Data: nil,
+		})
+		return
+	}
+	req.UserID = userClaims.Id
+	req.Kind = ctx.Param("kind")
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  file_service.ErrAttachmentNone.Error(),
+			Data: nil,
+		})
+		f.l.Warn(file_service.ErrAttachmentNone.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+
+	a, err := f.svc.Upload(ctx, req, file)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		f.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		f.l.Error("上传附件失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "上传成功",
+		Data: a,
+	})
+}
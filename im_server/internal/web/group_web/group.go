@@ -0,0 +1,85 @@
+package group_web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/service/group_service"
+	"github.com/ink-yht/im/pkg/logger"
+)
+
+// bizErrs 业务错误，错误信息可以直接返回给前端
+var bizErrs = []error{
+	group_service.ErrNotGroupMember,
+	group_service.ErrNotGroupAdmin,
+	group_service.ErrNoticeNotFound,
+	group_service.ErrNoticeNoConfirm,
+	group_domain.ErrNoticeTitleEmpty,
+	group_domain.ErrNoticeTitleTooLong,
+	group_domain.ErrNoticeContentEmpty,
+	group_domain.ErrNoticeContentTooLong,
+	group_service.ErrGroupNotFound,
+	group_service.ErrInviteDisabled,
+	group_service.ErrInviteNotFriend,
+	group_service.ErrInviteLinkInvalid,
+	group_service.ErrInviteLinkNotFound,
+	group_service.ErrJoinForbidden,
+	group_service.ErrAlreadyMember,
+	group_service.ErrGroupFull,
+	group_service.ErrVerifyNotFound,
+	group_service.ErrVerifyHandled,
+	group_domain.ErrInviteEmpty,
+	group_domain.ErrInviteTooMany,
+	group_domain.ErrInviteLinkTTL,
+	group_domain.ErrInviteLinkMaxUses,
+	group_domain.ErrVerifyMessageLen,
+	group_domain.ErrVerifyStatus,
+	group_domain.ErrGroupKeywordEmpty,
+	group_domain.ErrGroupKeywordTooLong,
+	group_domain.ErrMemberNicknameTooLong,
+}
+
+func isBizErr(err error) bool {
+	for _, e := range bizErrs {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+type GroupHandler struct {
+	svc group_service.GroupService
+	l   logger.Logger
+}
+
+func NewGroupHandler(svc group_service.GroupService, l logger.Logger) *GroupHandler {
+	return &GroupHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+// RegisterRoutes 路由注册
+func (h *GroupHandler) RegisterRoutes(server *gin.Engine) {
+	gg := server.Group("/groups")
+	gg.GET("/search", h.SearchGroups)                 // 搜索群
+	gg.GET("/members", h.Members)                     // 群成员列表
+	gg.POST("/members/nickname", h.SetMemberNickname) // 修改自己的群昵称
+
+	gg.GET("/notices", h.Notices)                 // 群公告列表
+	gg.POST("/notices/create", h.CreateNotice)    // 发布群公告
+	gg.POST("/notices/update", h.UpdateNotice)    // 编辑群公告
+	gg.POST("/notices/delete", h.DeleteNotice)    // 删除群公告
+	gg.POST("/notices/pin", h.PinNotice)          // 置顶、取消置顶群公告
+	gg.POST("/notices/confirm", h.ConfirmNotice)  // 确认群公告
+	gg.GET("/notices/confirms", h.NoticeConfirms) // 群公告确认详情
+
+	gg.POST("/invite", h.Invite)                        // 邀请好友入群
+	gg.GET("/invite_links", h.InviteLinks)              // 邀请链接列表
+	gg.POST("/invite_links/create", h.CreateInviteLink) // 创建邀请链接
+	gg.POST("/invite_links/revoke", h.RevokeInviteLink) // 撤销邀请链接
+	gg.POST("/join/link", h.JoinByLink)                 // 通过邀请链接入群
+	gg.GET("/verifies", h.Verifies)                     // 待处理的入群验证请求
+	gg.POST("/verifies/handle", h.HandleVerify)         // 同意、拒绝入群验证请求
+}
@@ -0,0 +1,257 @@
+package group_web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+	"strconv"
+)
+
+func (h *GroupHandler) Invite(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.InviteRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := h.svc.Invite(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("邀请好友入群失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "邀请成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) CreateInviteLink(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.InviteLinkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := h.svc.CreateInviteLink(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("创建邀请链接失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "创建成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) InviteLinks(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	groupID, err := strconv.ParseInt(ctx.Query("groupID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "参数错误",
+			Data: nil,
+		})
+		return
+	}
+
+	res, err := h.svc.InviteLinks(ctx, userClaims.Id, groupID)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("获取邀请链接失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取邀请链接成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) RevokeInviteLink(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.InviteLinkIDRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := h.svc.RevokeInviteLink(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("撤销邀请链接失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "撤销成功",
+		Data: nil,
+	})
+}
+
+func (h *GroupHandler) JoinByLink(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.JoinByLinkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := h.svc.JoinByLink(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("通过邀请链接入群失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "操作成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) Verifies(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	groupID, err := strconv.ParseInt(ctx.Query("groupID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  "参数错误",
+			Data: nil,
+		})
+		return
+	}
+
+	res, err := h.svc.Verifies(ctx, userClaims.Id, groupID)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("获取入群验证请求失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取验证请求成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) HandleVerify(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.VerifyHandleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := h.svc.HandleVerify(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("处理入群验证请求失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "处理成功",
+		Data: nil,
+	})
+}
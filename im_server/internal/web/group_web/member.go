@@ -0,0 +1,78 @@
+package group_web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+)
+
+func (h *GroupHandler) Members(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.MemberListRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	res, err := h.svc.Members(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("获取群成员列表失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "获取群成员成功",
+		Data: res,
+	})
+}
+
+func (h *GroupHandler) SetMemberNickname(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.MemberNicknameRequest
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	err := h.svc.SetMemberNickname(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("修改群昵称失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "修改成功",
+		Data: nil,
+	})
+}
@@ -0,0 +1,44 @@
+package group_web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/domain/group_domain"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+)
+
+func (h *GroupHandler) SearchGroups(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+	var req group_domain.GroupSearchRequest
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	req.UserID = userClaims.Id
+
+	groups, err := h.svc.SearchGroups(ctx, req)
+	if isBizErr(err) {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 1,
+			Msg:  err.Error(),
+			Data: nil,
+		})
+		h.l.Warn(err.Error(), logger.Int64("uid", userClaims.Id))
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, web.Result{
+			Code: 2,
+			Msg:  "系统错误",
+			Data: nil,
+		})
+		h.l.Error("搜索群失败", logger.Error("err", err))
+		return
+	}
+	ctx.JSON(http.StatusOK, web.Result{
+		Code: 0,
+		Msg:  "搜索成功",
+		Data: groups,
+	})
+}
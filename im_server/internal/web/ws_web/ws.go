@@ -0,0 +1,108 @@
+package ws_web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/ink-yht/im/internal/service/push_service"
+	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/pkg/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// pongWait 超过这个时间没有收到客户端任何数据就断开
+	pongWait = 60 * time.Second
+	// pingPeriod 服务端发送 ping 的间隔，必须小于 pongWait
+	pingPeriod = pongWait * 9 / 10
+	writeWait  = 10 * time.Second
+)
+
+type WsHandler struct {
+	push     push_service.PushService
+	l        logger.Logger
+	upgrader websocket.Upgrader
+}
+
+func NewWsHandler(push push_service.PushService, l logger.Logger) *WsHandler {
+	return &WsHandler{
+		push: push,
+		l:    l,
+		upgrader: websocket.Upgrader{
+			// 跨域已经由 cors 中间件处理
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
+}
+
+// RegisterRoutes 路由注册
+func (w *WsHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/ws", w.Connect) // 建立实时推送长连接
+}
+
+func (w *WsHandler) Connect(ctx *gin.Context) {
+	userClaims := ctx.MustGet("claims").(*user_service.UserClaims)
+
+	ws, err := w.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		w.l.Warn("升级 websocket 失败", logger.Error("err", err))
+		return
+	}
+	c := &conn{ws: ws}
+	w.push.Online(userClaims.Id, c)
+	w.l.Info("用户上线", logger.Int64("uid", userClaims.Id))
+
+	done := make(chan struct{})
+	go c.keepalive(done)
+
+	// 客户端只需要维持连接，业务请求都走 HTTP 接口
+	_ = ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err = ws.ReadMessage(); err != nil {
+			break
+		}
+		_ = ws.SetReadDeadline(time.Now().Add(pongWait))
+	}
+
+	close(done)
+	w.push.Offline(userClaims.Id, c)
+	_ = ws.Close()
+	w.l.Info("用户下线", logger.Int64("uid", userClaims.Id))
+}
+
+// conn 包装 websocket 连接，gorilla 的连接不支持并发写
+type conn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *conn) WriteJSON(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.ws.WriteJSON(v)
+}
+
+func (c *conn) keepalive(done chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			c.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
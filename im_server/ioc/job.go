@@ -0,0 +1,17 @@
+package ioc
+
+import (
+	"github.com/ink-yht/im/internal/job"
+	"github.com/ink-yht/im/pkg/logger"
+	"time"
+)
+
+func InitScheduler(l logger.Logger, purgeChat *job.PurgeChatJob, purgeUploads *job.PurgeUploadsJob, collectFiles *job.CollectFilesJob,
+	scanFiles *job.ScanFilesJob) *job.Scheduler {
+	s := job.NewScheduler(l)
+	s.Every(10*time.Minute, purgeChat)
+	s.Every(10*time.Minute, purgeUploads)
+	s.Every(time.Hour, collectFiles)
+	s.Every(5*time.Minute, scanFiles)
+	return s
+}
@@ -0,0 +1,51 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/ink-yht/im/pkg/scanner"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// InitScanner 按配置组合上传文件的内容扫描器，没有配置扫描器时返回 nil，上传的文件不需要扫描
+func InitScanner() scanner.Scanner {
+	type ClamAVConfig struct {
+		Network string `yaml:"network"`
+		Addr    string `yaml:"addr"`
+		Timeout int    `yaml:"timeout"` // 单个文件的扫描超时（秒）
+	}
+	type Config struct {
+		Scanners []string     `yaml:"scanners"`
+		ClamAV   ClamAVConfig `yaml:"clamav"`
+		Mimes    []string     `yaml:"mimes"`
+	}
+	var c Config
+	err := viper.UnmarshalKey("scan", &c)
+	if err != nil {
+		panic(fmt.Errorf("初始化配置失败: %s \n", err))
+	}
+	var scanners []scanner.Scanner
+	for _, name := range c.Scanners {
+		switch name {
+		case "nop":
+			scanners = append(scanners, scanner.NewNop())
+		case "fake":
+			scanners = append(scanners, scanner.NewFake())
+		case "mime":
+			scanners = append(scanners, scanner.NewMimeAllowlist(c.Mimes))
+		case "clamav":
+			network := c.ClamAV.Network
+			if network == "" {
+				network = "unix"
+			}
+			timeout := time.Duration(c.ClamAV.Timeout) * time.Second
+			scanners = append(scanners, scanner.NewClamAV(network, c.ClamAV.Addr, timeout))
+		default:
+			panic(fmt.Errorf("未知的扫描器: %s \n", name))
+		}
+	}
+	if len(scanners) == 0 {
+		return nil
+	}
+	return scanner.Chain(scanners...)
+}
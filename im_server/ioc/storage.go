@@ -0,0 +1,37 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/ink-yht/im/pkg/storage"
+	"github.com/spf13/viper"
+)
+
+// InitStorage 按配置选择文件存储后端，local 为本地文件系统，s3 为 S3 兼容存储
+func InitStorage() storage.Storage {
+	type LocalConfig struct {
+		Root    string `yaml:"root"`
+		BaseURL string `yaml:"baseURL"`
+	}
+	type Config struct {
+		Driver string           `yaml:"driver"`
+		Local  LocalConfig      `yaml:"local"`
+		S3     storage.S3Config `yaml:"s3"`
+	}
+	var c Config
+	err := viper.UnmarshalKey("storage", &c)
+	if err != nil {
+		panic(fmt.Errorf("初始化配置失败: %s \n", err))
+	}
+	switch c.Driver {
+	case "", "local":
+		return storage.NewLocalStorage(c.Local.Root, c.Local.BaseURL)
+	case "s3":
+		s, err := storage.NewS3Storage(c.S3)
+		if err != nil {
+			panic(err)
+		}
+		return s
+	default:
+		panic(fmt.Errorf("未知的存储后端: %s \n", c.Driver))
+	}
+}
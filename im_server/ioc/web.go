@@ -3,11 +3,16 @@ package ioc
 import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/web/chat_web"
 	"github.com/ink-yht/im/internal/web/file_web"
+	"github.com/ink-yht/im/internal/web/group_web"
 	"github.com/ink-yht/im/internal/web/middlewares"
 	"github.com/ink-yht/im/internal/web/user_web"
+	"github.com/ink-yht/im/internal/web/ws_web"
 	"github.com/ink-yht/im/pkg/logger"
+	"github.com/ink-yht/im/pkg/storage"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -15,14 +20,24 @@ import (
 func InitWebServer(mdls []gin.HandlerFunc,
 	userHdl *user_web.UserHandler,
 	fileHdl *file_web.FileHandler,
+	chatHdl *chat_web.ChatHandler,
+	groupHdl *group_web.GroupHandler,
+	wsHdl *ws_web.WsHandler,
+	store storage.Storage,
 
 ) *gin.Engine {
 
 	server := gin.Default()
-	server.StaticFS("uploads", http.Dir("uploads"))
+	// 本地存储只公开头像目录，聊天附件必须通过签名地址下载
+	if ls, ok := store.(*storage.LocalStorage); ok {
+		server.StaticFS(ls.BaseURL+"/avatar", http.Dir(filepath.Join(ls.Root, "avatar")))
+	}
 	server.Use(mdls...)
 	userHdl.RegisterRoutes(server)
 	fileHdl.RegisterRoutes(server)
+	chatHdl.RegisterRoutes(server)
+	groupHdl.RegisterRoutes(server)
+	wsHdl.RegisterRoutes(server)
 	return server
 }
 
@@ -35,7 +50,7 @@ func InitMiddleWares(l logger.Logger) []gin.HandlerFunc {
 		//	l.Debug("HTTP请求", logger.Field{Key: "al", Value: al})
 		//}).AllowReqBody().AllowRespBody().Build(),
 
-		middlewares.NewLoginJWTMiddlewareBuilder().IgnorePaths("/users/signup").IgnorePaths("/users/login").Build(),
+		middlewares.NewLoginJWTMiddlewareBuilder().IgnorePaths("/users/signup").IgnorePaths("/users/login").IgnorePaths("/files/download").Build(),
 
 		//ratelimit.NewBuilder(redisClient, time.Minute, 100).Build(),
 	}
@@ -9,9 +9,10 @@ func main() {
 
 	initViperV1()
 
-	server := InitWebServer()
+	app := InitApp()
+	defer app.scheduler.Stop()
 
-	err := server.Run(":8080")
+	err := app.server.Run(":8080")
 	if err != nil {
 		return
 	}
@@ -0,0 +1,147 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// tiffWithOrientation 生成只有一个 IFD 条目的 TIFF 头，条目为图片方向
+func tiffWithOrientation(order binary.ByteOrder, o uint16) []byte {
+	b := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(b, "II")
+	} else {
+		copy(b, "MM")
+	}
+	order.PutUint16(b[2:], 42)
+	order.PutUint32(b[4:], 8)
+	order.PutUint16(b[8:], 1)
+	order.PutUint16(b[10:], tagOrientation)
+	order.PutUint16(b[12:], 3) // SHORT
+	order.PutUint32(b[14:], 1)
+	order.PutUint16(b[18:], o)
+	return b
+}
+
+// jpegWithSegments 生成 SOI 之后依次带有 segs 中各个段的 JPEG 头
+func jpegWithSegments(segs ...[]byte) []byte {
+	b := []byte{0xFF, 0xD8}
+	for _, s := range segs {
+		b = append(b, s...)
+	}
+	return append(b, 0xFF, 0xD9)
+}
+
+// segment 生成一个标记为 marker 的段
+func segment(marker byte, payload []byte) []byte {
+	b := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+func exifPayload(tiff []byte) []byte {
+	return append([]byte("Exif\x00\x00"), tiff...)
+}
+
+func TestOrientation(t *testing.T) {
+	app0 := segment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	truncated := jpegWithSegments(segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, 6))))
+	truncated = truncated[:len(truncated)-8]
+	badOffset := tiffWithOrientation(binary.BigEndian, 6)
+	binary.BigEndian.PutUint32(badOffset[4:], 1000)
+	manyEntries := tiffWithOrientation(binary.LittleEndian, 6)
+	binary.LittleEndian.PutUint16(manyEntries[8:], 100)
+
+	testCases := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "小端", data: jpegWithSegments(segment(0xE1, exifPayload(tiffWithOrientation(binary.LittleEndian, 6)))), want: 6},
+		{name: "大端", data: jpegWithSegments(segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, 8)))), want: 8},
+		{name: "EXIF 在 APP0 之后", data: jpegWithSegments(app0, segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, 3)))), want: 3},
+		{name: "没有 EXIF", data: jpegWithSegments(app0), want: 1},
+		{name: "APP1 不是 EXIF", data: jpegWithSegments(segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"))), want: 1},
+		{name: "图像数据之后的 EXIF", data: jpegWithSegments(segment(0xDA, []byte{0}), segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, 6)))), want: 1},
+		{name: "字节序错误", data: jpegWithSegments(segment(0xE1, exifPayload(append([]byte("XX"), tiffWithOrientation(binary.BigEndian, 6)[2:]...)))), want: 1},
+		{name: "IFD 偏移越界", data: jpegWithSegments(segment(0xE1, exifPayload(badOffset))), want: 1},
+		{name: "条目数超过实际长度", data: jpegWithSegments(segment(0xE1, exifPayload(manyEntries))), want: 6},
+		{name: "段长度越界", data: truncated, want: 1},
+		{name: "不是 JPEG", data: []byte("\x89PNG\r\n\x1a\n"), want: 1},
+		{name: "空", data: nil, want: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Orientation(tc.data); got != tc.want {
+				t.Errorf("Orientation = %d, 期望 %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	// 左边一半为白色、右边一半为黑色的 4x2 图片
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			src.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	testCases := []struct {
+		name       string
+		o          uint16
+		wantW      int
+		wantH      int
+		wantBright image.Point // 原图左上角的像素旋转后的位置
+	}{
+		{name: "不旋转", o: 1, wantW: 4, wantH: 2, wantBright: image.Pt(0, 0)},
+		{name: "水平翻转", o: 2, wantW: 4, wantH: 2, wantBright: image.Pt(3, 0)},
+		{name: "旋转 180 度", o: 3, wantW: 4, wantH: 2, wantBright: image.Pt(3, 1)},
+		{name: "顺时针旋转 90 度", o: 6, wantW: 2, wantH: 4, wantBright: image.Pt(1, 0)},
+		{name: "逆时针旋转 90 度", o: 8, wantW: 2, wantH: 4, wantBright: image.Pt(0, 3)},
+		{name: "无效的方向", o: 9, wantW: 4, wantH: 2, wantBright: image.Pt(0, 0)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app1 := segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, tc.o)))
+			data := append(append(append([]byte{}, plain[:2]...), app1...), plain[2:]...)
+			img, format, err := Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != FormatJPEG {
+				t.Errorf("format = %q, 期望 %q", format, FormatJPEG)
+			}
+			b := img.Bounds()
+			if b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+				t.Fatalf("尺寸 = %dx%d, 期望 %dx%d", b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+			}
+			p := b.Min.Add(tc.wantBright)
+			if y := color.GrayModel.Convert(img.At(p.X, p.Y)).(color.Gray).Y; y < 0x80 {
+				t.Errorf("(%d, %d) 的亮度 = %d, 期望为白色", tc.wantBright.X, tc.wantBright.Y, y)
+			}
+		})
+	}
+}
+
+func FuzzOrientation(f *testing.F) {
+	f.Add(jpegWithSegments(segment(0xE1, exifPayload(tiffWithOrientation(binary.LittleEndian, 6)))))
+	f.Add(jpegWithSegments(segment(0xE1, exifPayload(tiffWithOrientation(binary.BigEndian, 8)))))
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x02})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		o := Orientation(data)
+		if o < 0 || o > 0xFFFF {
+			t.Errorf("Orientation = %d, 超出 SHORT 的范围", o)
+		}
+	})
+}
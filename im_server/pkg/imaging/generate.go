@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// background 生成头像的背景色
+var background = color.RGBA{R: 0xF0, G: 0xF0, B: 0xF0, A: 0xFF}
+
+// Identicon 根据种子生成 5x5 左右对称的色块头像，同一个种子总是得到同样的图片
+// 种子的 SHA-256 第一个字节决定颜色，后面 15 个字节决定左边 3 列的色块，右边 2 列镜像
+func Identicon(seed []byte, size int) image.Image {
+	sum := sha256.Sum256(seed)
+	fg := hslColor(float64(sum[0])/256*360, 0.55, 0.55)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	cell := size / 6
+	pad := (size - cell*5) / 2
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 3; x++ {
+			if sum[1+y*3+x]&1 == 0 {
+				continue
+			}
+			for _, cx := range []int{x, 4 - x} {
+				r := image.Rect(pad+cx*cell, pad+y*cell, pad+(cx+1)*cell, pad+(y+1)*cell)
+				draw.Draw(dst, r, image.NewUniform(fg), image.Point{}, draw.Src)
+			}
+		}
+	}
+	return dst
+}
+
+// Grid 把最多 9 张图片拼成九宫格：1 张铺满，2 到 4 张两列，5 到 9 张三列，排不满的一行放在最上面并居中
+func Grid(imgs []image.Image, size int) image.Image {
+	if len(imgs) > 9 {
+		imgs = imgs[:9]
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	n := len(imgs)
+	if n == 0 {
+		return dst
+	}
+
+	cols := 3
+	switch {
+	case n == 1:
+		cols = 1
+	case n <= 4:
+		cols = 2
+	}
+	rows := (n + cols - 1) / cols
+	gap := size / 40
+	if n == 1 {
+		gap = 0
+	}
+	cell := (size - gap*(cols+1)) / cols
+	top := (size - rows*cell - (rows-1)*gap) / 2
+
+	i := 0
+	for row := 0; row < rows; row++ {
+		count := cols
+		if row == 0 && n%cols != 0 {
+			count = n % cols
+		}
+		left := (size - count*cell - (count-1)*gap) / 2
+		y := top + row*(cell+gap)
+		for c := 0; c < count; c++ {
+			x := left + c*(cell+gap)
+			sq := Square(imgs[i], cell)
+			r := image.Rect(x, y, x+cell, y+cell)
+			draw.Draw(dst, r, sq, sq.Bounds().Min, draw.Src)
+			i++
+		}
+	}
+	return dst
+}
+
+// hslColor HSL 转 RGB，h 取值 [0, 360)，s 和 l 取值 [0, 1]
+func hslColor(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g = c, x
+	case hp < 2:
+		r, g = x, c
+	case hp < 3:
+		g, b = c, x
+	case hp < 4:
+		g, b = x, c
+	case hp < 5:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	return color.RGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 0xFF}
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestIdenticon(t *testing.T) {
+	testCases := []struct {
+		name string
+		seed string
+		size int
+	}{
+		{name: "用户1", seed: "user:1", size: 120},
+		{name: "用户2", seed: "user:2", size: 120},
+		{name: "尺寸不能被 6 整除", seed: "user:3", size: 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Identicon([]byte(tc.seed), tc.size).(*image.RGBA)
+			b := Identicon([]byte(tc.seed), tc.size).(*image.RGBA)
+			if a.Bounds().Dx() != tc.size || a.Bounds().Dy() != tc.size {
+				t.Fatalf("尺寸 = %v, 期望 %dx%d", a.Bounds(), tc.size, tc.size)
+			}
+			if string(a.Pix) != string(b.Pix) {
+				t.Error("同一个种子生成的头像不一致")
+			}
+			// 左右对称
+			for y := 0; y < tc.size; y++ {
+				for x := 0; x < tc.size/2; x++ {
+					if a.RGBAAt(x, y) != a.RGBAAt(tc.size-1-x, y) {
+						t.Fatalf("(%d, %d) 和 (%d, %d) 不对称", x, y, tc.size-1-x, y)
+					}
+				}
+			}
+		})
+	}
+
+	a := Identicon([]byte("user:1"), 120).(*image.RGBA)
+	b := Identicon([]byte("user:2"), 120).(*image.RGBA)
+	if string(a.Pix) == string(b.Pix) {
+		t.Error("不同的种子生成了同样的头像")
+	}
+}
+
+func TestGrid(t *testing.T) {
+	// 成员头像通常比格子大，缩放后铺满格子
+	tile := func() image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		for i := range img.Pix {
+			img.Pix[i] = 0xFF
+		}
+		return img
+	}
+	testCases := []struct {
+		name string
+		n    int
+		// 应该被图片覆盖的点，和只有背景色的点
+		covered []image.Point
+		empty   []image.Point
+	}{
+		{name: "没有图片", n: 0, empty: []image.Point{{60, 60}}},
+		{name: "1 张铺满", n: 1, covered: []image.Point{{0, 0}, {119, 119}}},
+		{name: "2 张一行居中", n: 2, covered: []image.Point{{30, 60}, {90, 60}}, empty: []image.Point{{60, 10}, {60, 110}}},
+		{name: "3 张两列第一行居中", n: 3, covered: []image.Point{{60, 30}, {30, 90}, {90, 90}}, empty: []image.Point{{10, 30}, {110, 30}}},
+		{name: "5 张排不满的一行在上面居中", n: 5, covered: []image.Point{{40, 40}, {80, 40}, {20, 80}, {60, 80}, {100, 80}}, empty: []image.Point{{10, 40}, {110, 40}}},
+		{name: "9 张", n: 9, covered: []image.Point{{20, 20}, {60, 60}, {100, 100}}},
+		{name: "超过 9 张只取前 9 张", n: 12, covered: []image.Point{{20, 20}, {100, 100}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imgs := make([]image.Image, tc.n)
+			for i := range imgs {
+				imgs[i] = tile()
+			}
+			dst := Grid(imgs, 120).(*image.RGBA)
+			if dst.Bounds().Dx() != 120 || dst.Bounds().Dy() != 120 {
+				t.Fatalf("尺寸 = %v, 期望 120x120", dst.Bounds())
+			}
+			for _, p := range tc.covered {
+				if c := dst.RGBAAt(p.X, p.Y); c != (color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}) {
+					t.Errorf("(%d, %d) = %v, 期望被图片覆盖", p.X, p.Y, c)
+				}
+			}
+			for _, p := range tc.empty {
+				if c := dst.RGBAAt(p.X, p.Y); c != background {
+					t.Errorf("(%d, %d) = %v, 期望为背景色", p.X, p.Y, c)
+				}
+			}
+		})
+	}
+}
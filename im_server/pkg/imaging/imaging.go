@@ -0,0 +1,125 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// 支持的图片格式，和 image.Decode 返回的格式名一致
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// ErrFormat 不是支持的图片格式或者图片已损坏
+var ErrFormat = errors.New("imaging: 不支持的图片格式")
+
+// Config 不解码像素，只读取图片的格式和尺寸，用来在解码前拒绝尺寸过大的图片
+func Config(data []byte) (image.Config, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, "", ErrFormat
+	}
+	return cfg, format, nil
+}
+
+// Decode 解码图片，JPEG 会按照 EXIF 中的方向旋转，返回的图片方向和用户看到的一致
+// GIF 只解码第一帧
+func Decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrFormat
+	}
+	if format == FormatJPEG {
+		img = orient(img, Orientation(data))
+	}
+	return img, format, nil
+}
+
+// Encode 编码图片，只支持 JPEG 和 PNG，编码结果不带任何元数据
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+	return ErrFormat
+}
+
+// Fit 等比缩小到长边不超过 size，图片本身更小时原样返回
+func Fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+	return scale(img, b, w, h)
+}
+
+// Square 从中间裁剪出正方形并缩放到 size，图片本身更小时只裁剪不放大
+func Square(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return scale(img, image.Rect(x0, y0, x0+side, y0+side), min(side, size), min(side, size))
+}
+
+func scale(img image.Image, src image.Rectangle, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// orient 按照 EXIF 方向值旋转和翻转图片，取值含义见 EXIF 规范中的 Orientation 标签
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if o >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch o {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180 度
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿左上到右下的对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90 度
+				dx, dy = w-1-y, x
+			case 7: // 沿右上到左下的对角线翻转
+				dx, dy = w-1-y, h-1-x
+			case 8: // 逆时针旋转 90 度
+				dx, dy = y, h-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		w, h  int
+		size  int
+		wantW int
+		wantH int
+	}{
+		{name: "横图", w: 400, h: 200, size: 100, wantW: 100, wantH: 50},
+		{name: "竖图", w: 200, h: 400, size: 100, wantW: 50, wantH: 100},
+		{name: "正方形", w: 300, h: 300, size: 100, wantW: 100, wantH: 100},
+		{name: "长条图短边至少 1 像素", w: 1000, h: 1, size: 100, wantW: 100, wantH: 1},
+		{name: "小图不放大", w: 80, h: 60, size: 100, wantW: 80, wantH: 60},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := Fit(image.NewRGBA(image.Rect(0, 0, tc.w, tc.h)), tc.size).Bounds()
+			if b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+				t.Errorf("尺寸 = %dx%d, 期望 %dx%d", b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+			}
+		})
+	}
+}
+
+func TestSquare(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rect     image.Rectangle
+		size     int
+		wantSide int
+	}{
+		{name: "横图", rect: image.Rect(0, 0, 400, 200), size: 100, wantSide: 100},
+		{name: "竖图", rect: image.Rect(0, 0, 200, 400), size: 100, wantSide: 100},
+		{name: "小图只裁剪", rect: image.Rect(0, 0, 80, 60), size: 100, wantSide: 60},
+		{name: "起点不为 0", rect: image.Rect(10, 20, 110, 70), size: 100, wantSide: 50},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := Square(image.NewRGBA(tc.rect), tc.size).Bounds()
+			if b.Dx() != tc.wantSide || b.Dy() != tc.wantSide {
+				t.Errorf("尺寸 = %dx%d, 期望 %dx%d", b.Dx(), b.Dy(), tc.wantSide, tc.wantSide)
+			}
+		})
+	}
+}
+
+func TestSquareCenter(t *testing.T) {
+	// 中间一列为白色的 3x1 图片，裁剪后只剩中间的像素
+	src := image.NewGray(image.Rect(0, 0, 3, 1))
+	src.SetGray(1, 0, color.Gray{Y: 0xFF})
+	img := Square(src, 1)
+	if y := color.GrayModel.Convert(img.At(0, 0)).(color.Gray).Y; y != 0xFF {
+		t.Errorf("裁剪后的亮度 = %d, 期望 255", y)
+	}
+}
+
+func TestEncodeConfig(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 30, 20))
+	testCases := []struct {
+		name    string
+		format  string
+		wantErr error
+	}{
+		{name: "JPEG", format: FormatJPEG},
+		{name: "PNG", format: FormatPNG},
+		{name: "不支持编码 GIF", format: FormatGIF, wantErr: ErrFormat},
+		{name: "不支持编码 WebP", format: FormatWebP, wantErr: ErrFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Encode(&buf, img, tc.format, 80)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			cfg, format, err := Config(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tc.format || cfg.Width != 30 || cfg.Height != 20 {
+				t.Errorf("Config = %s %dx%d, 期望 %s 30x20", format, cfg.Width, cfg.Height, tc.format)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var gifBuf bytes.Buffer
+	pal := image.NewPaletted(image.Rect(0, 0, 4, 3), color.Palette{color.Black, color.White})
+	if err := gif.Encode(&gifBuf, pal, nil); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name       string
+		data       []byte
+		wantFormat string
+		wantErr    error
+	}{
+		{name: "GIF", data: gifBuf.Bytes(), wantFormat: FormatGIF},
+		{name: "不是图片", data: []byte("plain text"), wantErr: ErrFormat},
+		{name: "截断的 GIF", data: gifBuf.Bytes()[:16], wantErr: ErrFormat},
+		{name: "空", data: nil, wantErr: ErrFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, format, err := Decode(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tc.wantErr)
+			}
+			if format != tc.wantFormat {
+				t.Errorf("format = %q, 期望 %q", format, tc.wantFormat)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// vp8xMetadataFlags VP8X 头中表示带有 EXIF 和 XMP 元数据的标志位
+const vp8xMetadataFlags = 0x08 | 0x04
+
+// StripWebP 去掉 WebP 中的 EXIF 和 XMP 元数据块，其他内容原样保留
+// 标准库没有 WebP 编码器，不能像 JPEG 和 PNG 那样通过重新编码去掉元数据
+func StripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrFormat
+	}
+	var out bytes.Buffer
+	out.Write(data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrFormat
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		// 块的长度为奇数时后面有一个填充字节
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil, ErrFormat
+		}
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := bytes.Clone(data[i:end])
+			if len(chunk) > 8 {
+				chunk[8] &^= vp8xMetadataFlags
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	b := out.Bytes()
+	binary.LittleEndian.PutUint32(b[4:8], uint32(len(b)-8))
+	return b, nil
+}
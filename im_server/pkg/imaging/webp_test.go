@@ -0,0 +1,110 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// chunk 生成一个 RIFF 块，长度为奇数时补一个填充字节
+func chunk(fourCC string, payload []byte) []byte {
+	b := make([]byte, 8, 8+len(payload)+1)
+	copy(b, fourCC)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(payload)))
+	b = append(b, payload...)
+	if len(payload)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// webpFile 把块拼成 WebP 文件并写入 RIFF 长度
+func webpFile(chunks ...[]byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WEBP")
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(b)-8))
+	return b
+}
+
+func TestStripWebP(t *testing.T) {
+	vp8x := func(flags byte) []byte {
+		return chunk("VP8X", []byte{flags, 0, 0, 0, 9, 0, 0, 9, 0, 0})
+	}
+	vp8 := chunk("VP8 ", []byte("frame"))
+	iccp := chunk("ICCP", []byte("profile"))
+	exif := chunk("EXIF", []byte("Exif\x00\x00MM"))
+	xmp := chunk("XMP ", []byte("<x:xmpmeta/>"))
+	truncated := webpFile(vp8)
+	truncated = truncated[:len(truncated)-2]
+	oversized := webpFile(vp8)
+	binary.LittleEndian.PutUint32(oversized[16:], 1<<31)
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{name: "没有元数据", data: webpFile(vp8), want: webpFile(vp8)},
+		{name: "去掉 EXIF 和 XMP", data: webpFile(vp8x(0x0C), vp8, exif, xmp), want: webpFile(vp8x(0), vp8)},
+		{name: "保留其他标志位", data: webpFile(vp8x(0x3E), iccp, vp8, exif), want: webpFile(vp8x(0x32), iccp, vp8)},
+		{name: "奇数长度的块", data: webpFile(vp8x(0x08), chunk("EXIF", []byte("odd")), vp8), want: webpFile(vp8x(0), vp8)},
+		{name: "不是 WebP", data: []byte("RIFF\x04\x00\x00\x00WAVE"), wantErr: ErrFormat},
+		{name: "太短", data: []byte("RIFF"), wantErr: ErrFormat},
+		{name: "块头不完整", data: append(webpFile(vp8), 'V', 'P'), wantErr: ErrFormat},
+		{name: "块长度越界", data: truncated, wantErr: ErrFormat},
+		{name: "块长度过大", data: oversized, wantErr: ErrFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := StripWebP(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tc.wantErr)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("StripWebP = %q, 期望 %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func FuzzStripWebP(f *testing.F) {
+	f.Add(webpFile(chunk("VP8 ", []byte("frame"))))
+	f.Add(webpFile(chunk("VP8X", make([]byte, 10)), chunk("EXIF", []byte("odd")), chunk("XMP ", nil)))
+	f.Add([]byte("RIFF\xff\xff\xff\xffWEBPEXIF\xff\xff\xff\xff"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := StripWebP(data)
+		if err != nil {
+			return
+		}
+		if len(out) > len(data) {
+			t.Fatalf("输出 %d 字节, 比输入的 %d 字节更长", len(out), len(data))
+		}
+		if size := binary.LittleEndian.Uint32(out[4:8]); int(size) != len(out)-8 {
+			t.Fatalf("RIFF 长度 = %d, 期望 %d", size, len(out)-8)
+		}
+		// 去掉元数据之后的结果再处理一次不应该有变化
+		again, err := StripWebP(out)
+		if err != nil {
+			t.Fatalf("再次处理失败: %v", err)
+		}
+		if !bytes.Equal(again, out) {
+			t.Fatalf("再次处理的结果不一致")
+		}
+		for i := 12; i+8 <= len(out); {
+			switch string(out[i : i+4]) {
+			case "EXIF", "XMP ":
+				t.Fatalf("偏移 %d 处仍然有 %q 块", i, out[i:i+4])
+			case "VP8X":
+				if i+8 < len(out) && out[i+8]&vp8xMetadataFlags != 0 {
+					t.Fatalf("VP8X 仍然带有元数据标志位")
+				}
+			}
+			size := int(binary.LittleEndian.Uint32(out[i+4:]))
+			i += 8 + size + size%2
+		}
+	})
+}
@@ -13,3 +13,10 @@ func Error(key string, err error) Field {
 		Value: err,
 	}
 }
+
+func Int64(key string, val int64) Field {
+	return Field{
+		Key:   key,
+		Value: val,
+	}
+}
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// adtsRates ADTS 头中采样率索引对应的采样率
+var adtsRates = []int64{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// probeADTS 解析 ADTS 封装的 AAC，逐帧读取帧头，每个原始数据块 1024 个采样
+func probeADTS(r io.ReaderAt, size int64) (Info, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	if err := skipID3(br); err != nil {
+		return Info{}, err
+	}
+	var (
+		total int64
+		rate  int64
+		head  = make([]byte, 7)
+	)
+	for {
+		if _, err := io.ReadFull(br, head); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return Info{}, err
+		}
+		if head[0] != 0xFF || head[1]&0xF6 != 0xF0 {
+			break
+		}
+		idx := int(head[2]>>2) & 0x0F
+		if idx >= len(adtsRates) {
+			return Info{}, ErrFormat
+		}
+		rate = adtsRates[idx]
+		frameLen := int(head[3]&0x03)<<11 | int(head[4])<<3 | int(head[5])>>5
+		if frameLen < 7 {
+			return Info{}, ErrFormat
+		}
+		total += int64(head[6]&0x03+1) * 1024
+		if _, err := br.Discard(frameLen - 7); err != nil {
+			break
+		}
+	}
+	return Info{Duration: samples(total, rate)}, nil
+}
+
+// skipID3 跳过文件开头的 ID3v2 标签，长度为 4 个字节的同步安全整数，每个字节只用低 7 位
+func skipID3(br *bufio.Reader) error {
+	head, err := br.Peek(10)
+	if err != nil || string(head[:3]) != "ID3" {
+		return nil
+	}
+	size := int(head[6]&0x7F)<<21 | int(head[7]&0x7F)<<14 | int(head[8]&0x7F)<<7 | int(head[9]&0x7F)
+	size += 10
+	// 标志位中的 footer 表示标签末尾还有 10 个字节
+	if head[5]&0x10 != 0 {
+		size += 10
+	}
+	if _, err = br.Discard(size); err != nil {
+		return ErrFormat
+	}
+	return nil
+}
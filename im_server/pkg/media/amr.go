@@ -0,0 +1,44 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+)
+
+// amrFrame 每一帧 20 毫秒
+const amrFrame = 20 * time.Millisecond
+
+// 各种编码模式下一帧的字节数，包含 1 个字节的帧头，按帧头中的 FT 索引
+var (
+	amrNBSizes = []int{13, 14, 16, 18, 20, 21, 27, 32, 6, 1, 1, 1, 1, 1, 1, 1}
+	amrWBSizes = []int{18, 24, 33, 37, 41, 47, 51, 59, 61, 6, 1, 1, 1, 1, 1, 1}
+)
+
+// probeAMR 解析 AMR-NB 和 AMR-WB 单声道文件，时长为帧数乘以 20 毫秒
+func probeAMR(r io.ReaderAt, size int64) (Info, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	sizes := amrNBSizes
+	magic := []byte("#!AMR\n")
+	if head, _ := br.Peek(9); bytes.Equal(head, []byte("#!AMR-WB\n")) {
+		sizes, magic = amrWBSizes, head
+	}
+	if head, _ := br.Peek(len(magic)); !bytes.Equal(head, magic) {
+		return Info{}, ErrFormat
+	}
+	_, _ = br.Discard(len(magic))
+
+	var frames int64
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			break
+		}
+		if _, err = br.Discard(sizes[(b>>3)&0x0F] - 1); err != nil {
+			break
+		}
+		frames++
+	}
+	return Info{Duration: time.Duration(frames) * amrFrame}, nil
+}
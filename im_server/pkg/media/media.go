@@ -0,0 +1,56 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrFormat 不支持的容器格式，或者文件已损坏、读不到时长
+var ErrFormat = errors.New("media: 无法解析的音视频格式")
+
+// Info 从容器中读取的音视频信息，音频的宽高为 0
+type Info struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// Probe 根据识别出的 MIME 类型解析容器，读取时长，视频还会读取画面尺寸
+// 只读取容器的索引和帧头，不解码音视频数据
+func Probe(r io.ReaderAt, size int64, mimeType string) (Info, error) {
+	var (
+		info Info
+		err  error
+	)
+	switch mimeType {
+	case "video/mp4", "video/quicktime", "audio/mp4":
+		info, err = probeMP4(r, size)
+	case "video/webm", "audio/webm":
+		info, err = probeWebM(r, size)
+	case "application/ogg", "audio/ogg":
+		info, err = probeOgg(r, size)
+	case "audio/aac":
+		info, err = probeADTS(r, size)
+	case "audio/amr":
+		info, err = probeAMR(r, size)
+	case "audio/mpeg":
+		info, err = probeMP3(r, size)
+	case "audio/wave", "audio/wav", "audio/x-wav":
+		info, err = probeWAV(r, size)
+	default:
+		return Info{}, ErrFormat
+	}
+	if err != nil || info.Duration <= 0 {
+		return Info{}, ErrFormat
+	}
+	return info, nil
+}
+
+// samples 采样数换算为时长
+func samples(n, rate int64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(n * int64(time.Second) / rate)
+}
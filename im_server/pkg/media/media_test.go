@@ -0,0 +1,382 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// be32 32 位大端整数
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+// le32 32 位小端整数
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// cat 拼接多段数据
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// mp4Box 生成一个 ISO BMFF 盒子
+func mp4Box(typ string, parts ...[]byte) []byte {
+	body := cat(parts...)
+	return cat(be32(uint32(8+len(body))), []byte(typ), body)
+}
+
+// mvhd0 版本 0 的 mvhd，只填写时间单位和时长
+func mvhd0(timescale, duration uint32) []byte {
+	return mp4Box("mvhd", make([]byte, 12), be32(timescale), be32(duration), make([]byte, 80))
+}
+
+// tkhd0 版本 0 的 tkhd，rotate 为 true 时写入旋转 90 度的变换矩阵
+func tkhd0(w, h uint32, rotate bool) []byte {
+	matrix := make([]byte, 36)
+	if rotate {
+		copy(matrix[4:], be32(0x00010000))
+		copy(matrix[12:], be32(0xFFFF0000))
+	} else {
+		copy(matrix[0:], be32(0x00010000))
+		copy(matrix[16:], be32(0x00010000))
+	}
+	copy(matrix[32:], be32(0x40000000))
+	return mp4Box("tkhd", make([]byte, 4+20+16), matrix, be32(w<<16), be32(h<<16))
+}
+
+// ebml 生成一个 EBML 元素，长度用 8 个字节表示
+func ebml(id uint32, parts ...[]byte) []byte {
+	body := cat(parts...)
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	return cat(ebmlID(id), size, body)
+}
+
+// ebmlUnknown 生成长度未知的 EBML 元素
+func ebmlUnknown(id uint32, parts ...[]byte) []byte {
+	return cat(ebmlID(id), []byte{0xFF}, cat(parts...))
+}
+
+func ebmlID(id uint32) []byte {
+	b := be32(id)
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func ebmlUint(id uint32, v uint64) []byte {
+	return ebml(id, binary.BigEndian.AppendUint64(nil, v))
+}
+
+func ebmlFloat(id uint32, v float64) []byte {
+	return ebml(id, binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+
+// simpleBlock 轨道 1 上相对时间戳为 tc 的帧
+func simpleBlock(tc int16) []byte {
+	return ebml(idSimpleBlock, []byte{0x81}, binary.BigEndian.AppendUint16(nil, uint16(tc)), []byte{0x80, 0})
+}
+
+// webmVideo 带有 Info 和一个视频轨道的 WebM
+func webmVideo(info []byte, w, h uint64, rest ...[]byte) []byte {
+	tracks := ebml(idTracks, ebml(idTrackEntry, ebml(idVideo, ebmlUint(idPixelWidth, w), ebmlUint(idPixelHeight, h))))
+	return cat(ebml(idEBML, ebmlUint(0x4282, 0)), ebml(idSegment, info, tracks, cat(rest...)))
+}
+
+// oggPage 生成一个只有一个数据段的 Ogg 页
+func oggPage(serial uint32, granule int64, body []byte) []byte {
+	head := make([]byte, 27)
+	copy(head, "OggS")
+	binary.LittleEndian.PutUint64(head[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(head[14:], serial)
+	head[26] = 1
+	return cat(head, []byte{byte(len(body))}, body)
+}
+
+func opusHead(preSkip uint16) []byte {
+	return cat([]byte("OpusHead\x01\x01"), binary.LittleEndian.AppendUint16(nil, preSkip), le32(48000), make([]byte, 3))
+}
+
+func vorbisHead(rate uint32) []byte {
+	return cat([]byte("\x01vorbis"), le32(0), []byte{2}, le32(rate), make([]byte, 13))
+}
+
+// adtsFrame 采样率索引为 srIdx、数据长度为 payload 的 ADTS 帧
+func adtsFrame(srIdx byte, payload int) []byte {
+	n := 7 + payload
+	b := make([]byte, n)
+	b[0], b[1] = 0xFF, 0xF1
+	b[2] = 0x40 | srIdx<<2
+	b[3] = byte(n >> 11 & 0x03)
+	b[4] = byte(n >> 3)
+	b[5] = byte(n<<5) | 0x1F
+	b[6] = 0xFC
+	return b
+}
+
+// mp3Frames n 个 MPEG-1 Layer III、128kbps、44.1kHz 的帧，每帧 417 字节
+func mp3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// amrFrames n 个 AMR-NB 12.2kbps 的帧
+func amrFrames(n int) []byte {
+	frame := make([]byte, 32)
+	frame[0] = 7<<3 | 0x04
+	return bytes.Repeat(frame, n)
+}
+
+// wavFile byteRate 为每秒字节数，dataSize 为 data 块声明的长度，data 为实际写入的数据
+func wavFile(byteRate uint32, dataSize uint32, data []byte) []byte {
+	fmtChunk := cat([]byte("fmt "), le32(16), []byte{1, 0, 1, 0}, le32(byteRate/2), le32(byteRate), []byte{2, 0, 16, 0})
+	body := cat([]byte("WAVE"), fmtChunk, []byte("data"), le32(dataSize), data)
+	return cat([]byte("RIFF"), le32(uint32(len(body))), body)
+}
+
+func TestProbe(t *testing.T) {
+	mdat := mp4Box("mdat", make([]byte, 64))
+	trak := func(w, h uint32, rotate bool) []byte {
+		return mp4Box("trak", tkhd0(w, h, rotate))
+	}
+	largeMdat := cat(be32(1), []byte("mdat"), binary.BigEndian.AppendUint64(nil, 16+8), make([]byte, 8))
+	mvhd1 := mp4Box("mvhd", []byte{1, 0, 0, 0}, make([]byte, 16), be32(1000), binary.BigEndian.AppendUint64(nil, 2500), make([]byte, 80))
+	fragmented := mp4Box("moov", mvhd0(1000, 0), mp4Box("mvex", mp4Box("mehd", make([]byte, 4), be32(4000))))
+
+	cluster := func(tc uint64, blocks ...int16) []byte {
+		parts := [][]byte{ebmlUint(idTimecode, tc)}
+		for _, b := range blocks {
+			parts = append(parts, simpleBlock(b))
+		}
+		return ebmlUnknown(idCluster, parts...)
+	}
+	noDuration := ebml(idInfo, ebmlUint(idTimecodeScale, 1000000))
+	recorded := cat(ebml(idEBML, ebmlUint(0x4282, 0)), ebmlUnknown(idSegment, noDuration, cluster(0, 0, 500), cluster(1000, 0, 1500)))
+
+	id3 := cat([]byte("ID3\x04\x00\x00\x00\x00\x00\x0A"), make([]byte, 10))
+
+	testCases := []struct {
+		name     string
+		mimeType string
+		data     []byte
+		want     Info
+		wantErr  error
+	}{
+		{
+			name:     "MP4 视频",
+			mimeType: "video/mp4",
+			data:     cat(mp4Box("ftyp", []byte("isom")), mp4Box("moov", mvhd0(600, 1800), trak(1920, 1080, false)), mdat),
+			want:     Info{Duration: 3 * time.Second, Width: 1920, Height: 1080},
+		},
+		{
+			name:     "MOV 竖拍视频交换宽高",
+			mimeType: "video/quicktime",
+			data:     cat(mp4Box("ftyp", []byte("qt  ")), mdat, mp4Box("moov", mvhd0(1000, 1500), trak(1920, 1080, true))),
+			want:     Info{Duration: 1500 * time.Millisecond, Width: 1080, Height: 1920},
+		},
+		{
+			name:     "M4A 第一个轨道是音频",
+			mimeType: "audio/mp4",
+			data:     cat(mp4Box("ftyp", []byte("M4A ")), mp4Box("moov", mvhd0(44100, 44100), trak(0, 0, false))),
+			want:     Info{Duration: time.Second},
+		},
+		{
+			name:     "MP4 版本 1 的 mvhd 和 64 位长度的 mdat",
+			mimeType: "video/mp4",
+			data:     cat(largeMdat, mp4Box("moov", mvhd1)),
+			want:     Info{Duration: 2500 * time.Millisecond},
+		},
+		{
+			name:     "分片 MP4 从 mehd 读取时长",
+			mimeType: "video/mp4",
+			data:     cat(mp4Box("ftyp", []byte("iso6")), fragmented),
+			want:     Info{Duration: 4 * time.Second},
+		},
+		{
+			name:     "MP4 没有 moov",
+			mimeType: "video/mp4",
+			data:     cat(mp4Box("ftyp", []byte("isom")), mdat),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "MP4 盒子长度越界",
+			mimeType: "video/mp4",
+			data:     cat(be32(1000), []byte("moov"), mvhd0(1000, 1000)),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "WebM 从 Info 读取时长",
+			mimeType: "video/webm",
+			data:     webmVideo(ebml(idInfo, ebmlUint(idTimecodeScale, 1000000), ebmlFloat(idDuration, 2500)), 640, 480),
+			want:     Info{Duration: 2500 * time.Millisecond, Width: 640, Height: 480},
+		},
+		{
+			name:     "WebM 没有时长时扫描帧时间戳",
+			mimeType: "audio/webm",
+			data:     recorded,
+			want:     Info{Duration: 2500 * time.Millisecond},
+		},
+		{
+			name:     "WebM 画面尺寸超出范围",
+			mimeType: "video/webm",
+			data:     webmVideo(ebml(idInfo, ebmlFloat(idDuration, 2500)), 640, math.MaxUint64),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "WebM 不是 EBML",
+			mimeType: "video/webm",
+			data:     []byte("not a webm file"),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "Opus 减去预跳过采样数",
+			mimeType: "audio/ogg",
+			data:     cat(oggPage(7, 0, opusHead(312)), oggPage(7, 0, []byte("OpusTags")), oggPage(7, 48000, []byte{0}), oggPage(7, 96312, []byte{0})),
+			want:     Info{Duration: 2 * time.Second},
+		},
+		{
+			name:     "Vorbis 忽略其他逻辑流",
+			mimeType: "application/ogg",
+			data:     cat(oggPage(1, 0, vorbisHead(44100)), oggPage(1, 88200, []byte{0}), oggPage(2, 441000, []byte{0}), oggPage(1, -1, []byte{0})),
+			want:     Info{Duration: 2 * time.Second},
+		},
+		{
+			name:     "Ogg 不认识的编码",
+			mimeType: "audio/ogg",
+			data:     cat(oggPage(1, 0, []byte("Speex   ")), oggPage(1, 8000, []byte{0})),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "AAC 每帧 1024 个采样",
+			mimeType: "audio/aac",
+			data:     cat(id3, bytes.Repeat(adtsFrame(11, 10), 16)),
+			want:     Info{Duration: 2048 * time.Millisecond},
+		},
+		{
+			name:     "AAC 采样率索引无效",
+			mimeType: "audio/aac",
+			data:     adtsFrame(13, 10),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "AMR-NB 每帧 20 毫秒",
+			mimeType: "audio/amr",
+			data:     cat([]byte("#!AMR\n"), amrFrames(150)),
+			want:     Info{Duration: 3 * time.Second},
+		},
+		{
+			name:     "AMR-WB",
+			mimeType: "audio/amr",
+			data:     cat([]byte("#!AMR-WB\n"), bytes.Repeat(cat([]byte{8<<3 | 0x04}, make([]byte, 60)), 50)),
+			want:     Info{Duration: time.Second},
+		},
+		{
+			name:     "AMR 缺少文件头",
+			mimeType: "audio/amr",
+			data:     amrFrames(50),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "MP3 跳过 ID3 标签和末尾的 ID3v1",
+			mimeType: "audio/mpeg",
+			data:     cat(id3, mp3Frames(100), []byte("TAG"), make([]byte, 125)),
+			want:     Info{Duration: samples(100*1152, 44100)},
+		},
+		{
+			name:     "MP3 帧之间有无法识别的数据",
+			mimeType: "audio/mpeg",
+			data:     cat(mp3Frames(50), []byte("junk"), mp3Frames(50)),
+			want:     Info{Duration: samples(100*1152, 44100)},
+		},
+		{
+			name:     "MP3 没有有效的帧",
+			mimeType: "audio/mpeg",
+			data:     bytes.Repeat([]byte{0xFF, 0xFF, 0xFF, 0xFF}, 100),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "WAV",
+			mimeType: "audio/wav",
+			data:     wavFile(16000, 32000, make([]byte, 32000)),
+			want:     Info{Duration: 2 * time.Second},
+		},
+		{
+			name:     "WAV 录音中没有写入长度",
+			mimeType: "audio/x-wav",
+			data:     wavFile(16000, 0xFFFFFFFF, make([]byte, 8000)),
+			want:     Info{Duration: 500 * time.Millisecond},
+		},
+		{
+			name:     "WAV 没有 fmt 块",
+			mimeType: "audio/wave",
+			data:     cat([]byte("RIFF"), le32(12), []byte("WAVEdata"), le32(0)),
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "空文件",
+			mimeType: "audio/wav",
+			wantErr:  ErrFormat,
+		},
+		{
+			name:     "不支持的类型",
+			mimeType: "video/x-msvideo",
+			data:     cat([]byte("RIFF"), le32(4), []byte("AVI ")),
+			wantErr:  ErrFormat,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := Probe(bytes.NewReader(tc.data), int64(len(tc.data)), tc.mimeType)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, 期望 %v", err, tc.wantErr)
+			}
+			if info != tc.want {
+				t.Errorf("Probe = %+v, 期望 %+v", info, tc.want)
+			}
+		})
+	}
+}
+
+// probeMimes Probe 支持的所有类型，模糊测试按下标选择
+var probeMimes = []string{
+	"video/mp4", "video/quicktime", "audio/mp4",
+	"video/webm", "audio/webm",
+	"application/ogg", "audio/ogg",
+	"audio/aac", "audio/amr", "audio/mpeg",
+	"audio/wave", "audio/wav", "audio/x-wav",
+}
+
+func FuzzProbe(f *testing.F) {
+	f.Add(uint8(0), cat(mp4Box("ftyp", []byte("isom")), mp4Box("moov", mvhd0(600, 1800), mp4Box("trak", tkhd0(1920, 1080, true)))))
+	f.Add(uint8(3), webmVideo(ebml(idInfo, ebmlFloat(idDuration, 2500)), 640, 480))
+	f.Add(uint8(4), cat(ebml(idEBML), ebmlUnknown(idSegment, ebmlUnknown(idCluster, ebmlUint(idTimecode, 10), simpleBlock(20)))))
+	f.Add(uint8(6), cat(oggPage(7, 0, opusHead(312)), oggPage(7, 96312, []byte{0})))
+	f.Add(uint8(5), cat(oggPage(1, 0, vorbisHead(44100)), oggPage(1, 88200, []byte{0})))
+	f.Add(uint8(7), bytes.Repeat(adtsFrame(4, 10), 4))
+	f.Add(uint8(8), cat([]byte("#!AMR\n"), amrFrames(5)))
+	f.Add(uint8(9), mp3Frames(3))
+	f.Add(uint8(11), wavFile(16000, 3200, make([]byte, 3200)))
+	f.Fuzz(func(t *testing.T, idx uint8, data []byte) {
+		mimeType := probeMimes[int(idx)%len(probeMimes)]
+		info, err := Probe(bytes.NewReader(data), int64(len(data)), mimeType)
+		if err != nil {
+			if info != (Info{}) {
+				t.Fatalf("失败时返回了 %+v", info)
+			}
+			return
+		}
+		if info.Duration <= 0 {
+			t.Fatalf("时长 = %v, 期望大于 0", info.Duration)
+		}
+		if info.Width < 0 || info.Height < 0 {
+			t.Fatalf("画面尺寸 = %dx%d, 期望不小于 0", info.Width, info.Height)
+		}
+	})
+}
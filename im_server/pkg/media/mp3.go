@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bufio"
+	"io"
+)
+
+// MPEG 音频帧头中比特率索引对应的比特率（kbps）
+var (
+	mpeg1L2Bitrates = []int{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384}
+	mpeg1L3Bitrates = []int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates   = []int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// mpegRates MPEG-1、MPEG-2、MPEG-2.5 的采样率
+var mpegRates = [][]int{{44100, 48000, 32000}, {22050, 24000, 16000}, {11025, 12000, 8000}}
+
+// probeMP3 解析 MPEG 音频 Layer II 和 Layer III，逐帧读取帧头累计采样数，兼容可变比特率
+// 遇到无法识别的数据时逐字节向后查找下一个帧头
+func probeMP3(r io.ReaderAt, size int64) (Info, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	if err := skipID3(br); err != nil {
+		return Info{}, err
+	}
+	var total, rate int64
+	for {
+		head, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+		// 文件末尾的 ID3v1 标签
+		if string(head[:3]) == "TAG" {
+			break
+		}
+		n, count, sr := mpegFrame(head)
+		if n == 0 {
+			_, _ = br.Discard(1)
+			continue
+		}
+		total += int64(count)
+		rate = int64(sr)
+		if _, err = br.Discard(n); err != nil {
+			break
+		}
+	}
+	return Info{Duration: samples(total, rate)}, nil
+}
+
+// mpegFrame 解析帧头，返回帧长度、采样数和采样率，不是有效的帧头时帧长度为 0
+func mpegFrame(h []byte) (int, int, int) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return 0, 0, 0
+	}
+	var v int // 0 MPEG-1，1 MPEG-2，2 MPEG-2.5
+	switch (h[1] >> 3) & 0x03 {
+	case 3:
+		v = 0
+	case 2:
+		v = 1
+	case 0:
+		v = 2
+	default:
+		return 0, 0, 0
+	}
+	layer := (h[1] >> 1) & 0x03 // 1 Layer III，2 Layer II
+	brIdx, srIdx := int(h[2]>>4), int(h[2]>>2)&0x03
+	if brIdx == 0 || brIdx == 15 || srIdx == 3 {
+		return 0, 0, 0
+	}
+	sr := mpegRates[v][srIdx]
+	pad := int(h[2]>>1) & 0x01
+
+	switch {
+	case layer == 1 && v == 0:
+		return 144*mpeg1L3Bitrates[brIdx]*1000/sr + pad, 1152, sr
+	case layer == 1:
+		return 72*mpeg2Bitrates[brIdx]*1000/sr + pad, 576, sr
+	case layer == 2 && v == 0:
+		return 144*mpeg1L2Bitrates[brIdx]*1000/sr + pad, 1152, sr
+	case layer == 2:
+		return 144*mpeg2Bitrates[brIdx]*1000/sr + pad, 1152, sr
+	}
+	return 0, 0, 0
+}
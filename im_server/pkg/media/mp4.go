@@ -0,0 +1,182 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// maxMoovSize moov 盒子的大小上限，只保存索引，正常的视频远小于这个值
+const maxMoovSize = 64 << 20
+
+// probeMP4 解析 MP4、MOV、M4A 等 ISO BMFF 容器
+// 时长取自 moov/mvhd，分片 MP4 取自 moov/mvex/mehd；画面尺寸取自第一个视频轨道的 tkhd
+func probeMP4(r io.ReaderAt, size int64) (Info, error) {
+	moov, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return Info{}, err
+	}
+	if moov.size > maxMoovSize {
+		return Info{}, ErrFormat
+	}
+	data := make([]byte, moov.size)
+	if _, err = r.ReadAt(data, moov.offset); err != nil {
+		return Info{}, err
+	}
+
+	var (
+		info                Info
+		timescale, duration int64
+	)
+	for _, b := range boxes(data) {
+		switch b.typ {
+		case "mvhd":
+			timescale, duration = mvhd(b.data)
+		case "mvex":
+			for _, c := range boxes(b.data) {
+				if c.typ == "mehd" && duration == 0 {
+					duration = mehd(c.data)
+				}
+			}
+		case "trak":
+			if info.Width > 0 {
+				continue
+			}
+			for _, c := range boxes(b.data) {
+				if c.typ == "tkhd" {
+					info.Width, info.Height = tkhdSize(c.data)
+				}
+			}
+		}
+	}
+	info.Duration = samples(duration, timescale)
+	return info, nil
+}
+
+// span 盒子内容在文件中的位置，不包含盒子头
+type span struct {
+	offset int64
+	size   int64
+}
+
+// findBox 在 [start, end) 范围内查找顶层盒子，跳过 mdat 等数据盒子时不读取内容
+func findBox(r io.ReaderAt, start, end int64, typ string) (span, error) {
+	head := make([]byte, 16)
+	for off := start; off+8 <= end; {
+		if _, err := r.ReadAt(head[:8], off); err != nil {
+			return span{}, err
+		}
+		size := int64(binary.BigEndian.Uint32(head))
+		hdr := int64(8)
+		switch size {
+		case 0: // 一直到文件结束
+			size = end - off
+		case 1: // 64 位长度
+			if _, err := r.ReadAt(head[8:16], off+8); err != nil {
+				return span{}, err
+			}
+			size = int64(binary.BigEndian.Uint64(head[8:]))
+			hdr = 16
+		}
+		if size < hdr || off+size > end {
+			return span{}, ErrFormat
+		}
+		if string(head[4:8]) == typ {
+			return span{offset: off + hdr, size: size - hdr}, nil
+		}
+		off += size
+	}
+	return span{}, ErrFormat
+}
+
+type box struct {
+	typ  string
+	data []byte
+}
+
+// boxes 拆分内存中的一层盒子，格式错误时返回已经拆分出来的部分
+func boxes(data []byte) []box {
+	var res []box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		hdr := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return res
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			hdr = 16
+		}
+		if size < hdr || size > uint64(len(data)) {
+			return res
+		}
+		res = append(res, box{typ: string(data[4:8]), data: data[hdr:size]})
+		data = data[size:]
+	}
+	return res
+}
+
+// mehd 读取分片 MP4 的总时长，版本 0 为 32 位，版本 1 为 64 位，时间单位和 mvhd 相同
+func mehd(d []byte) int64 {
+	switch {
+	case len(d) >= 12 && d[0] == 1:
+		return int64(binary.BigEndian.Uint64(d[4:]))
+	case len(d) >= 8:
+		return int64(binary.BigEndian.Uint32(d[4:]))
+	}
+	return 0
+}
+
+// mvhd 读取影片的时间单位和时长
+// 版本 0：创建时间、修改时间 32 位，时间单位 32 位，时长 32 位；版本 1：创建时间、修改时间、时长为 64 位
+func mvhd(d []byte) (int64, int64) {
+	if len(d) < 4 {
+		return 0, 0
+	}
+	tsOff := 12
+	if d[0] == 1 {
+		tsOff = 20
+	}
+	if len(d) < tsOff+4 {
+		return 0, 0
+	}
+	timescale := int64(binary.BigEndian.Uint32(d[tsOff:]))
+	var duration int64
+	if d[0] == 1 {
+		if len(d) >= tsOff+12 {
+			duration = int64(binary.BigEndian.Uint64(d[tsOff+4:]))
+		}
+	} else if len(d) >= tsOff+8 {
+		duration = int64(binary.BigEndian.Uint32(d[tsOff+4:]))
+	}
+	return timescale, duration
+}
+
+// tkhdSize 读取轨道的显示尺寸，音频轨道为 0
+// 宽高为 16.16 定点数，位于变换矩阵之后；矩阵表示旋转 90 度或 270 度时交换宽高，和播放时看到的方向一致
+func tkhdSize(d []byte) (int, int) {
+	if len(d) < 4 {
+		return 0, 0
+	}
+	// 版本 0：版本和标志 4，创建时间、修改时间、轨道ID、保留、时长各 4；版本 1 中三个时间字段为 8
+	off := 4 + 20
+	if d[0] == 1 {
+		off = 4 + 32
+	}
+	// 保留 8，层 2，分组 2，音量 2，保留 2
+	matrix := off + 16
+	size := matrix + 36
+	if len(d) < size+8 {
+		return 0, 0
+	}
+	w := int(binary.BigEndian.Uint32(d[size:]) >> 16)
+	h := int(binary.BigEndian.Uint32(d[size+4:]) >> 16)
+	a := int32(binary.BigEndian.Uint32(d[matrix:]))
+	b := int32(binary.BigEndian.Uint32(d[matrix+4:]))
+	if a == 0 && b != 0 {
+		w, h = h, w
+	}
+	return w, h
+}
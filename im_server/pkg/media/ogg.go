@@ -0,0 +1,76 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// opusRate Opus 的颗粒位置固定以 48kHz 计
+const opusRate = 48000
+
+// probeOgg 解析 Ogg 容器中的 Opus 和 Vorbis 音频
+// 时长为第一个逻辑流最后一页的颗粒位置（采样数）除以采样率，Opus 还要减去头部声明的预跳过采样数
+func probeOgg(r io.ReaderAt, size int64) (Info, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	var (
+		serial   uint32
+		rate     int64
+		preSkip  int64
+		granule  int64
+		first    = true
+		head     = make([]byte, 27)
+		segTable = make([]byte, 255)
+	)
+	for {
+		if _, err := io.ReadFull(br, head); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return Info{}, err
+		}
+		if string(head[:4]) != "OggS" {
+			return Info{}, ErrFormat
+		}
+		n := int(head[26])
+		if _, err := io.ReadFull(br, segTable[:n]); err != nil {
+			return Info{}, ErrFormat
+		}
+		bodySize := 0
+		for _, l := range segTable[:n] {
+			bodySize += int(l)
+		}
+		pageSerial := binary.LittleEndian.Uint32(head[14:])
+		pageGranule := int64(binary.LittleEndian.Uint64(head[6:]))
+
+		if first {
+			// 第一页是编码的识别头
+			body := make([]byte, bodySize)
+			if _, err := io.ReadFull(br, body); err != nil {
+				return Info{}, ErrFormat
+			}
+			switch {
+			case bytes.HasPrefix(body, []byte("OpusHead")) && len(body) >= 12:
+				rate = opusRate
+				preSkip = int64(binary.LittleEndian.Uint16(body[10:]))
+			case bytes.HasPrefix(body, []byte("\x01vorbis")) && len(body) >= 16:
+				rate = int64(binary.LittleEndian.Uint32(body[12:]))
+			default:
+				return Info{}, ErrFormat
+			}
+			serial = pageSerial
+			first = false
+			continue
+		}
+		// 颗粒位置为 -1 表示这一页没有结束的数据包
+		if pageSerial == serial && pageGranule > 0 {
+			granule = pageGranule
+		}
+		if _, err := br.Discard(bodySize); err != nil {
+			break
+		}
+	}
+	return Info{Duration: samples(max(granule-preSkip, 0), rate)}, nil
+}
@@ -0,0 +1,43 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// probeWAV 解析 RIFF WAVE，时长为 data 块的长度除以 fmt 块中的每秒字节数
+func probeWAV(r io.ReaderAt, size int64) (Info, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return Info{}, ErrFormat
+	}
+	if string(head[:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return Info{}, ErrFormat
+	}
+	var byteRate, dataSize int64
+	chunk := make([]byte, 16)
+	for off := int64(12); off+8 <= size; {
+		if _, err := r.ReadAt(chunk[:8], off); err != nil {
+			return Info{}, ErrFormat
+		}
+		n := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if n < 16 {
+				return Info{}, ErrFormat
+			}
+			if _, err := r.ReadAt(chunk, off+8); err != nil {
+				return Info{}, ErrFormat
+			}
+			byteRate = int64(binary.LittleEndian.Uint32(chunk[8:12]))
+		case "data":
+			// 录音中的程序可能还没有写入长度，按文件剩余部分计算
+			dataSize = min(n, size-off-8)
+		}
+		off += 8 + n + n%2
+	}
+	if byteRate == 0 {
+		return Info{}, ErrFormat
+	}
+	return Info{Duration: samples(dataSize, byteRate)}, nil
+}
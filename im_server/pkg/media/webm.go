@@ -0,0 +1,326 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Matroska / WebM 中用到的元素ID
+const (
+	idEBML          = 0x1A45DFA3
+	idSegment       = 0x18538067
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTracks        = 0x1654AE6B
+	idTrackEntry    = 0xAE
+	idVideo         = 0xE0
+	idPixelWidth    = 0xB0
+	idPixelHeight   = 0xBA
+	idCluster       = 0x1F43B675
+	idTimecode      = 0xE7
+	idSimpleBlock   = 0xA3
+	idBlockGroup    = 0xA0
+	idBlock         = 0xA1
+	idCues          = 0x1C53BB6B
+	idTags          = 0x1254C367
+	idSeekHead      = 0x114D9B74
+	idChapters      = 0x1043A770
+	idAttachments   = 0x1941A469
+)
+
+// unknownSize 长度未知的元素，浏览器 MediaRecorder 录制的 WebM 中 Segment 和 Cluster 都是这种情况
+const unknownSize = -1
+
+// defaultTimecodeScale 时间戳的默认单位（纳秒）
+const defaultTimecodeScale = 1000000
+
+type element struct {
+	id     uint32
+	offset int64 // 内容开始的位置
+	size   int64 // 内容长度，长度未知时为 unknownSize
+}
+
+func (e element) end(limit int64) int64 {
+	if e.size == unknownSize || e.offset+e.size > limit {
+		return limit
+	}
+	return e.offset + e.size
+}
+
+// isTopLevel Segment 下的一级元素，用来判断长度未知的 Cluster 在哪里结束
+func isTopLevel(id uint32) bool {
+	switch id {
+	case idInfo, idTracks, idCluster, idCues, idTags, idSeekHead, idChapters, idAttachments:
+		return true
+	}
+	return false
+}
+
+// probeWebM 解析 WebM 和 Matroska 容器
+// 时长优先取 Info 中的 Duration，实时录制的文件没有 Duration，改为扫描所有 Cluster 取最大的帧时间戳
+func probeWebM(r io.ReaderAt, size int64) (Info, error) {
+	e, err := readElement(r, 0)
+	if err != nil || e.id != idEBML {
+		return Info{}, ErrFormat
+	}
+	seg, err := readElement(r, e.end(size))
+	if err != nil || seg.id != idSegment {
+		return Info{}, ErrFormat
+	}
+
+	var (
+		info     Info
+		scale    int64 = defaultTimecodeScale
+		duration float64
+		maxTC    int64
+	)
+	end := seg.end(size)
+	for off := seg.offset; off < end; {
+		e, err = readElement(r, off)
+		if err != nil {
+			break
+		}
+		switch e.id {
+		case idInfo:
+			scale, duration, err = webmInfo(r, e, end)
+		case idTracks:
+			info.Width, info.Height, err = webmTracks(r, e, end)
+		case idCluster:
+			if duration > 0 {
+				off = end
+				continue
+			}
+			var tc, next int64
+			tc, next, err = webmCluster(r, e, end)
+			maxTC = max(maxTC, tc)
+			if err == nil {
+				off = next
+				continue
+			}
+		}
+		if err != nil {
+			return Info{}, err
+		}
+		if e.size == unknownSize {
+			return Info{}, ErrFormat
+		}
+		off = e.end(end)
+	}
+
+	if duration > 0 {
+		info.Duration = time.Duration(duration * float64(scale))
+	} else {
+		info.Duration = time.Duration(maxTC * scale)
+	}
+	return info, nil
+}
+
+// webmInfo 读取时间戳单位和时长，时长以时间戳单位计，是浮点数
+func webmInfo(r io.ReaderAt, e element, limit int64) (int64, float64, error) {
+	scale, duration := int64(defaultTimecodeScale), 0.0
+	err := children(r, e, limit, func(c element) error {
+		switch c.id {
+		case idTimecodeScale:
+			v, err := readUint(r, c)
+			if err != nil {
+				return err
+			}
+			if v > 0 {
+				scale = int64(v)
+			}
+		case idDuration:
+			v, err := readFloat(r, c)
+			if err != nil {
+				return err
+			}
+			duration = v
+		}
+		return nil
+	})
+	return scale, duration, err
+}
+
+// webmTracks 读取第一个视频轨道的画面尺寸
+func webmTracks(r io.ReaderAt, e element, limit int64) (int, int, error) {
+	var w, h uint64
+	err := children(r, e, limit, func(entry element) error {
+		if entry.id != idTrackEntry || w > 0 {
+			return nil
+		}
+		return children(r, entry, limit, func(v element) error {
+			if v.id != idVideo {
+				return nil
+			}
+			return children(r, v, limit, func(c element) error {
+				var err error
+				switch c.id {
+				case idPixelWidth:
+					w, err = readUint(r, c)
+				case idPixelHeight:
+					h, err = readUint(r, c)
+				}
+				return err
+			})
+		})
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	// 尺寸是无符号整数，文件损坏时可能超出 int 的范围
+	if w > math.MaxInt32 || h > math.MaxInt32 {
+		return 0, 0, ErrFormat
+	}
+	return int(w), int(h), nil
+}
+
+// webmCluster 返回 Cluster 中最大的帧时间戳和下一个一级元素的位置
+func webmCluster(r io.ReaderAt, e element, limit int64) (int64, int64, error) {
+	var base, maxTC int64
+	end := e.end(limit)
+	off := e.offset
+	for off < end {
+		c, err := readElement(r, off)
+		if err != nil {
+			break
+		}
+		// 长度未知的 Cluster 遇到下一个一级元素时结束
+		if e.size == unknownSize && isTopLevel(c.id) {
+			return maxTC, off, nil
+		}
+		if c.size == unknownSize {
+			return 0, 0, ErrFormat
+		}
+		switch c.id {
+		case idTimecode:
+			v, err := readUint(r, c)
+			if err != nil {
+				return 0, 0, err
+			}
+			base = int64(v)
+		case idSimpleBlock:
+			maxTC = max(maxTC, base+blockTimecode(r, c))
+		case idBlockGroup:
+			err = children(r, c, end, func(b element) error {
+				if b.id == idBlock {
+					maxTC = max(maxTC, base+blockTimecode(r, b))
+				}
+				return nil
+			})
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		off = c.end(end)
+	}
+	return maxTC, end, nil
+}
+
+// blockTimecode 帧相对于 Cluster 的时间戳，位于轨道号之后，是 16 位有符号数
+func blockTimecode(r io.ReaderAt, e element) int64 {
+	buf := make([]byte, 10)
+	n, _ := r.ReadAt(buf, e.offset)
+	if n < 1 {
+		return 0
+	}
+	l := bits.LeadingZeros8(buf[0]) + 1
+	if l > 8 || n < l+2 {
+		return 0
+	}
+	return int64(int16(binary.BigEndian.Uint16(buf[l:])))
+}
+
+// children 依次处理长度已知的元素的子元素
+func children(r io.ReaderAt, e element, limit int64, fn func(element) error) error {
+	if e.size == unknownSize {
+		return ErrFormat
+	}
+	end := e.end(limit)
+	for off := e.offset; off < end; {
+		c, err := readElement(r, off)
+		if err != nil {
+			return err
+		}
+		if c.size == unknownSize {
+			return ErrFormat
+		}
+		if err = fn(c); err != nil {
+			return err
+		}
+		off = c.end(end)
+	}
+	return nil
+}
+
+// readElement 读取 off 位置的元素ID和长度，ID 保留长度标记位，长度去掉标记位
+func readElement(r io.ReaderAt, off int64) (element, error) {
+	buf := make([]byte, 12)
+	n, err := r.ReadAt(buf, off)
+	if n == 0 {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = ErrFormat
+		}
+		return element{}, err
+	}
+	buf = buf[:n]
+
+	idLen := bits.LeadingZeros8(buf[0]) + 1
+	if idLen > 4 || len(buf) < idLen+1 {
+		return element{}, ErrFormat
+	}
+	var id uint32
+	for _, b := range buf[:idLen] {
+		id = id<<8 | uint32(b)
+	}
+
+	sizeLen := bits.LeadingZeros8(buf[idLen]) + 1
+	if sizeLen > 8 || len(buf) < idLen+sizeLen {
+		return element{}, ErrFormat
+	}
+	size := uint64(buf[idLen] & (0xFF >> sizeLen))
+	allOnes := size == uint64(0xFF>>sizeLen)
+	for _, b := range buf[idLen+1 : idLen+sizeLen] {
+		size = size<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	e := element{id: id, offset: off + int64(idLen+sizeLen), size: int64(size)}
+	if allOnes {
+		e.size = unknownSize
+	} else if size > math.MaxInt64/2 {
+		return element{}, ErrFormat
+	}
+	return e, nil
+}
+
+func readUint(r io.ReaderAt, e element) (uint64, error) {
+	if e.size > 8 {
+		return 0, ErrFormat
+	}
+	buf := make([]byte, e.size)
+	if _, err := r.ReadAt(buf, e.offset); err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+func readFloat(r io.ReaderAt, e element) (float64, error) {
+	if e.size != 4 && e.size != 8 {
+		return 0, ErrFormat
+	}
+	buf := make([]byte, e.size)
+	if _, err := r.ReadAt(buf, e.offset); err != nil {
+		return 0, err
+	}
+	if e.size == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(buf)), nil
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamChunkSize INSTREAM 每个数据块的大小，需要小于 clamd 的 StreamMaxLength
+const clamChunkSize = 64 * 1024
+
+// ClamAV 通过 clamd 的 INSTREAM 命令扫描文件内容
+// clamd 通常监听本地的 unix socket，例如 /var/run/clamav/clamd.ctl，也可以使用 tcp
+type ClamAV struct {
+	Network string
+	Addr    string
+	Timeout time.Duration // 单个文件的扫描超时
+}
+
+func NewClamAV(network, addr string, timeout time.Duration) *ClamAV {
+	return &ClamAV{
+		Network: network,
+		Addr:    addr,
+		Timeout: timeout,
+	}
+}
+
+// Scan 发送 zINSTREAM 命令，文件内容按 4 字节大端长度加数据分块发送，长度为 0 的块表示结束
+// clamd 返回 "stream: OK" 表示没有问题，"stream: <病毒名> FOUND" 表示发现病毒，其他返回视为扫描失败
+func (c *ClamAV) Scan(ctx context.Context, obj Object) (Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.Network, c.Addr)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, err
+	}
+	buf := make([]byte, 4+clamChunkSize)
+	for {
+		n, rerr := io.ReadFull(obj.Content, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err = conn.Write(buf[:4+n]); err != nil {
+				// 超过 StreamMaxLength 时 clamd 会提前返回错误并关闭连接，读取返回的原因
+				return c.reply(conn, err)
+			}
+		}
+		if errors.Is(rerr, io.EOF) || errors.Is(rerr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if rerr != nil {
+			return Result{}, rerr
+		}
+	}
+	if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, err
+	}
+	return c.reply(conn, nil)
+}
+
+// reply 读取并解析 clamd 的返回，writeErr 为发送内容时的错误，读不到返回时作为扫描失败的原因
+func (c *ClamAV) reply(conn net.Conn, writeErr error) (Result, error) {
+	data, err := io.ReadAll(io.LimitReader(conn, 1024))
+	if len(data) == 0 {
+		if writeErr != nil {
+			return Result{}, writeErr
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return Result{}, err
+	}
+	msg := strings.TrimSpace(string(bytes.TrimRight(data, "\x00")))
+	msg = strings.TrimPrefix(msg, "stream: ")
+	switch {
+	case msg == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(msg, " FOUND"):
+		return Result{Blocked: true, Reason: strings.TrimSuffix(msg, " FOUND")}, nil
+	}
+	return Result{}, fmt.Errorf("clamd: %s", msg)
+}
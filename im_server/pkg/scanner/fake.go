@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// EICAR 反病毒软件通用的测试字符串，不是真正的病毒，各个扫描器都会识别为病毒
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!H+H*`
+
+// Nop 不做任何检查
+type Nop struct{}
+
+func NewNop() Nop {
+	return Nop{}
+}
+
+func (Nop) Scan(ctx context.Context, obj Object) (Result, error) {
+	return Result{}, nil
+}
+
+// Fake 本地测试用的扫描器，内容包含任意一个特征串时拦截，不需要安装 ClamAV
+// 会把整个文件读入内存，只用于开发和测试环境
+type Fake struct {
+	Signatures [][]byte
+}
+
+// NewFake 没有指定特征串时使用 EICAR 测试字符串
+func NewFake(signatures ...string) *Fake {
+	if len(signatures) == 0 {
+		signatures = []string{EICAR}
+	}
+	f := &Fake{}
+	for _, s := range signatures {
+		f.Signatures = append(f.Signatures, []byte(s))
+	}
+	return f
+}
+
+func (f *Fake) Scan(ctx context.Context, obj Object) (Result, error) {
+	data, err := io.ReadAll(obj.Content)
+	if err != nil {
+		return Result{}, err
+	}
+	for _, sig := range f.Signatures {
+		if bytes.Contains(data, sig) {
+			return Result{Blocked: true, Reason: "包含测试特征串"}, nil
+		}
+	}
+	return Result{}, nil
+}
@@ -0,0 +1,23 @@
+package scanner
+
+import "context"
+
+// MimeAllowlist 只允许列表中的文件类型，类型由上传时根据文件内容识别，不读取文件内容
+type MimeAllowlist struct {
+	mimes map[string]struct{}
+}
+
+func NewMimeAllowlist(mimes []string) *MimeAllowlist {
+	m := make(map[string]struct{}, len(mimes))
+	for _, t := range mimes {
+		m[t] = struct{}{}
+	}
+	return &MimeAllowlist{mimes: m}
+}
+
+func (s *MimeAllowlist) Scan(ctx context.Context, obj Object) (Result, error) {
+	if _, ok := s.mimes[obj.MimeType]; ok {
+		return Result{}, nil
+	}
+	return Result{Blocked: true, Reason: "不允许的文件类型 " + obj.MimeType}, nil
+}
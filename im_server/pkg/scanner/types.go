@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Object 待扫描的文件，Content 支持 Seek，多个扫描器依次从头读取
+type Object struct {
+	Name     string // 原始文件名
+	MimeType string // 根据文件内容识别的类型
+	Size     int64
+	Content  io.ReadSeeker
+}
+
+// Result 扫描结果
+type Result struct {
+	Blocked bool   // 是否拦截
+	Reason  string // 拦截原因，例如病毒名称
+}
+
+// Scanner 上传文件的内容安全检查
+// 文件有问题时返回 Blocked 的结果；扫描本身失败（例如扫描服务不可用）时返回错误，调用方稍后重试
+type Scanner interface {
+	Scan(ctx context.Context, obj Object) (Result, error)
+}
+
+// chain 依次执行多个扫描器，任意一个拦截就不再执行后面的
+type chain []Scanner
+
+// Chain 组合多个扫描器，便宜的检查放在前面
+func Chain(scanners ...Scanner) Scanner {
+	return chain(scanners)
+}
+
+func (c chain) Scan(ctx context.Context, obj Object) (Result, error) {
+	for _, s := range c {
+		if _, err := obj.Content.Seek(0, io.SeekStart); err != nil {
+			return Result{}, err
+		}
+		res, err := s.Scan(ctx, obj)
+		if err != nil || res.Blocked {
+			return res, err
+		}
+	}
+	return Result{}, nil
+}
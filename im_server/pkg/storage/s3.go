@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Config S3 兼容存储的配置，本地可以使用 MinIO 测试
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"` // 不带协议，如 127.0.0.1:9000
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	UseSSL    bool   `yaml:"useSSL"`
+	BaseURL   string `yaml:"baseURL"` // 公开访问地址前缀，为空时使用 Endpoint/Bucket
+}
+
+// S3Storage S3 兼容存储实现
+type S3Storage struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+func NewS3Storage(c S3Config) (*S3Storage, error) {
+	client, err := minio.New(c.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(c.AccessKey, c.SecretKey, ""),
+		Secure: c.UseSSL,
+		Region: c.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		scheme := "http://"
+		if c.UseSSL {
+			scheme = "https://"
+		}
+		baseURL = scheme + c.Endpoint + "/" + c.Bucket
+	}
+	return &S3Storage{
+		client:  client,
+		bucket:  c.Bucket,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.key(key), r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Get GetObject 不会立即发出请求，先 Stat 一次，对象不存在时返回 ErrNotExist
+func (s *S3Storage) Get(ctx context.Context, key string) (Object, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.key(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.convertErr(err)
+	}
+	oi, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		return nil, s.convertErr(err)
+	}
+	return &s3Object{Object: obj, info: s.info(key, oi)}, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, s.key(key), minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	oi, err := s.client.StatObject(ctx, s.bucket, s.key(key), minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, s.convertErr(err)
+	}
+	return s.info(key, oi), nil
+}
+
+func (s *S3Storage) Presign(ctx context.Context, key string, expire time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, s.key(key), expire, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.baseURL + "/" + s.key(key)
+}
+
+// key 去掉开头的 / 和路径中的 ..，和本地实现保持一致
+func (s *S3Storage) key(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+func (s *S3Storage) info(key string, oi minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        oi.Size,
+		ContentType: oi.ContentType,
+		ModTime:     oi.LastModified,
+	}
+}
+
+func (s *S3Storage) convertErr(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotExist
+	}
+	return err
+}
+
+type s3Object struct {
+	*minio.Object
+	info ObjectInfo
+}
+
+func (o *s3Object) Info() ObjectInfo {
+	return o.info
+}
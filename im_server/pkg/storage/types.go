@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotExist 对象不存在
+var ErrNotExist = errors.New("对象不存在")
+
+// Storage 文件存储后端，key 是后端内的相对路径，统一使用 / 分隔
+type Storage interface {
+	// Put 保存对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，返回的 Object 支持 Seek，可以用来响应 Range 请求
+	Get(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, key string) error
+	// Stat 查询对象信息，不存在时返回 ErrNotExist
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Presign 生成有效期为 expire 的临时访问地址
+	Presign(ctx context.Context, key string, expire time.Duration) (string, error)
+	// URL 公开访问地址，只用于头像等不需要鉴权的文件
+	URL(key string) string
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Object 读取中的对象
+type Object interface {
+	io.ReadSeekCloser
+	Info() ObjectInfo
+}
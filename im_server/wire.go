@@ -3,45 +3,81 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"github.com/ink-yht/im/internal/job"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/repository/dao/chat_dao"
 	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"github.com/ink-yht/im/internal/repository/dao/group_dao"
+	"github.com/ink-yht/im/internal/repository/dao/search_dao"
 	"github.com/ink-yht/im/internal/repository/dao/user_dao"
 	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/repository/search_repo"
 	"github.com/ink-yht/im/internal/repository/user_repo"
+	"github.com/ink-yht/im/internal/service/chat_service"
 	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/group_service"
+	"github.com/ink-yht/im/internal/service/push_service"
 	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web/chat_web"
 	"github.com/ink-yht/im/internal/web/file_web"
+	"github.com/ink-yht/im/internal/web/group_web"
 	"github.com/ink-yht/im/internal/web/user_web"
+	"github.com/ink-yht/im/internal/web/ws_web"
 	"github.com/ink-yht/im/ioc"
 )
 
-func InitWebServer() *gin.Engine {
+func InitApp() *App {
 	wire.Build(
 		// 最基础的第三方依赖
-		ioc.InitDB, ioc.InitLogger,
+		ioc.InitDB, ioc.InitLogger, ioc.InitStorage, ioc.InitScanner,
 
 		// DAO 部分
 		user_dao.NewUserDAO,
+		user_dao.NewFriendDAO,
 		file_dao.NewFileDAO,
+		group_dao.NewGroupDAO,
+		chat_dao.NewChatDAO,
+		search_dao.NewSearchDAO,
 
 		// cache 部分
 
 		// repository 部分
 		user_repo.NewUserRepository,
+		user_repo.NewFriendRepository,
 		file_repo.NewFileRepository,
+		group_repo.NewGroupRepository,
+		chat_repo.NewChatRepository,
+		search_repo.NewSearchRepository,
 
 		// service 部分
 		user_service.NewUserService,
 		file_service.NewFileService,
+		file_service.NewURLSigner,
+		push_service.NewPushService,
+		chat_service.NewChatService,
+		group_service.NewGroupService,
 
 		// Handler 部分
 		user_web.NewUserHandler,
 		file_web.NewFileHandler,
+		chat_web.NewChatHandler,
+		group_web.NewGroupHandler,
+		ws_web.NewWsHandler,
+
+		// 后台任务
+		job.NewPurgeChatJob,
+		job.NewPurgeUploadsJob,
+		job.NewCollectFilesJob,
+		job.NewScanFilesJob,
+		ioc.InitScheduler,
 
 		// 中间件
 		ioc.InitWebServer,
 		ioc.InitMiddleWares,
+
+		wire.Struct(new(App), "*"),
 	)
-	return new(gin.Engine)
+	return new(App)
 }
@@ -7,15 +7,27 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"
+	"github.com/ink-yht/im/internal/job"
+	"github.com/ink-yht/im/internal/repository/chat_repo"
+	"github.com/ink-yht/im/internal/repository/dao/chat_dao"
 	"github.com/ink-yht/im/internal/repository/dao/file_dao"
+	"github.com/ink-yht/im/internal/repository/dao/group_dao"
+	"github.com/ink-yht/im/internal/repository/dao/search_dao"
 	"github.com/ink-yht/im/internal/repository/dao/user_dao"
 	"github.com/ink-yht/im/internal/repository/file_repo"
+	"github.com/ink-yht/im/internal/repository/group_repo"
+	"github.com/ink-yht/im/internal/repository/search_repo"
 	"github.com/ink-yht/im/internal/repository/user_repo"
+	"github.com/ink-yht/im/internal/service/chat_service"
 	"github.com/ink-yht/im/internal/service/file_service"
+	"github.com/ink-yht/im/internal/service/group_service"
+	"github.com/ink-yht/im/internal/service/push_service"
 	"github.com/ink-yht/im/internal/service/user_service"
+	"github.com/ink-yht/im/internal/web/chat_web"
 	"github.com/ink-yht/im/internal/web/file_web"
+	"github.com/ink-yht/im/internal/web/group_web"
 	"github.com/ink-yht/im/internal/web/user_web"
+	"github.com/ink-yht/im/internal/web/ws_web"
 	"github.com/ink-yht/im/ioc"
 )
 
@@ -25,18 +37,44 @@ import (
 
 // Injectors from wire.go:
 
-func InitWebServer() *gin.Engine {
+func InitApp() *App {
 	logger := ioc.InitLogger()
 	v := ioc.InitMiddleWares(logger)
 	db := ioc.InitDB(logger)
 	userDao := user_dao.NewUserDAO(db)
 	userRepository := user_repo.NewUserRepository(userDao)
-	userService := user_service.NewUserService(userRepository)
-	userHandler := user_web.NewUserHandler(userService, logger)
 	fileDao := file_dao.NewFileDAO(db)
 	fileRepository := file_repo.NewFileRepository(fileDao)
-	fileService := file_service.NewFileService(fileRepository)
+	groupDao := group_dao.NewGroupDAO(db)
+	groupRepository := group_repo.NewGroupRepository(groupDao)
+	chatDao := chat_dao.NewChatDAO(db)
+	chatRepository := chat_repo.NewChatRepository(chatDao)
+	storage := ioc.InitStorage()
+	urlSigner := file_service.NewURLSigner()
+	scanner := ioc.InitScanner()
+	fileService := file_service.NewFileService(fileRepository, groupRepository, chatRepository, storage, urlSigner, scanner)
+	userService := user_service.NewUserService(userRepository, fileService, logger)
+	userHandler := user_web.NewUserHandler(userService, logger)
 	fileHandler := file_web.NewFileHandler(fileService, logger)
-	engine := ioc.InitWebServer(v, userHandler, fileHandler)
-	return engine
+	friendDao := user_dao.NewFriendDAO(db)
+	friendRepository := user_repo.NewFriendRepository(friendDao)
+	searchDao := search_dao.NewSearchDAO(db)
+	searchRepository := search_repo.NewSearchRepository(searchDao)
+	pushService := push_service.NewPushService(logger)
+	chatService := chat_service.NewChatService(chatRepository, groupRepository, friendRepository, userRepository, searchRepository, fileRepository, urlSigner, pushService, logger)
+	chatHandler := chat_web.NewChatHandler(chatService, logger)
+	groupService := group_service.NewGroupService(groupRepository, friendRepository, pushService, fileService, logger)
+	groupHandler := group_web.NewGroupHandler(groupService, logger)
+	wsHandler := ws_web.NewWsHandler(pushService, logger)
+	engine := ioc.InitWebServer(v, userHandler, fileHandler, chatHandler, groupHandler, wsHandler, storage)
+	purgeChatJob := job.NewPurgeChatJob(chatService, logger)
+	purgeUploadsJob := job.NewPurgeUploadsJob(fileService, logger)
+	collectFilesJob := job.NewCollectFilesJob(fileService, logger)
+	scanFilesJob := job.NewScanFilesJob(fileService, logger)
+	scheduler := ioc.InitScheduler(logger, purgeChatJob, purgeUploadsJob, collectFilesJob, scanFilesJob)
+	app := &App{
+		server:    engine,
+		scheduler: scheduler,
+	}
+	return app
 }